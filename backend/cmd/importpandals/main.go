@@ -0,0 +1,125 @@
+// Command importpandals bulk-loads pandals from a CSV or GeoJSON file.
+// It applies the same validation, duplicate detection and batching as
+// POST /api/v1/admin/pandals/import.
+//
+//	go run ./cmd/importpandals -file pandals.csv -dry-run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/config"
+	"tirthankarkundu17/pandal-hopping-api/internal/importer"
+	"tirthankarkundu17/pandal-hopping-api/internal/repository"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+	"tirthankarkundu17/pandal-hopping-api/internal/validation"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run performs the import and returns the process exit code,
+// so deferred cleanup happens before the process exits
+func run() int {
+	path := flag.String("file", "", "CSV or GeoJSON file to import (required)")
+	formatName := flag.String("format", "", "csv or geojson (default: from the file extension)")
+	dryRun := flag.Bool("dry-run", false, "validate and report without writing")
+	onDuplicate := flag.String("on-duplicate", "skip", "what to do with rows matching an existing pandal: skip or update")
+	importedBy := flag.String("imported-by", "import-cli", "value recorded as createdBy on new pandals")
+	asJSON := flag.Bool("json", false, "print the full report as JSON")
+	flag.Parse()
+
+	if *path == "" {
+		flag.Usage()
+		return 2
+	}
+	if *onDuplicate != "skip" && *onDuplicate != "update" {
+		log.Fatalf("-on-duplicate must be skip or update, got %q", *onDuplicate)
+	}
+
+	var format importer.Format
+	var err error
+	if *formatName != "" {
+		format, err = importer.ParseFormat(*formatName)
+	} else {
+		format, err = importer.DetectFormat(*path, "")
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := validation.LoadAdministrativeData(); err != nil {
+		log.Fatalf("Could not load administrative data: %v", err)
+	}
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Error loading environment variables: %v", err)
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	records, err := importer.Parse(file, format)
+	file.Close()
+	if err != nil {
+		log.Fatalf("Could not parse %s: %v", *path, err)
+	}
+
+	client := config.ConnectDB()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		client.Disconnect(ctx)
+	}()
+
+	pandalRepo := repository.NewPandalRepository(config.GetCollection(client, "durgapuja"))
+	// The server's tile cache is out of reach here; its tiles expire on their own
+	importService := services.NewImportService(pandalRepo, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	report, err := importService.ImportPandals(ctx, records, services.ImportOptions{
+		DryRun:           *dryRun,
+		UpdateDuplicates: *onDuplicate == "update",
+		ImportedBy:       *importedBy,
+	})
+	if err != nil {
+		log.Printf("Import failed: %v", err)
+		return 1
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		for _, row := range report.Rows {
+			switch {
+			case len(row.Errors) > 0:
+				fmt.Printf("row %d %q: %s: %v\n", row.Row, row.Name, row.Action, row.Errors)
+			case row.DuplicateOf != "":
+				fmt.Printf("row %d %q: %s (duplicate of %s)\n", row.Row, row.Name, row.Action, row.DuplicateOf)
+			}
+		}
+		mode := ""
+		if report.DryRun {
+			mode = " (dry run, nothing written)"
+		}
+		fmt.Printf("%d rows: %d created, %d updated, %d skipped, %d failed%s\n",
+			report.Total, report.Created, report.Updated, report.Skipped, report.Failed, mode)
+	}
+
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -7,15 +7,21 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"tirthankarkundu17/pandal-hopping-api/internal/auth"
 	"tirthankarkundu17/pandal-hopping-api/internal/config"
 	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
+	"tirthankarkundu17/pandal-hopping-api/internal/lockout"
+	"tirthankarkundu17/pandal-hopping-api/internal/mailer"
+	"tirthankarkundu17/pandal-hopping-api/internal/middleware"
 	"tirthankarkundu17/pandal-hopping-api/internal/migrations"
 	"tirthankarkundu17/pandal-hopping-api/internal/repository"
 	"tirthankarkundu17/pandal-hopping-api/internal/routes"
 	"tirthankarkundu17/pandal-hopping-api/internal/services"
+	"tirthankarkundu17/pandal-hopping-api/internal/tiles"
 	"tirthankarkundu17/pandal-hopping-api/internal/validation"
 
 	"github.com/gin-contrib/cors"
@@ -23,6 +29,12 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// Map tile cache bounds; the TTL caps how stale ratings in cached tiles can get
+const (
+	tileCacheSize = 4096
+	tileCacheTTL  = 10 * time.Minute
+)
+
 func main() {
 	// Load administrative geographic data into memory
 	err := validation.LoadAdministrativeData()
@@ -36,6 +48,25 @@ func main() {
 		log.Printf("Error loading environment variables: %v", err)
 	}
 
+	// Load the token signing keys; refuses default secrets outside development
+	keys, err := auth.NewKeyManager(auth.ConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Fatal: could not load JWT keys: %v", err)
+	}
+	middleware.UseKeyManager(keys)
+
+	// Outgoing mail; refuses to run without an SMTP relay outside development
+	mail, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatalf("Fatal: could not set up outgoing mail: %v", err)
+	}
+
+	// OpenID Connect providers users may sign in with, from OIDC_PROVIDERS
+	oidcConfigs, err := auth.OIDCConfigsFromEnv()
+	if err != nil {
+		log.Fatalf("Fatal: invalid OpenID Connect configuration: %v", err)
+	}
+
 	// Connect to the Database
 	client := config.ConnectDB()
 
@@ -54,32 +85,82 @@ func main() {
 	userCollection := config.GetCollection(client, "users")
 	routeCollection := config.GetCollection(client, "routes")
 	foodStopCollection := config.GetCollection(client, "food_stops")
+	refreshTokenCollection := config.GetCollection(client, "refresh_tokens")
+	reviewCollection := config.GetCollection(client, "reviews")
+	emailTokenCollection := config.GetCollection(client, "email_tokens")
+	loginAttemptCollection := config.GetCollection(client, "login_attempts")
+	oidcSessionCollection := config.GetCollection(client, "oidc_sessions")
 
 	// Run Database Migrations
-	migrations.RunMigrations(pandalCollection, foodStopCollection)
+	migrations.RunMigrations(pandalCollection, foodStopCollection, refreshTokenCollection, reviewCollection, routeCollection, emailTokenCollection, loginAttemptCollection, userCollection, oidcSessionCollection)
+
+	// Encoded map tiles are shared by every service that moves a pin
+	tileCache := tiles.NewCache(tileCacheSize, tileCacheTTL)
 
 	// Initialize the dependency graph (Repository -> Service -> Handler)
+	userRepo := repository.NewUserRepository(userCollection)
+
 	pandalRepo := repository.NewPandalRepository(pandalCollection)
-	pandalService := services.NewPandalService(pandalRepo)
+	pandalService := services.NewPandalService(pandalRepo, userRepo, tileCache)
 	pandalHandler := handlers.NewPandalHandler(pandalService)
+	importService := services.NewImportService(pandalRepo, tileCache)
+	importHandler := handlers.NewImportHandler(importService)
 
-	userRepo := repository.NewUserRepository(userCollection)
-	authService := services.NewAuthService(userRepo)
-	authHandler := handlers.NewAuthHandler(authService)
+	reviewRepo := repository.NewReviewRepository(reviewCollection)
+	reviewService := services.NewReviewService(reviewRepo, pandalRepo)
+	reviewHandler := handlers.NewReviewHandler(reviewService)
 
 	routeRepo := repository.NewRouteRepository(routeCollection, pandalCollection)
-	routeService := services.NewRouteService(routeRepo)
+
+	// Failed logins are tracked in Mongo so lockouts hold across replicas, unless
+	// LOGIN_ATTEMPT_STORE=memory asks for a single instance store
+	var loginAttempts lockout.Store = lockout.NewMongoStore(loginAttemptCollection)
+	if os.Getenv("LOGIN_ATTEMPT_STORE") == "memory" {
+		loginAttempts = lockout.NewMemoryStore()
+	}
+
+	refreshTokenRepo := repository.NewRefreshTokenRepository(refreshTokenCollection)
+	emailTokenRepo := repository.NewEmailTokenRepository(emailTokenCollection)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, emailTokenRepo, mail, loginAttempts, keys)
+	authHandler := handlers.NewAuthHandler(authService)
+	oidcSessionRepo := repository.NewOIDCSessionRepository(oidcSessionCollection)
+	oidcService := services.NewOIDCService(userRepo, refreshTokenRepo, oidcSessionRepo, keys, oidcConfigs)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, os.Getenv("OIDC_APP_REDIRECT_URL"))
+	userService := services.NewUserService(userRepo, loginAttempts, refreshTokenRepo, pandalRepo, reviewRepo, routeRepo)
+	userHandler := handlers.NewUserHandler(userService)
+
+	// Promote the configured bootstrap admin, if any
+	bootstrapCtx, bootstrapCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := userService.BootstrapAdmin(bootstrapCtx); err != nil {
+		log.Printf("Error promoting bootstrap admin: %v", err)
+	}
+	bootstrapCancel()
+
+	routeService := services.NewRouteService(routeRepo, pandalRepo)
 	routeHandler := handlers.NewRouteHandler(routeService)
 
+	mergeService := services.NewPandalMergeService(pandalRepo, reviewRepo, routeRepo, tileCache)
+	mergeHandler := handlers.NewMergeHandler(mergeService)
+
 	foodStopRepo := repository.NewFoodStopRepository(foodStopCollection)
-	foodStopService := services.NewFoodStopService(foodStopRepo)
+	foodStopService := services.NewFoodStopService(foodStopRepo, tileCache)
 	foodStopHandler := handlers.NewFoodStopHandler(foodStopService)
 
+	tileService := services.NewTileService(pandalRepo, foodStopRepo, tileCache)
+	tileHandler := handlers.NewTileHandler(tileService)
+
 	locationHandler := handlers.NewLocationHandler()
+	jwksHandler := handlers.NewJWKSHandler(keys)
 
 	// Setup Gin router
 	router := gin.Default()
 
+	// Client IPs key the login lockouts, so X-Forwarded-For is only believed from
+	// the proxies listed in TRUSTED_PROXIES; by default none are trusted
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatalf("Fatal: invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// CORS — allow the Expo web dev server (and any origin in development)
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
@@ -95,10 +176,15 @@ func main() {
 
 	// Setup routes
 	routes.PandalRoute(apiGroup, pandalHandler)
-	routes.AuthRoute(apiGroup, authHandler)
+	routes.AuthRoute(apiGroup, authHandler, oidcHandler)
+	routes.UserRoute(apiGroup, userHandler)
 	routes.RouteRoute(apiGroup, routeHandler)
 	routes.FoodRoute(apiGroup, foodStopHandler)
 	routes.LocationRoute(apiGroup, locationHandler)
+	routes.AdminRoute(apiGroup, userHandler, importHandler, mergeHandler)
+	routes.ReviewRoute(apiGroup, reviewHandler)
+	routes.TileRoute(apiGroup, tileHandler)
+	routes.WellKnownRoute(&router.RouterGroup, jwksHandler)
 
 	// Default response
 	router.GET("/", func(c *gin.Context) {
@@ -154,3 +240,14 @@ func main() {
 
 	log.Println("Server exiting")
 }
+
+// trustedProxies reads the comma separated IPs and CIDRs of TRUSTED_PROXIES
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
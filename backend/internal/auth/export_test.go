@@ -0,0 +1,8 @@
+package auth
+
+// AgeKeys makes the provider's cached keys old enough for an unknown kid to refetch them
+func (p *OIDCProvider) AgeKeys() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keysFetched = p.keysFetched.Add(-jwksRefreshInterval)
+}
@@ -0,0 +1,451 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenKind tells apart the tokens the app signs. Each kind gets its own audience,
+// so a refresh token or an emailed link can never pass as an access token.
+type TokenKind string
+
+const (
+	KindAccess  TokenKind = "access"
+	KindRefresh TokenKind = "refresh"
+	KindEmail   TokenKind = "email"
+)
+
+const (
+	// minRSABits is the smallest RSA modulus accepted for signing or verification
+	minRSABits = 2048
+
+	// minSecretLength is the shortest HS256 secret accepted outside development
+	minSecretLength = 32
+
+	// clockLeeway absorbs clock skew between instances when checking exp and iat
+	clockLeeway = 30 * time.Second
+
+	// hmacKeyID is the kid of the shared secret, which is never published
+	hmacKeyID = "hs256"
+)
+
+// defaultSecrets are the placeholder secrets from earlier releases and .env.example
+var defaultSecrets = []string{"supersecretkey", "supersecretrefreshkey", "supersecretemailkey"}
+
+// legacyDefaults are the secrets earlier releases fell back to for each kind of token
+var legacyDefaults = map[TokenKind]string{
+	KindAccess:  defaultSecrets[0],
+	KindRefresh: defaultSecrets[1],
+	KindEmail:   defaultSecrets[2],
+}
+
+// Config says where the signing keys come from
+type Config struct {
+	// SigningKeyFile is a PEM encoded RSA or Ed25519 private key that signs new tokens
+	SigningKeyFile string
+	// VerificationKeyFiles are PEM keys, public or private, that are still accepted,
+	// such as the previous signing key during a rotation
+	VerificationKeyFiles []string
+	// Secret is the HS256 fallback used when no SigningKeyFile is configured. With a
+	// SigningKeyFile it only verifies, so tokens it signed keep working after the switch.
+	Secret   string
+	Issuer   string
+	Audience string
+	// DevMode allows the HS256 fallback with a weak or default secret
+	DevMode bool
+	// LegacySecrets are the HS256 secrets of each kind of token issued before tokens
+	// named their key, from JWT_SECRET, JWT_REFRESH_SECRET and JWT_EMAIL_SECRET
+	LegacySecrets map[TokenKind]string
+	// LegacyUntil is the RFC 3339 time until which such tokens, which have no kid,
+	// iss, aud or iat, are still accepted; they are refused when it is empty
+	LegacyUntil string
+}
+
+// ConfigFromEnv reads the key configuration from the environment
+func ConfigFromEnv() Config {
+	cfg := Config{
+		SigningKeyFile: os.Getenv("JWT_SIGNING_KEY_FILE"),
+		Secret:         os.Getenv("JWT_SECRET"),
+		Issuer:         os.Getenv("JWT_ISSUER"),
+		Audience:       os.Getenv("JWT_AUDIENCE"),
+		DevMode:        os.Getenv("APP_ENV") == "development",
+		LegacySecrets: map[TokenKind]string{
+			KindAccess:  os.Getenv("JWT_SECRET"),
+			KindRefresh: os.Getenv("JWT_REFRESH_SECRET"),
+			KindEmail:   os.Getenv("JWT_EMAIL_SECRET"),
+		},
+		LegacyUntil: os.Getenv("JWT_LEGACY_UNTIL"),
+	}
+	for _, file := range strings.Split(os.Getenv("JWT_VERIFICATION_KEY_FILES"), ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			cfg.VerificationKeyFiles = append(cfg.VerificationKeyFiles, file)
+		}
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = "pandal-hopping-api"
+	}
+	if cfg.Audience == "" {
+		cfg.Audience = "pandal-hopping-app"
+	}
+	return cfg
+}
+
+// verificationKey is a key tokens may be signed with, looked up by kid
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    any // *rsa.PublicKey, ed25519.PublicKey or the HMAC secret
+	jwk    *JWK
+}
+
+// KeyManager signs the app's tokens with the current key and verifies them against
+// every configured key, so keys can be rotated without logging anyone out
+type KeyManager struct {
+	signingKID    string
+	signingMethod jwt.SigningMethod
+	signingKey    any
+	keys          map[string]verificationKey
+	issuer        string
+	audience      string
+
+	// legacySecrets verify tokens without a kid, by kind, until legacyUntil
+	legacySecrets map[TokenKind][]byte
+	legacyUntil   time.Time
+}
+
+// NewKeyManager loads the configured keys. Outside DevMode it refuses to fall back to a
+// missing, short or default HS256 secret.
+func NewKeyManager(cfg Config) (*KeyManager, error) {
+	km := &KeyManager{
+		keys:     make(map[string]verificationKey),
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+	}
+
+	if cfg.SigningKeyFile == "" {
+		if err := checkSecret(cfg.Secret, cfg.DevMode); err != nil {
+			return nil, err
+		}
+		secret := cfg.Secret
+		if secret == "" {
+			secret = defaultSecrets[0]
+			log.Println("WARNING: no JWT signing key configured, using the development default secret")
+		}
+		km.signingKID = hmacKeyID
+		km.signingMethod = jwt.SigningMethodHS256
+		km.signingKey = []byte(secret)
+		km.keys[hmacKeyID] = verificationKey{method: jwt.SigningMethodHS256, key: []byte(secret)}
+	} else {
+		signer, err := loadKeyFile(cfg.SigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		private, ok := signer.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%s: signing key must be a private key", cfg.SigningKeyFile)
+		}
+		key, err := newVerificationKey(private.Public())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.SigningKeyFile, err)
+		}
+		km.signingKID = key.jwk.KeyID
+		km.signingMethod = key.method
+		km.signingKey = private
+		km.keys[key.jwk.KeyID] = key
+
+		// Tokens signed while the secret was in use stay valid until they expire
+		if cfg.Secret != "" {
+			if err := checkSecret(cfg.Secret, cfg.DevMode); err != nil {
+				log.Printf("WARNING: not accepting tokens signed with JWT_SECRET: %v", err)
+			} else {
+				km.keys[hmacKeyID] = verificationKey{method: jwt.SigningMethodHS256, key: []byte(cfg.Secret)}
+			}
+		}
+	}
+
+	for _, file := range cfg.VerificationKeyFiles {
+		loaded, err := loadKeyFile(file)
+		if err != nil {
+			return nil, err
+		}
+		public := loaded
+		if signer, ok := loaded.(crypto.Signer); ok {
+			public = signer.Public()
+		}
+		key, err := newVerificationKey(public)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		km.keys[key.jwk.KeyID] = key
+	}
+
+	if err := km.loadLegacySecrets(cfg); err != nil {
+		return nil, err
+	}
+	return km, nil
+}
+
+// loadLegacySecrets sets up the secrets of tokens issued before tokens named their key,
+// when LegacyUntil asks for them. A kind whose secret is unsafe is left out.
+func (km *KeyManager) loadLegacySecrets(cfg Config) error {
+	if cfg.LegacyUntil == "" {
+		return nil
+	}
+	until, err := time.Parse(time.RFC3339, cfg.LegacyUntil)
+	if err != nil {
+		return fmt.Errorf("JWT_LEGACY_UNTIL must be an RFC 3339 time: %w", err)
+	}
+
+	km.legacyUntil = until
+	km.legacySecrets = make(map[TokenKind][]byte)
+	for kind, def := range legacyDefaults {
+		secret := cfg.LegacySecrets[kind]
+		if secret == "" && cfg.DevMode {
+			secret = def
+		}
+		if err := checkSecret(secret, cfg.DevMode); err != nil {
+			log.Printf("WARNING: not accepting legacy %s tokens: %v", kind, err)
+			continue
+		}
+		km.legacySecrets[kind] = []byte(secret)
+	}
+	return nil
+}
+
+// checkSecret rejects an HS256 secret that is missing, a known default or too short,
+// unless running in development
+func checkSecret(secret string, devMode bool) error {
+	if devMode {
+		return nil
+	}
+	if secret == "" {
+		return errors.New("no JWT signing key configured: set JWT_SIGNING_KEY_FILE (or JWT_SECRET), or APP_ENV=development")
+	}
+	for _, def := range defaultSecrets {
+		if secret == def {
+			return errors.New("JWT_SECRET is a default placeholder; set a real secret or JWT_SIGNING_KEY_FILE")
+		}
+	}
+	if len(secret) < minSecretLength {
+		return fmt.Errorf("JWT_SECRET must be at least %d characters", minSecretLength)
+	}
+	return nil
+}
+
+// Sign adds the issuer, audience and issue time for kind to claims and signs them with
+// the current key, naming it in the kid header
+func (km *KeyManager) Sign(claims jwt.MapClaims, kind TokenKind) (string, error) {
+	claims["iss"] = km.issuer
+	claims["aud"] = km.audienceFor(kind)
+	claims["iat"] = time.Now().Unix()
+
+	token := jwt.NewWithClaims(km.signingMethod, claims)
+	token.Header["kid"] = km.signingKID
+	return token.SignedString(km.signingKey)
+}
+
+// Parse verifies a token of the given kind: its signature against the key named by
+// kid, the algorithm that key is for, and the exp, iat, iss and aud claims. Tokens
+// without a kid are legacy tokens, see parseLegacy.
+func (km *KeyManager) Parse(tokenString string, kind TokenKind) (jwt.MapClaims, error) {
+	if unverified, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{}); err == nil {
+		if _, ok := unverified.Header["kid"]; !ok {
+			return km.parseLegacy(tokenString, kind)
+		}
+	}
+
+	token, err := jwt.Parse(tokenString, km.keyFor,
+		jwt.WithIssuer(km.issuer),
+		jwt.WithAudience(km.audienceFor(kind)),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockLeeway),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	if _, ok := claims["iat"]; !ok {
+		return nil, errors.New("token has no issue time")
+	}
+	return claims, nil
+}
+
+// parseLegacy verifies a token issued before tokens named their key: HS256 with the
+// secret of its kind, an exp claim and nothing else, and only until legacyUntil. As the
+// secrets may be shared, claims only another kind carries are refused too.
+func (km *KeyManager) parseLegacy(tokenString string, kind TokenKind) (jwt.MapClaims, error) {
+	secret, ok := km.legacySecrets[kind]
+	if !ok || !time.Now().Before(km.legacyUntil) {
+		return nil, errors.New("token does not name its signing key")
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (any, error) { return secret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockLeeway),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, hasJTI := claims["jti"]
+	_, hasPurpose := claims["purpose"]
+	switch {
+	case kind == KindAccess && hasJTI, kind == KindRefresh && hasPurpose, kind == KindEmail && !hasPurpose:
+		return nil, fmt.Errorf("not a legacy %s token", kind)
+	}
+	return claims, nil
+}
+
+// keyFor finds the verification key named by the token's kid
+func (km *KeyManager) keyFor(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := km.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.key, nil
+}
+
+func (km *KeyManager) audienceFor(kind TokenKind) string {
+	if kind == KindAccess {
+		return km.audience
+	}
+	return km.audience + "/" + string(kind)
+}
+
+// JWK is the public half of a signing key in JSON Web Key form (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS returns the public keys tokens may be verified with, the current signing key
+// first. The HS256 secret is never published, so the set is empty in that mode.
+func (km *KeyManager) JWKS() []JWK {
+	jwks := []JWK{}
+	if key, ok := km.keys[km.signingKID]; ok && key.jwk != nil {
+		jwks = append(jwks, *key.jwk)
+	}
+	var previous []JWK
+	for kid, key := range km.keys {
+		if kid != km.signingKID && key.jwk != nil {
+			previous = append(previous, *key.jwk)
+		}
+	}
+	sort.Slice(previous, func(i, j int) bool { return previous[i].KeyID < previous[j].KeyID })
+	return append(jwks, previous...)
+}
+
+// newVerificationKey wraps an RSA or Ed25519 public key, deriving its kid
+func newVerificationKey(public crypto.PublicKey) (verificationKey, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	var key verificationKey
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSABits {
+			return key, fmt.Errorf("RSA keys must be at least %d bits", minRSABits)
+		}
+		key = verificationKey{method: jwt.SigningMethodRS256, key: pub, jwk: &JWK{
+			KeyType:   "RSA",
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			N:         b64(pub.N.Bytes()),
+			E:         b64(big.NewInt(int64(pub.E)).Bytes()),
+		}}
+	case ed25519.PublicKey:
+		key = verificationKey{method: jwt.SigningMethodEdDSA, key: pub, jwk: &JWK{
+			KeyType:   "OKP",
+			Algorithm: jwt.SigningMethodEdDSA.Alg(),
+			Curve:     "Ed25519",
+			X:         b64(pub),
+		}}
+	default:
+		return key, fmt.Errorf("unsupported key type %T, expected RSA or Ed25519", public)
+	}
+
+	key.jwk.Use = "sig"
+	key.jwk.KeyID = thumbprint(key.jwk)
+	return key, nil
+}
+
+// thumbprint is the RFC 7638 SHA-256 thumbprint of a JWK, used as its kid so the
+// same key gets the same kid on every instance
+func thumbprint(jwk *JWK) string {
+	// The required members in lexicographic order; json.Marshal sorts map keys
+	members := map[string]string{"kty": jwk.KeyType}
+	if jwk.KeyType == "RSA" {
+		members["e"] = jwk.E
+		members["n"] = jwk.N
+	} else {
+		members["crv"] = jwk.Curve
+		members["x"] = jwk.X
+	}
+	canonical, _ := json.Marshal(members)
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// loadKeyFile reads the first PEM key from file: a PKCS#8 or PKCS#1 private key, or
+// a PKIX or PKCS#1 public key
+func loadKeyFile(file string) (any, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", file)
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", file, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return key, nil
+}
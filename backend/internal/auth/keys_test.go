@@ -0,0 +1,143 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/auth"
+)
+
+const testSecret = "a-test-secret-that-is-long-enough-for-hs256"
+
+// writeSigningKey writes a fresh Ed25519 private key as PEM and returns its path
+func writeSigningKey(t *testing.T) string {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "jwt.pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func newKeys(t *testing.T, cfg auth.Config) *auth.KeyManager {
+	t.Helper()
+	cfg.Issuer, cfg.Audience = "test", "test"
+	keys, err := auth.NewKeyManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// legacyToken signs claims the way releases before key IDs did: HS256, no kid, no iss or aud
+func legacyToken(t *testing.T, claims jwt.MapClaims, secret string) string {
+	t.Helper()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestSecretVerifiesAfterSwitchingToKeyFile(t *testing.T) {
+	before := newKeys(t, auth.Config{Secret: testSecret})
+	token, err := before.Sign(jwt.MapClaims{"sub": "user", "exp": time.Now().Add(time.Hour).Unix()}, auth.KindAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := writeSigningKey(t)
+	after := newKeys(t, auth.Config{SigningKeyFile: keyFile, Secret: testSecret})
+	if _, err := after.Parse(token, auth.KindAccess); err != nil {
+		t.Fatalf("token signed with JWT_SECRET before the switch: %v", err)
+	}
+	if _, err := after.Parse(token, auth.KindRefresh); err == nil {
+		t.Fatal("an access token passed as a refresh token")
+	}
+
+	// Dropping the secret ends the transition
+	done := newKeys(t, auth.Config{SigningKeyFile: keyFile})
+	if _, err := done.Parse(token, auth.KindAccess); err == nil {
+		t.Fatal("token signed with JWT_SECRET accepted without it")
+	}
+}
+
+func TestWeakSecretIsNotKeptForVerification(t *testing.T) {
+	dev := newKeys(t, auth.Config{Secret: "supersecretkey", DevMode: true})
+	signed, err := dev.Sign(jwt.MapClaims{"sub": "user", "exp": time.Now().Add(time.Hour).Unix()}, auth.KindAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := newKeys(t, auth.Config{SigningKeyFile: writeSigningKey(t), Secret: "supersecretkey"})
+	if _, err := keys.Parse(signed, auth.KindAccess); err == nil {
+		t.Fatal("token signed with a placeholder secret was accepted")
+	}
+}
+
+func TestLegacyTokens(t *testing.T) {
+	const refreshSecret = "a-different-secret-for-refresh-tokens!!"
+	exp := time.Now().Add(time.Hour).Unix()
+	access := legacyToken(t, jwt.MapClaims{"sub": "user", "role": "user", "exp": exp}, testSecret)
+	refresh := legacyToken(t, jwt.MapClaims{"sub": "user", "jti": "1", "exp": exp}, refreshSecret)
+	email := legacyToken(t, jwt.MapClaims{"sub": "user", "jti": "2", "purpose": "verify_email", "exp": exp}, testSecret)
+
+	secrets := map[auth.TokenKind]string{auth.KindAccess: testSecret, auth.KindRefresh: refreshSecret, auth.KindEmail: testSecret}
+	cfg := auth.Config{
+		SigningKeyFile: writeSigningKey(t),
+		LegacySecrets:  secrets,
+		LegacyUntil:    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+	}
+	keys := newKeys(t, cfg)
+
+	if _, err := keys.Parse(access, auth.KindAccess); err != nil {
+		t.Errorf("legacy access token: %v", err)
+	}
+	if _, err := keys.Parse(refresh, auth.KindRefresh); err != nil {
+		t.Errorf("legacy refresh token: %v", err)
+	}
+	if _, err := keys.Parse(email, auth.KindEmail); err != nil {
+		t.Errorf("legacy email token: %v", err)
+	}
+
+	// The access and email secrets are shared, so the claims must tell them apart
+	if _, err := keys.Parse(email, auth.KindAccess); err == nil {
+		t.Error("legacy email token passed as an access token")
+	}
+	if _, err := keys.Parse(access, auth.KindEmail); err == nil {
+		t.Error("legacy access token passed as an email token")
+	}
+	if _, err := keys.Parse(refresh, auth.KindAccess); err == nil {
+		t.Error("legacy refresh token passed as an access token")
+	}
+
+	expired := legacyToken(t, jwt.MapClaims{"sub": "user", "exp": time.Now().Add(-time.Hour).Unix()}, testSecret)
+	if _, err := keys.Parse(expired, auth.KindAccess); err == nil {
+		t.Error("expired legacy token accepted")
+	}
+
+	cfg.LegacyUntil = time.Now().Add(-time.Minute).Format(time.RFC3339)
+	if _, err := newKeys(t, cfg).Parse(access, auth.KindAccess); err == nil {
+		t.Error("legacy token accepted after the transition window")
+	}
+	cfg.LegacyUntil = ""
+	if _, err := newKeys(t, cfg).Parse(access, auth.KindAccess); err == nil {
+		t.Error("legacy token accepted without a transition window")
+	}
+}
@@ -0,0 +1,482 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// oidcTimeout bounds each request to an identity provider
+	oidcTimeout = 10 * time.Second
+
+	// jwksRefreshInterval is the least time between refetches of a provider's keys
+	// prompted by an unknown kid, so forged tokens cannot hammer the provider
+	jwksRefreshInterval = time.Minute
+
+	// maxProviderResponse caps how much of a provider response is read
+	maxProviderResponse = 1 << 20
+)
+
+// idTokenAlgorithms are the ID token signing algorithms accepted from providers
+var idTokenAlgorithms = []string{"RS256", "ES256", "EdDSA"}
+
+// providerNamePattern keeps provider names safe to use in URLs and env var names
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// OIDCConfig describes one OpenID Connect identity provider
+type OIDCConfig struct {
+	Name         string // used in the login URLs, e.g. "google"
+	Issuer       string
+	ClientID     string
+	ClientSecret string // empty for public clients, which rely on PKCE alone
+	RedirectURL  string
+	Scopes       []string
+}
+
+// OIDCConfigsFromEnv reads the providers listed in OIDC_PROVIDERS. Each name NAME
+// is configured by OIDC_NAME_ISSUER, OIDC_NAME_CLIENT_ID, OIDC_NAME_CLIENT_SECRET and
+// optionally OIDC_NAME_SCOPES and OIDC_NAME_REDIRECT_URL.
+func OIDCConfigsFromEnv() ([]OIDCConfig, error) {
+	var configs []OIDCConfig
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !providerNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid OIDC provider name %q", name)
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg := OIDCConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			return nil, fmt.Errorf("OIDC provider %s needs %sISSUER and %sCLIENT_ID", name, prefix, prefix)
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+// Identity is what a verified ID token says about the user
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	AuthTime      time.Time // when the user authenticated at the provider
+}
+
+// discoveryDocument holds the parts of the provider metadata the flow needs
+type discoveryDocument struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported"`
+	TokenEndpointAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// OIDCProvider runs the authorization code flow with PKCE against one provider.
+// Discovery happens on first use, so a provider that is down at startup does not
+// keep the API from starting.
+type OIDCProvider struct {
+	config OIDCConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	discovery   *discoveryDocument
+	keys        map[string]verificationKey
+	keysFetched time.Time
+}
+
+// NewOIDCProvider creates a provider; client may be nil for a default with a timeout
+func NewOIDCProvider(config OIDCConfig, client *http.Client) *OIDCProvider {
+	if client == nil {
+		client = &http.Client{Timeout: oidcTimeout}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	} else if !slices.Contains(config.Scopes, "openid") {
+		config.Scopes = append([]string{"openid"}, config.Scopes...)
+	}
+	return &OIDCProvider{config: config, client: client}
+}
+
+// Name returns the provider's configured name
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+// NewPKCE returns a random code verifier and its S256 code challenge (RFC 7636)
+func NewPKCE() (verifier, challenge string, err error) {
+	verifier, err = RandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// RandomToken returns 32 random bytes, base64url encoded, for states, nonces and verifiers
+func RandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AuthCodeURL returns the provider URL the user is sent to for signing in
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint, err := url.Parse(doc.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	query := endpoint.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	endpoint.RawQuery = query.Encode()
+	return endpoint.String(), nil
+}
+
+// Exchange trades an authorization code and its PKCE verifier for the ID token and
+// verifies it, including that it carries nonce
+func (p *OIDCProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {verifier},
+	}
+	// client_secret_basic is the default; fall back to posting the secret when the
+	// provider says that is all it supports
+	useBasic := p.config.ClientSecret != "" && (len(doc.TokenEndpointAuthMethods) == 0 ||
+		slices.Contains(doc.TokenEndpointAuthMethods, "client_secret_basic"))
+	if p.config.ClientSecret != "" && !useBasic {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if useBasic {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(req, &body)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("token exchange failed: %s %s", body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return p.VerifyIDToken(ctx, body.IDToken, nonce)
+}
+
+// VerifyIDToken checks an ID token's signature against the provider's keys, its
+// issuer, audience, authorized party, expiry, issue time and nonce
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, rawToken, nonce string) (*Identity, error) {
+	token, err := jwt.Parse(rawToken, func(token *jwt.Token) (any, error) {
+		return p.keyFor(ctx, token)
+	},
+		jwt.WithValidMethods(idTokenAlgorithms),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockLeeway),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid ID token claims")
+	}
+
+	if got, _ := claims["nonce"].(string); got == "" || got != nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+	// With several audiences the token must name us as the party it was issued to
+	if audiences, _ := claims.GetAudience(); len(audiences) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.config.ClientID {
+			return nil, errors.New("ID token was issued to another client")
+		}
+	}
+
+	identity := &Identity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	// Some providers send email_verified as the string "true"
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	if identity.Subject == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+	// auth_time is only required when asked for, and the token was issued at sign-in otherwise
+	if authTime, ok := claims["auth_time"].(float64); ok {
+		identity.AuthTime = time.Unix(int64(authTime), 0)
+	} else if issuedAt, err := claims.GetIssuedAt(); err == nil && issuedAt != nil {
+		identity.AuthTime = issuedAt.Time
+	}
+	return identity, nil
+}
+
+// discover fetches and caches the provider's metadata
+func (p *OIDCProvider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(p.config.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var doc discoveryDocument
+	status, err := p.doJSON(req, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("OIDC discovery failed with status %d", status)
+	}
+
+	// Issuer mix-up protection: the document must be about the issuer we asked
+	if doc.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("OIDC discovery issuer %q does not match %q", doc.Issuer, p.config.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery document is missing endpoints")
+	}
+	if len(doc.CodeChallengeMethodsSupported) > 0 && !slices.Contains(doc.CodeChallengeMethodsSupported, "S256") {
+		return nil, errors.New("OIDC provider does not support S256 PKCE")
+	}
+
+	p.discovery = &doc
+	return p.discovery, nil
+}
+
+// keyFor finds the provider key that signed token, refetching the provider's JWKS
+// once when the kid is unknown, since that is how providers roll their keys
+func (p *OIDCProvider) keyFor(ctx context.Context, token *jwt.Token) (any, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.lookupKey(kid)
+	if !ok && time.Since(p.keysFetched) >= jwksRefreshInterval {
+		if err := p.fetchKeys(ctx, doc.JWKSURI); err != nil {
+			return nil, err
+		}
+		key, ok = p.lookupKey(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.key, nil
+}
+
+// lookupKey finds a key by kid, or the only key when the token names none; callers must hold mu
+func (p *OIDCProvider) lookupKey(kid string) (verificationKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// fetchKeys replaces the cached provider keys; callers must hold mu
+func (p *OIDCProvider) fetchKeys(ctx context.Context, jwksURI string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return err
+	}
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	status, err := p.doJSON(req, &set)
+	if err != nil {
+		return fmt.Errorf("fetching provider keys: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("fetching provider keys failed with status %d", status)
+	}
+
+	keys := make(map[string]verificationKey, len(set.Keys))
+	for _, raw := range set.Keys {
+		kid, key, err := parseJWK(raw)
+		if err != nil {
+			// Providers may publish keys for other uses or algorithms; skip those
+			continue
+		}
+		keys[kid] = key
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+	return nil
+}
+
+// doJSON sends req and decodes a JSON response body into v, returning the status code
+func (p *OIDCProvider) doJSON(req *http.Request, v any) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProviderResponse))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("invalid JSON from provider: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// parseJWK turns a provider's signing JWK into a verification key, returning its kid
+func parseJWK(raw json.RawMessage) (string, verificationKey, error) {
+	var jwk struct {
+		KeyType string `json:"kty"`
+		KeyID   string `json:"kid"`
+		Use     string `json:"use"`
+		Curve   string `json:"crv"`
+		N       string `json:"n"`
+		E       string `json:"e"`
+		X       string `json:"x"`
+		Y       string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &jwk); err != nil {
+		return "", verificationKey{}, err
+	}
+	if jwk.Use != "" && jwk.Use != "sig" {
+		return "", verificationKey{}, errors.New("not a signing key")
+	}
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch {
+	case jwk.KeyType == "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return "", verificationKey{}, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return "", verificationKey{}, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return "", verificationKey{}, errors.New("invalid RSA exponent")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+		if pub.N.BitLen() < minRSABits {
+			return "", verificationKey{}, fmt.Errorf("RSA keys must be at least %d bits", minRSABits)
+		}
+		return jwk.KeyID, verificationKey{method: jwt.SigningMethodRS256, key: pub}, nil
+
+	case jwk.KeyType == "EC" && jwk.Curve == "P-256":
+		x, err := decode(jwk.X)
+		if err != nil {
+			return "", verificationKey{}, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return "", verificationKey{}, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return "", verificationKey{}, errors.New("invalid P-256 coordinates")
+		}
+		// ecdh rejects points that are not on the curve
+		point := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return "", verificationKey{}, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return jwk.KeyID, verificationKey{method: jwt.SigningMethodES256, key: pub}, nil
+
+	case jwk.KeyType == "OKP" && jwk.Curve == "Ed25519":
+		x, err := decode(jwk.X)
+		if err != nil {
+			return "", verificationKey{}, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return "", verificationKey{}, errors.New("invalid Ed25519 key")
+		}
+		return jwk.KeyID, verificationKey{method: jwt.SigningMethodEdDSA, key: ed25519.PublicKey(x)}, nil
+	}
+	return "", verificationKey{}, fmt.Errorf("unsupported key type %s", jwk.KeyType)
+}
@@ -0,0 +1,291 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/auth"
+)
+
+const testClientID = "pandal-app"
+
+// fakeProvider is an OpenID Connect provider serving discovery, its JWKS and a token
+// endpoint that answers with whatever ID token is queued
+type fakeProvider struct {
+	server *httptest.Server
+
+	mu         sync.Mutex
+	kid        string
+	key        ed25519.PrivateKey
+	keyFetches int
+	tokenForm  url.Values
+	idToken    string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	p := &fakeProvider{}
+	p.rotate(t, "key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                           p.server.URL,
+			"authorization_endpoint":           p.server.URL + "/authorize",
+			"token_endpoint":                   p.server.URL + "/token",
+			"jwks_uri":                         p.server.URL + "/jwks",
+			"code_challenge_methods_supported": []string{"S256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.keyFetches++
+		public := p.key.Public().(ed25519.PublicKey)
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "OKP", "crv": "Ed25519", "use": "sig", "kid": p.kid, "x": base64.RawURLEncoding.EncodeToString(public)},
+			// Keys for other uses are skipped, not fatal
+			{"kty": "RSA", "use": "enc", "kid": "encryption"},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.tokenForm = r.PostForm
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// rotate replaces the provider's signing key with a fresh one named kid
+func (p *fakeProvider) rotate(t *testing.T, kid string) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.kid, p.key = kid, key
+}
+
+// claims returns valid ID token claims for this provider, with nonce
+func (p *fakeProvider) claims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            p.server.URL,
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"email":          "user@example.com",
+		"email_verified": true,
+		"nonce":          nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+	}
+}
+
+// sign signs claims with the provider's current key
+func (p *fakeProvider) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = p.kid
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func (p *fakeProvider) client() *auth.OIDCProvider {
+	return auth.NewOIDCProvider(auth.OIDCConfig{
+		Name:        "fake",
+		Issuer:      p.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost:8080/api/v1/auth/oidc/fake/callback",
+	}, p.server.Client())
+}
+
+func TestExchangeForwardsPKCEVerifier(t *testing.T) {
+	idp := newFakeProvider(t)
+	provider := idp.client()
+	ctx := context.Background()
+
+	verifier, challenge, err := auth.NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loginURL, err := provider.AuthCodeURL(ctx, "state", "nonce", challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, _ := url.Parse(loginURL)
+	if got := query.Query().Get("code_challenge"); got != challenge {
+		t.Fatalf("code_challenge = %q, want %q", got, challenge)
+	}
+	if got := query.Query().Get("code_challenge_method"); got != "S256" {
+		t.Fatalf("code_challenge_method = %q, want S256", got)
+	}
+
+	idp.idToken = idp.sign(t, idp.claims("nonce"))
+	identity, err := provider.Exchange(ctx, "the-code", verifier, "nonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Subject != "subject-1" || identity.Email != "user@example.com" || identity.AuthTime.IsZero() {
+		t.Fatalf("unexpected identity %+v", identity)
+	}
+
+	form := idp.tokenForm
+	if form.Get("code") != "the-code" || form.Get("grant_type") != "authorization_code" {
+		t.Fatalf("unexpected token request %v", form)
+	}
+	// The provider checks the verifier against the challenge it was sent earlier
+	sum := sha256.Sum256([]byte(form.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+		t.Fatalf("code_verifier %q does not match the challenge", form.Get("code_verifier"))
+	}
+}
+
+func TestIDTokenIsRejected(t *testing.T) {
+	idp := newFakeProvider(t)
+
+	tests := []struct {
+		name   string
+		change func(jwt.MapClaims)
+	}{
+		{"wrong nonce", func(c jwt.MapClaims) { c["nonce"] = "another-nonce" }},
+		{"missing nonce", func(c jwt.MapClaims) { delete(c, "nonce") }},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://attacker.example.com" }},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+		{"other party among audiences", func(c jwt.MapClaims) {
+			c["aud"] = []string{testClientID, "another-client"}
+			c["azp"] = "another-client"
+		}},
+		{"no party among audiences", func(c jwt.MapClaims) { c["aud"] = []string{testClientID, "another-client"} }},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"no expiry", func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"no subject", func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.claims("nonce")
+			tt.change(claims)
+			if _, err := idp.client().VerifyIDToken(context.Background(), idp.sign(t, claims), "nonce"); err == nil {
+				t.Fatal("ID token was accepted")
+			}
+		})
+	}
+
+	t.Run("authorized party among audiences", func(t *testing.T) {
+		claims := idp.claims("nonce")
+		claims["aud"] = []string{testClientID, "another-client"}
+		claims["azp"] = testClientID
+		if _, err := idp.client().VerifyIDToken(context.Background(), idp.sign(t, claims), "nonce"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("another key", func(t *testing.T) {
+		_, stranger, _ := ed25519.GenerateKey(rand.Reader)
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, idp.claims("nonce"))
+		token.Header["kid"] = idp.kid
+		signed, err := token.SignedString(stranger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := idp.client().VerifyIDToken(context.Background(), signed, "nonce"); err == nil {
+			t.Fatal("ID token signed with another key was accepted")
+		}
+	})
+}
+
+func TestUnknownKidRefetchesKeys(t *testing.T) {
+	idp := newFakeProvider(t)
+	provider := idp.client()
+	ctx := context.Background()
+
+	if _, err := provider.VerifyIDToken(ctx, idp.sign(t, idp.claims("nonce")), "nonce"); err != nil {
+		t.Fatal(err)
+	}
+	oldToken := idp.sign(t, idp.claims("nonce"))
+
+	idp.rotate(t, "key-2")
+	newToken := idp.sign(t, idp.claims("nonce"))
+
+	// Right after a fetch an unknown kid does not send us back to the provider
+	if _, err := provider.VerifyIDToken(ctx, newToken, "nonce"); err == nil {
+		t.Fatal("token with an unknown kid was accepted")
+	}
+	if idp.keyFetches != 1 {
+		t.Fatalf("keys fetched %d times, want 1", idp.keyFetches)
+	}
+
+	provider.AgeKeys()
+	if _, err := provider.VerifyIDToken(ctx, newToken, "nonce"); err != nil {
+		t.Fatalf("token signed with the rolled key: %v", err)
+	}
+	if idp.keyFetches != 2 {
+		t.Fatalf("keys fetched %d times, want 2", idp.keyFetches)
+	}
+
+	// The refetch replaced the keys, so the retired one no longer verifies
+	if _, err := provider.VerifyIDToken(ctx, oldToken, "nonce"); err == nil {
+		t.Fatal("token signed with a retired key was accepted")
+	}
+	if idp.keyFetches != 2 {
+		t.Fatalf("keys fetched %d times, want 2", idp.keyFetches)
+	}
+}
+
+func TestEmailVerifiedClaim(t *testing.T) {
+	idp := newFakeProvider(t)
+
+	tests := []struct {
+		name  string
+		value any
+		want  bool
+	}{
+		{"true", true, true},
+		{"false", false, false},
+		{"string true", "true", true},
+		{"string false", "false", false},
+		{"missing", nil, false},
+		{"number", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.claims("nonce")
+			if tt.value == nil {
+				delete(claims, "email_verified")
+			} else {
+				claims["email_verified"] = tt.value
+			}
+			identity, err := idp.client().VerifyIDToken(context.Background(), idp.sign(t, claims), "nonce")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identity.EmailVerified != tt.want {
+				t.Fatalf("EmailVerified = %v, want %v", identity.EmailVerified, tt.want)
+			}
+		})
+	}
+}
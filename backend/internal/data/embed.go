@@ -4,3 +4,8 @@ import _ "embed"
 
 //go:embed india-administrative.json
 var IndiaAdministrativeJSON []byte
+
+// DistrictBoundariesGeoJSON holds district outlines keyed by the codes in india-administrative.json
+//
+//go:embed district-boundaries.geojson
+var DistrictBoundariesGeoJSON []byte
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"errors"
+	"strings"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// ErrUnsupportedFormat is returned for export formats other than gpx, kml and geojson
+var ErrUnsupportedFormat = errors.New("unsupported export format, use gpx, kml or geojson")
+
+// Format is a map file format that routes and pandals can be exported to
+type Format string
+
+const (
+	FormatGPX     Format = "gpx"
+	FormatKML     Format = "kml"
+	FormatGeoJSON Format = "geojson"
+)
+
+// ParseFormat reads a format query value case-insensitively
+func ParseFormat(value string) (Format, error) {
+	switch f := Format(strings.ToLower(value)); f {
+	case FormatGPX, FormatKML, FormatGeoJSON:
+		return f, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ContentType returns the MIME type map apps expect for the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatGPX:
+		return "application/gpx+xml"
+	case FormatKML:
+		return "application/vnd.google-earth.kml+xml"
+	default:
+		return "application/geo+json"
+	}
+}
+
+// Route encodes the ordered stops of a route in the given format
+func Route(route *models.RouteWithStops, format Format) ([]byte, error) {
+	switch format {
+	case FormatGPX:
+		return RouteGPX(route)
+	case FormatKML:
+		return RouteKML(route)
+	case FormatGeoJSON:
+		return RouteGeoJSON(route)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// waypoint is a pandal reduced to what every export format needs
+type waypoint struct {
+	Name        string
+	Description string
+	Point       geo.Point
+}
+
+// waypoints returns the pandals that have usable coordinates, in order
+func waypoints(pandals []models.Pandal) []waypoint {
+	points := make([]waypoint, 0, len(pandals))
+	for _, pandal := range pandals {
+		point, ok := geo.FromCoordinates(pandal.Location.Coordinates)
+		if !ok {
+			continue
+		}
+		points = append(points, waypoint{
+			Name:        pandal.Name,
+			Description: describe(pandal),
+			Point:       point,
+		})
+	}
+	return points
+}
+
+// describe builds a short human-readable description of a pandal for map popups
+func describe(pandal models.Pandal) string {
+	parts := make([]string, 0, 3)
+	if pandal.Area != "" {
+		parts = append(parts, pandal.Area)
+	}
+	if pandal.Theme != "" {
+		parts = append(parts, "Theme: "+pandal.Theme)
+	}
+	if pandal.Description != "" {
+		parts = append(parts, pandal.Description)
+	}
+	return strings.Join(parts, " — ")
+}
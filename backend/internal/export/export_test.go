@@ -0,0 +1,191 @@
+package export_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/export"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// northKolkata is a two stop route whose names need escaping in XML. The pandal
+// without a pin is left out of every format.
+func northKolkata(t *testing.T) *models.RouteWithStops {
+	t.Helper()
+	id := func(hex string) primitive.ObjectID {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	return &models.RouteWithStops{
+		ID:                  id("65a000000000000000000001"),
+		Title:               "Ahiritola & Sons <north>",
+		Description:         `Two "must see" stops`,
+		Duration:            "1h",
+		TotalDistanceMeters: 520,
+		Stops: []models.Pandal{
+			{
+				ID:       id("65a000000000000000000002"),
+				Name:     "Ahiritola & Sons",
+				Area:     "Ahiritola",
+				District: "KOL",
+				Theme:    "Clay <and> river",
+				Tags:     []string{"heritage"},
+				Location: models.Location{Type: "Point", Coordinates: []float64{88.3595, 22.5958}},
+			},
+			{
+				ID:       id("65a000000000000000000003"),
+				Name:     "No pin",
+				Location: models.Location{Type: "Point"},
+			},
+			{
+				ID:          id("65a000000000000000000004"),
+				Name:        "Kumartuli Park",
+				District:    "KOL",
+				Description: "Idol makers' quarter",
+				RatingAvg:   4.5,
+				RatingCount: 2,
+				Location:    models.Location{Type: "Point", Coordinates: []float64{88.3626, 22.5995}},
+			},
+		},
+	}
+}
+
+// emptyRoute has no stops at all
+func emptyRoute() *models.RouteWithStops {
+	return &models.RouteWithStops{Title: "Empty"}
+}
+
+// checkGolden compares an export with the expected document
+func checkGolden(t *testing.T, route *models.RouteWithStops, format export.Format, want string) {
+	t.Helper()
+	got, err := export.Route(route, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = strings.TrimPrefix(want, "\n")
+	if string(got) != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRouteGPX(t *testing.T) {
+	// GPX takes lat and lon attributes, in that order in this file
+	checkGolden(t, northKolkata(t), export.FormatGPX, `
+<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="pandal-hopping-api" xmlns="http://www.topografix.com/GPX/1/1">
+  <metadata>
+    <name>Ahiritola &amp; Sons &lt;north&gt;</name>
+    <desc>Two &#34;must see&#34; stops</desc>
+  </metadata>
+  <wpt lat="22.5958" lon="88.3595">
+    <name>Ahiritola &amp; Sons</name>
+    <desc>Ahiritola — Theme: Clay &lt;and&gt; river</desc>
+  </wpt>
+  <wpt lat="22.5995" lon="88.3626">
+    <name>Kumartuli Park</name>
+    <desc>Idol makers&#39; quarter</desc>
+  </wpt>
+  <trk>
+    <name>Ahiritola &amp; Sons &lt;north&gt;</name>
+    <trkseg>
+      <trkpt lat="22.5958" lon="88.3595"></trkpt>
+      <trkpt lat="22.5995" lon="88.3626"></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`)
+
+	checkGolden(t, emptyRoute(), export.FormatGPX, `
+<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="pandal-hopping-api" xmlns="http://www.topografix.com/GPX/1/1">
+  <metadata>
+    <name>Empty</name>
+  </metadata>
+</gpx>`)
+}
+
+func TestRouteKML(t *testing.T) {
+	// KML coordinates are lng,lat tuples
+	checkGolden(t, northKolkata(t), export.FormatKML, `
+<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <name>Ahiritola &amp; Sons &lt;north&gt;</name>
+    <description>Two &#34;must see&#34; stops</description>
+    <Placemark>
+      <name>Ahiritola &amp; Sons</name>
+      <description>Ahiritola — Theme: Clay &lt;and&gt; river</description>
+      <Point>
+        <coordinates>88.3595,22.5958</coordinates>
+      </Point>
+    </Placemark>
+    <Placemark>
+      <name>Kumartuli Park</name>
+      <description>Idol makers&#39; quarter</description>
+      <Point>
+        <coordinates>88.3626,22.5995</coordinates>
+      </Point>
+    </Placemark>
+    <Placemark>
+      <name>Ahiritola &amp; Sons &lt;north&gt;</name>
+      <LineString>
+        <tessellate>1</tessellate>
+        <coordinates>88.3595,22.5958 88.3626,22.5995</coordinates>
+      </LineString>
+    </Placemark>
+  </Document>
+</kml>`)
+
+	checkGolden(t, emptyRoute(), export.FormatKML, `
+<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <name>Empty</name>
+  </Document>
+</kml>`)
+}
+
+func TestRouteGeoJSON(t *testing.T) {
+	// GeoJSON positions are [lng, lat]
+	checkGolden(t, northKolkata(t), export.FormatGeoJSON, `{"type":"FeatureCollection","features":[`+
+		`{"type":"Feature","id":"65a000000000000000000002","geometry":{"type":"Point","coordinates":[88.3595,22.5958]},`+
+		`"properties":{"area":"Ahiritola","district":"KOL","name":"Ahiritola \u0026 Sons","ratingAvg":0,"ratingCount":0,"stop":1,"tags":["heritage"]}},`+
+		`{"type":"Feature","id":"65a000000000000000000004","geometry":{"type":"Point","coordinates":[88.3626,22.5995]},`+
+		`"properties":{"area":"","district":"KOL","name":"Kumartuli Park","ratingAvg":4.5,"ratingCount":2,"stop":2,"tags":[]}},`+
+		`{"type":"Feature","id":"65a000000000000000000001","geometry":{"type":"LineString","coordinates":[[88.3595,22.5958],[88.3626,22.5995]]},`+
+		`"properties":{"description":"Two \"must see\" stops","duration":"1h","title":"Ahiritola \u0026 Sons \u003cnorth\u003e","totalDistanceMeters":520}}]}`)
+
+	checkGolden(t, emptyRoute(), export.FormatGeoJSON, `{"type":"FeatureCollection","features":[]}`)
+}
+
+func TestSingleStopRouteHasNoLine(t *testing.T) {
+	route := northKolkata(t)
+	route.Stops = route.Stops[:1]
+	for _, format := range []export.Format{export.FormatGPX, export.FormatKML, export.FormatGeoJSON} {
+		got, err := export.Route(route, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range []string{"<trk>", "<LineString>", `"LineString"`} {
+			if strings.Contains(string(got), line) {
+				t.Fatalf("%s export of one stop has a %s:\n%s", format, line, got)
+			}
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for value, want := range map[string]export.Format{"GPX": export.FormatGPX, "kml": export.FormatKML, "GeoJSON": export.FormatGeoJSON} {
+		if got, err := export.ParseFormat(value); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := export.ParseFormat("shp"); !errors.Is(err, export.ErrUnsupportedFormat) {
+		t.Errorf("ParseFormat(shp): got %v, want ErrUnsupportedFormat", err)
+	}
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"encoding/json"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// FeatureCollection is a GeoJSON (RFC 7946) feature collection
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature with free-form properties
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is a GeoJSON Point or LineString
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// PandalFeatures converts pandals into a feature collection for map tools.
+// Pandals without usable coordinates are left out.
+func PandalFeatures(pandals []models.Pandal) FeatureCollection {
+	collection := FeatureCollection{Type: "FeatureCollection", Features: make([]Feature, 0, len(pandals))}
+	for _, pandal := range pandals {
+		if len(pandal.Location.Coordinates) < 2 {
+			continue
+		}
+		collection.Features = append(collection.Features, pandalFeature(pandal))
+	}
+	return collection
+}
+
+// RouteGeoJSON encodes a route as a feature collection of its stops plus a LineString through them
+func RouteGeoJSON(route *models.RouteWithStops) ([]byte, error) {
+	collection := FeatureCollection{Type: "FeatureCollection", Features: make([]Feature, 0, len(route.Stops)+1)}
+
+	line := make([][]float64, 0, len(route.Stops))
+	for _, pandal := range route.Stops {
+		if len(pandal.Location.Coordinates) < 2 {
+			continue
+		}
+		feature := pandalFeature(pandal)
+		feature.Properties["stop"] = len(line) + 1
+		collection.Features = append(collection.Features, feature)
+		line = append(line, pandal.Location.Coordinates[:2])
+	}
+	if len(line) > 1 {
+		collection.Features = append(collection.Features, Feature{
+			Type:     "Feature",
+			ID:       route.ID.Hex(),
+			Geometry: Geometry{Type: "LineString", Coordinates: line},
+			Properties: map[string]interface{}{
+				"title":               route.Title,
+				"description":         route.Description,
+				"duration":            route.Duration,
+				"totalDistanceMeters": route.TotalDistanceMeters,
+			},
+		})
+	}
+
+	return json.Marshal(collection)
+}
+
+// pandalFeature builds the Point feature shared by pandal and route exports
+func pandalFeature(pandal models.Pandal) Feature {
+	tags := pandal.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return Feature{
+		Type:     "Feature",
+		ID:       pandal.ID.Hex(),
+		Geometry: Geometry{Type: "Point", Coordinates: pandal.Location.Coordinates[:2]},
+		Properties: map[string]interface{}{
+			"name":        pandal.Name,
+			"area":        pandal.Area,
+			"district":    pandal.District,
+			"tags":        tags,
+			"ratingAvg":   pandal.RatingAvg,
+			"ratingCount": pandal.RatingCount,
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"encoding/xml"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// gpxDoc is a GPX 1.1 document with one waypoint per stop and a single track through them
+type gpxDoc struct {
+	XMLName   xml.Name   `xml:"gpx"`
+	Version   string     `xml:"version,attr"`
+	Creator   string     `xml:"creator,attr"`
+	Namespace string     `xml:"xmlns,attr"`
+	Metadata  gpxMeta    `xml:"metadata"`
+	Waypoints []gpxPoint `xml:"wpt"`
+	Track     *gpxTrack  `xml:"trk,omitempty"`
+}
+
+type gpxMeta struct {
+	Name string `xml:"name"`
+	Desc string `xml:"desc,omitempty"`
+}
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Name string  `xml:"name,omitempty"`
+	Desc string  `xml:"desc,omitempty"`
+}
+
+type gpxTrack struct {
+	Name    string     `xml:"name"`
+	Segment []gpxPoint `xml:"trkseg>trkpt"`
+}
+
+// RouteGPX encodes a route as GPX 1.1
+func RouteGPX(route *models.RouteWithStops) ([]byte, error) {
+	points := waypoints(route.Stops)
+
+	doc := gpxDoc{
+		Version:   "1.1",
+		Creator:   "pandal-hopping-api",
+		Namespace: "http://www.topografix.com/GPX/1/1",
+		Metadata:  gpxMeta{Name: route.Title, Desc: route.Description},
+		Waypoints: make([]gpxPoint, 0, len(points)),
+	}
+
+	segment := make([]gpxPoint, 0, len(points))
+	for _, p := range points {
+		doc.Waypoints = append(doc.Waypoints, gpxPoint{Lat: p.Point.Lat, Lon: p.Point.Lng, Name: p.Name, Desc: p.Description})
+		segment = append(segment, gpxPoint{Lat: p.Point.Lat, Lon: p.Point.Lng})
+	}
+	if len(segment) > 1 {
+		doc.Track = &gpxTrack{Name: route.Title, Segment: segment}
+	}
+
+	return marshalXML(doc)
+}
+
+// marshalXML indents v and prefixes the XML declaration
+func marshalXML(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
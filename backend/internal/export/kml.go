@@ -0,0 +1,76 @@
+package export
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// kmlDoc is a KML 2.2 document with a placemark per stop and a line through them
+type kmlDoc struct {
+	XMLName   xml.Name    `xml:"kml"`
+	Namespace string      `xml:"xmlns,attr"`
+	Document  kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name        string         `xml:"name"`
+	Description string         `xml:"description,omitempty"`
+	Placemarks  []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name        string         `xml:"name"`
+	Description string         `xml:"description,omitempty"`
+	Point       *kmlPoint      `xml:"Point,omitempty"`
+	LineString  *kmlLineString `xml:"LineString,omitempty"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+// RouteKML encodes a route as KML 2.2
+func RouteKML(route *models.RouteWithStops) ([]byte, error) {
+	points := waypoints(route.Stops)
+
+	doc := kmlDoc{
+		Namespace: "http://www.opengis.net/kml/2.2",
+		Document: kmlDocument{
+			Name:        route.Title,
+			Description: route.Description,
+			Placemarks:  make([]kmlPlacemark, 0, len(points)+1),
+		},
+	}
+
+	line := make([]string, 0, len(points))
+	for _, p := range points {
+		doc.Document.Placemarks = append(doc.Document.Placemarks, kmlPlacemark{
+			Name:        p.Name,
+			Description: p.Description,
+			Point:       &kmlPoint{Coordinates: kmlCoordinate(p.Point)},
+		})
+		line = append(line, kmlCoordinate(p.Point))
+	}
+	if len(line) > 1 {
+		doc.Document.Placemarks = append(doc.Document.Placemarks, kmlPlacemark{
+			Name:       route.Title,
+			LineString: &kmlLineString{Tessellate: 1, Coordinates: strings.Join(line, " ")},
+		})
+	}
+
+	return marshalXML(doc)
+}
+
+// kmlCoordinate formats a point as KML's "lng,lat" tuple
+func kmlCoordinate(p geo.Point) string {
+	return strconv.FormatFloat(p.Lng, 'f', -1, 64) + "," + strconv.FormatFloat(p.Lat, 'f', -1, 64)
+}
@@ -0,0 +1,135 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxPolygonVertices bounds the size of a client-supplied polygon so a single
+// query cannot make the database test points against thousands of edges
+const MaxPolygonVertices = 500
+
+// ParseBBox parses "minLng,minLat,maxLng,maxLat" into a rectangular polygon.
+// Boxes crossing the antimeridian or spanning 180° of longitude or more are
+// rejected, since MongoDB would read them as the rest of the globe.
+func ParseBBox(s string) (Polygon, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, errors.New("bbox must be minLng,minLat,maxLng,maxLat")
+	}
+
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bbox value %q is not a number", part)
+		}
+		v[i] = f
+	}
+	minLng, minLat, maxLng, maxLat := v[0], v[1], v[2], v[3]
+
+	for _, p := range []Point{{minLng, minLat}, {maxLng, maxLat}} {
+		if err := checkRange(p); err != nil {
+			return nil, fmt.Errorf("bbox %w", err)
+		}
+	}
+	if minLng >= maxLng || minLat >= maxLat {
+		return nil, errors.New("bbox minimums must be less than its maximums")
+	}
+	if maxLng-minLng >= 180 {
+		return nil, errors.New("bbox must span less than 180 degrees of longitude")
+	}
+
+	return Polygon{Ring{
+		{minLng, minLat}, {maxLng, minLat}, {maxLng, maxLat}, {minLng, maxLat}, {minLng, minLat},
+	}}, nil
+}
+
+// ParsePolygon parses a GeoJSON Polygon geometry. Rings that are not closed are
+// closed, and each ring needs at least three vertices.
+func ParsePolygon(s string) (Polygon, error) {
+	var geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+	if err := json.Unmarshal([]byte(s), &geometry); err != nil {
+		return nil, errors.New("polygon must be a GeoJSON Polygon geometry")
+	}
+	if geometry.Type != "Polygon" {
+		return nil, fmt.Errorf("polygon type must be \"Polygon\", got %q", geometry.Type)
+	}
+	var rings [][][]float64
+	if err := json.Unmarshal(geometry.Coordinates, &rings); err != nil || len(rings) == 0 {
+		return nil, errors.New("polygon coordinates must be a list of rings of [lng, lat] positions")
+	}
+
+	vertices := 0
+	polygon := make(Polygon, 0, len(rings))
+	for _, rawRing := range rings {
+		ring := make(Ring, 0, len(rawRing)+1)
+		for _, position := range rawRing {
+			point, ok := FromCoordinates(position)
+			if !ok {
+				return nil, errors.New("polygon positions must be [lng, lat]")
+			}
+			if err := checkRange(point); err != nil {
+				return nil, fmt.Errorf("polygon %w", err)
+			}
+			ring = append(ring, point)
+		}
+		if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+			ring = append(ring, ring[0])
+		}
+		if len(ring) < 4 {
+			return nil, errors.New("polygon rings need at least three vertices")
+		}
+
+		vertices += len(ring)
+		if vertices > MaxPolygonVertices {
+			return nil, fmt.Errorf("polygon may have at most %d vertices", MaxPolygonVertices)
+		}
+		polygon = append(polygon, ring)
+	}
+	return polygon, nil
+}
+
+// Coordinates returns the polygon as GeoJSON Polygon coordinates
+func (poly Polygon) Coordinates() [][][]float64 {
+	coordinates := make([][][]float64, 0, len(poly))
+	for _, ring := range poly {
+		positions := make([][]float64, 0, len(ring))
+		for _, p := range ring {
+			positions = append(positions, []float64{p.Lng, p.Lat})
+		}
+		coordinates = append(coordinates, positions)
+	}
+	return coordinates
+}
+
+// Bounds returns the south-west and north-east corners of the outer ring's bounding box
+func (poly Polygon) Bounds() (sw, ne Point) {
+	if len(poly) == 0 || len(poly[0]) == 0 {
+		return Point{}, Point{}
+	}
+	sw, ne = poly[0][0], poly[0][0]
+	for _, p := range poly[0][1:] {
+		sw.Lng, sw.Lat = math.Min(sw.Lng, p.Lng), math.Min(sw.Lat, p.Lat)
+		ne.Lng, ne.Lat = math.Max(ne.Lng, p.Lng), math.Max(ne.Lat, p.Lat)
+	}
+	return sw, ne
+}
+
+// checkRange rejects points outside the valid longitude and latitude ranges
+func checkRange(p Point) error {
+	if !(p.Lng >= -180 && p.Lng <= 180) { // also catches NaN
+		return fmt.Errorf("longitude %v is out of range [-180, 180]", p.Lng)
+	}
+	if !(p.Lat >= -90 && p.Lat <= 90) {
+		return fmt.Errorf("latitude %v is out of range [-90, 90]", p.Lat)
+	}
+	return nil
+}
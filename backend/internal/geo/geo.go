@@ -0,0 +1,54 @@
+package geo
+
+import "math"
+
+const (
+	// EarthRadiusMeters is the mean Earth radius used for great-circle distances
+	EarthRadiusMeters = 6371008.8
+
+	// WalkingSpeedKmph is a festival-crowd walking pace, slower than the usual 5 km/h
+	WalkingSpeedKmph = 4.0
+)
+
+// Point is a longitude/latitude pair in degrees
+type Point struct {
+	Lng float64
+	Lat float64
+}
+
+// FromCoordinates converts GeoJSON [lng, lat] coordinates into a Point.
+// ok is false when fewer than two coordinates are given.
+func FromCoordinates(coordinates []float64) (Point, bool) {
+	if len(coordinates) < 2 {
+		return Point{}, false
+	}
+	return Point{Lng: coordinates[0], Lat: coordinates[1]}, true
+}
+
+// Haversine returns the great-circle distance between a and b in meters
+func Haversine(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// WalkingMinutes estimates how long it takes to walk meters at WalkingSpeedKmph
+func WalkingMinutes(meters float64) float64 {
+	return meters / (WalkingSpeedKmph * 1000) * 60
+}
+
+// Bearing returns the initial compass bearing from a to b in degrees, clockwise from north in [0, 360)
+func Bearing(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	y := math.Sin(dLng) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLng)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
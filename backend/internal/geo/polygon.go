@@ -0,0 +1,112 @@
+package geo
+
+import "math"
+
+// Ring is a closed linear ring of a polygon; the last point may repeat the first
+type Ring []Point
+
+// Polygon is an outer ring followed by zero or more holes, as in GeoJSON
+type Polygon []Ring
+
+// Contains reports whether p lies inside the polygon's outer ring and outside all of its holes
+func (poly Polygon) Contains(p Point) bool {
+	if len(poly) == 0 || !poly[0].contains(p) {
+		return false
+	}
+	for _, hole := range poly[1:] {
+		if hole.contains(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// DistanceMeters returns how far p is from the polygon: 0 inside, otherwise the
+// distance to the nearest edge of the outer ring
+func (poly Polygon) DistanceMeters(p Point) float64 {
+	if len(poly) == 0 {
+		return math.Inf(1)
+	}
+	if poly.Contains(p) {
+		return 0
+	}
+	return poly[0].distanceMeters(p)
+}
+
+// EdgeDistanceMeters returns how far p is from the nearest edge of the polygon, hole
+// edges included, whether p lies inside or outside
+func (poly Polygon) EdgeDistanceMeters(p Point) float64 {
+	best := math.Inf(1)
+	for _, ring := range poly {
+		best = math.Min(best, ring.distanceMeters(p))
+	}
+	return best
+}
+
+// AreaSqMeters approximates the polygon's area, holes excluded.
+// It is only used to rank overlapping polygons, so a local projection is enough.
+func (poly Polygon) AreaSqMeters() float64 {
+	if len(poly) == 0 {
+		return 0
+	}
+	area := poly[0].areaSqMeters()
+	for _, hole := range poly[1:] {
+		area -= hole.areaSqMeters()
+	}
+	return area
+}
+
+// contains runs the even-odd ray casting test
+func (r Ring) contains(p Point) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// distanceMeters is the shortest distance from p to any edge of the ring
+func (r Ring) distanceMeters(p Point) float64 {
+	best := math.Inf(1)
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		if d := segmentDistanceMeters(p, r[j], r[i]); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// areaSqMeters applies the shoelace formula in an equirectangular projection around the ring
+func (r Ring) areaSqMeters() float64 {
+	if len(r) < 3 {
+		return 0
+	}
+	scale := math.Cos(r[0].Lat * math.Pi / 180)
+	var sum float64
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		sum += (r[j].Lng*scale)*r[i].Lat - (r[i].Lng*scale)*r[j].Lat
+	}
+	metersPerDegree := EarthRadiusMeters * math.Pi / 180
+	return math.Abs(sum) / 2 * metersPerDegree * metersPerDegree
+}
+
+// segmentDistanceMeters projects around p and returns the distance from p to segment ab.
+// The projection is accurate to well under a percent at district scale.
+func segmentDistanceMeters(p, a, b Point) float64 {
+	metersPerDegree := EarthRadiusMeters * math.Pi / 180
+	scale := math.Cos(p.Lat*math.Pi/180) * metersPerDegree
+
+	ax, ay := (a.Lng-p.Lng)*scale, (a.Lat-p.Lat)*metersPerDegree
+	bx, by := (b.Lng-p.Lng)*scale, (b.Lat-p.Lat)*metersPerDegree
+
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if lengthSq := dx*dx + dy*dy; lengthSq > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSq))
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
@@ -0,0 +1,85 @@
+package geo
+
+// OrderTour returns the indices of points in a short walking order starting from start.
+// The tour is open (it does not return to start). It is built greedily with the
+// nearest-neighbour heuristic and then improved with 2-opt until no swap helps.
+func OrderTour(start Point, points []Point) []int {
+	n := len(points)
+	if n == 0 {
+		return []int{}
+	}
+
+	// Node 0 is the fixed start, node i+1 is points[i]
+	nodes := make([]Point, 0, n+1)
+	nodes = append(nodes, start)
+	nodes = append(nodes, points...)
+
+	dist := make([][]float64, n+1)
+	for i := range nodes {
+		dist[i] = make([]float64, n+1)
+		for j := range nodes {
+			if i != j {
+				dist[i][j] = Haversine(nodes[i], nodes[j])
+			}
+		}
+	}
+
+	path := nearestNeighbour(dist)
+	twoOpt(path, dist)
+
+	order := make([]int, 0, n)
+	for _, node := range path[1:] {
+		order = append(order, node-1)
+	}
+	return order
+}
+
+// nearestNeighbour walks from node 0 to the closest unvisited node until all are visited
+func nearestNeighbour(dist [][]float64) []int {
+	n := len(dist)
+	visited := make([]bool, n)
+	path := make([]int, 0, n)
+
+	current := 0
+	visited[0] = true
+	path = append(path, 0)
+	for len(path) < n {
+		next := -1
+		for j := 1; j < n; j++ {
+			if !visited[j] && (next == -1 || dist[current][j] < dist[current][next]) {
+				next = j
+			}
+		}
+		visited[next] = true
+		path = append(path, next)
+		current = next
+	}
+	return path
+}
+
+// twoOpt reverses path segments while doing so shortens the open path.
+// path[0] stays fixed as the start.
+func twoOpt(path []int, dist [][]float64) {
+	n := len(path)
+	improved := true
+	for improved {
+		improved = false
+		for i := 1; i < n-1; i++ {
+			for k := i + 1; k < n; k++ {
+				before := dist[path[i-1]][path[i]]
+				after := dist[path[i-1]][path[k]]
+				// The last node has no successor on an open path
+				if k+1 < n {
+					before += dist[path[k]][path[k+1]]
+					after += dist[path[i]][path[k+1]]
+				}
+				if after < before-1e-9 {
+					for l, r := i, k; l < r; l, r = l+1, r-1 {
+						path[l], path[r] = path[r], path[l]
+					}
+					improved = true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package geo_test
+
+import (
+	"math"
+	"testing"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+)
+
+// tourMeters is the length of the open walk from start through points in order
+func tourMeters(start geo.Point, points []geo.Point, order []int) float64 {
+	total, current := 0.0, start
+	for _, idx := range order {
+		total += geo.Haversine(current, points[idx])
+		current = points[idx]
+	}
+	return total
+}
+
+// shortestTourMeters tries every order of points and returns the shortest walk
+func shortestTourMeters(start geo.Point, points []geo.Point) float64 {
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+	}
+	best := math.Inf(1)
+	var permute func(k int)
+	permute = func(k int) {
+		if k == len(order) {
+			best = math.Min(best, tourMeters(start, points, order))
+			return
+		}
+		for i := k; i < len(order); i++ {
+			order[k], order[i] = order[i], order[k]
+			permute(k + 1)
+			order[k], order[i] = order[i], order[k]
+		}
+	}
+	permute(0)
+	return best
+}
+
+func TestOrderTour(t *testing.T) {
+	// About 1 km apart in Kolkata
+	const step = 0.01
+	origin := geo.Point{Lng: 88.36, Lat: 22.57}
+	at := func(dx, dy float64) geo.Point {
+		return geo.Point{Lng: origin.Lng + dx*step, Lat: origin.Lat + dy*step}
+	}
+
+	tests := []struct {
+		name   string
+		start  geo.Point
+		points []geo.Point
+		want   []int // nil when only the length is checked
+	}{
+		{"no stops", origin, nil, []int{}},
+		{"single stop", origin, []geo.Point{at(3, 4)}, []int{0}},
+		{"stops on a line, given out of order", origin, []geo.Point{at(3, 0), at(1, 0), at(4, 0), at(2, 0)}, []int{1, 3, 0, 2}},
+		{"start in the middle of a line", at(2, 0), []geo.Point{at(0, 0), at(1, 0), at(5, 0), at(3, 0)}, []int{1, 0, 3, 2}},
+		{"square corners from a corner", origin, []geo.Point{at(1, 1), at(0, 1), at(1, 0)}, nil},
+		{"duplicate stops", origin, []geo.Point{at(2, 0), at(1, 0), at(2, 0), at(1, 0)}, nil},
+		{"stop at the start", origin, []geo.Point{at(1, 0), origin}, []int{1, 0}},
+		// Nearest neighbour alone goes 1 east, then 2 west and then all the way back east
+		{"greedy walk that 2-opt must fix", origin, []geo.Point{at(1, 0), at(10, 0), at(-2, 0)}, []int{2, 0, 1}},
+		{"grid", origin, []geo.Point{at(0, 1), at(0, 2), at(1, 0), at(1, 1), at(1, 2), at(2, 0), at(2, 1), at(2, 2)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := geo.OrderTour(tt.start, tt.points)
+
+			if len(order) != len(tt.points) {
+				t.Fatalf("got %d stops %v, want %d", len(order), order, len(tt.points))
+			}
+			seen := make(map[int]bool, len(order))
+			for _, idx := range order {
+				if idx < 0 || idx >= len(tt.points) || seen[idx] {
+					t.Fatalf("order %v is not a permutation of the stops", order)
+				}
+				seen[idx] = true
+			}
+
+			if tt.want != nil {
+				for i := range tt.want {
+					if order[i] != tt.want[i] {
+						t.Fatalf("got order %v, want %v", order, tt.want)
+					}
+				}
+			}
+			if got, best := tourMeters(tt.start, tt.points, order), shortestTourMeters(tt.start, tt.points); got > best+1e-6 {
+				t.Fatalf("order %v walks %.0f m, the shortest walk is %.0f m", order, got, best)
+			}
+		})
+	}
+}
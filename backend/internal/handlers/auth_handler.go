@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
 	"tirthankarkundu17/pandal-hopping-api/internal/services"
@@ -25,10 +29,14 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	user, err := h.authService.Register(c.Request.Context(), req)
-	if err != nil {
+	if errors.Is(err, services.ErrEmailInUse) {
 		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "user registered successfully", "user": user})
 }
@@ -40,7 +48,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, expiresIn, err := h.authService.Login(c.Request.Context(), req)
+	accessToken, refreshToken, expiresIn, err := h.authService.Login(c.Request.Context(), req, c.ClientIP())
+	var throttled *services.LoginThrottledError
+	if errors.As(err, &throttled) {
+		tooManyRequests(c, err, throttled.RetryAfter)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -72,3 +85,108 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		ExpiresIn:    expiresIn,
 	})
 }
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.Logout(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	if err := h.authService.LogoutAll(c.Request.Context(), c.GetString("userID")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out from all devices"})
+}
+
+// ForgotPassword emails a reset link; the response is the same whether or not the account exists
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.authService.ForgotPassword(c.Request.Context(), req.Email, c.ClientIP())
+	var throttled *services.ResetThrottledError
+	if errors.As(err, &throttled) {
+		tooManyRequests(c, err, throttled.RetryAfter)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "if an account exists for that email, a reset link has been sent"})
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request.Context(), req); err != nil {
+		c.JSON(emailTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password has been reset, please log in again"})
+}
+
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	if err := h.authService.VerifyEmail(c.Request.Context(), token); err != nil {
+		c.JSON(emailTokenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email address verified"})
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	err := h.authService.ResendVerification(c.Request.Context(), c.GetString("userID"))
+	if errors.Is(err, services.ErrEmailAlreadyVerified) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification email sent"})
+}
+
+// emailTokenErrorStatus maps failures of emailed link flows onto HTTP status codes
+func emailTokenErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidEmailToken) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// tooManyRequests answers 429 with a Retry-After header in whole seconds
+func tooManyRequests(c *gin.Context, err error, wait time.Duration) {
+	retryAfter := int64(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "retryAfter": retryAfter})
+}
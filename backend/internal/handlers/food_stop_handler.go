@@ -3,7 +3,6 @@ package handlers
 import (
 	"context"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,6 +10,7 @@ import (
 
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
 	"tirthankarkundu17/pandal-hopping-api/internal/services"
+	"tirthankarkundu17/pandal-hopping-api/internal/validation"
 )
 
 // FoodStopHandler handles HTTP requests for food stops
@@ -23,45 +23,44 @@ func NewFoodStopHandler(service services.FoodStopService) *FoodStopHandler {
 	return &FoodStopHandler{service: service}
 }
 
-// GetFoodStops returns food stops, optionally filtered by proximity
-// GET /food/?lat=&lng=&radius=
+// GetFoodStops returns food stops, optionally filtered by proximity or a map viewport
+// GET /food/?lat=&lng=&radius=&minRadius=&limit=&sort=&cursor=
+// GET /food/?bbox=minLng,minLat,maxLng,maxLat|polygon=<GeoJSON>&limit=
 func (h *FoodStopHandler) GetFoodStops() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
-		lngStr := c.Query("lng")
-		latStr := c.Query("lat")
-		radiusStr := c.Query("radius")
-
-		var hasCoords bool
-		var lng, lat, radius float64
-
-		if lngStr != "" && latStr != "" {
-			var err1, err2 error
-			lng, err1 = strconv.ParseFloat(lngStr, 64)
-			lat, err2 = strconv.ParseFloat(latStr, 64)
-			if err1 != nil || err2 != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lng or lat coordinates"})
+		area, limit, hasArea, ok := parseAreaQuery(c)
+		if !ok {
+			return
+		}
+		if hasArea {
+			stops, truncated, err := h.service.GetFoodStopsInArea(ctx, area, limit)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
-			hasCoords = true
-			if radiusStr != "" {
-				if r, err := strconv.ParseFloat(radiusStr, 64); err == nil {
-					radius = r
-				}
-			}
-		} else if lngStr != "" || latStr != "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Both lng and lat are required for proximity search"})
+			c.JSON(http.StatusOK, gin.H{"data": stops, "truncated": truncated, "limit": services.AreaLimit(limit)})
+			return
+		}
+
+		near, ok := parseGeoQuery(c)
+		if !ok {
 			return
 		}
 
-		stops, err := h.service.GetFoodStops(ctx, lng, lat, radius, hasCoords)
+		page, ok := parsePageParams(c)
+		if !ok {
+			return
+		}
+
+		stops, nextCursor, err := h.service.GetFoodStops(ctx, near, page)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"data": stops})
+		c.JSON(http.StatusOK, gin.H{"data": stops, "nextCursor": nextCursor})
 	}
 }
 
@@ -87,7 +86,7 @@ func (h *FoodStopHandler) GetFoodStopByID() gin.HandlerFunc {
 	}
 }
 
-// CreateFoodStop inserts a new food stop (admin only)
+// CreateFoodStop inserts a new food stop (admin or moderator only)
 // POST /food/
 func (h *FoodStopHandler) CreateFoodStop() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -100,6 +99,12 @@ func (h *FoodStopHandler) CreateFoodStop() gin.HandlerFunc {
 			return
 		}
 
+		// Food stops carry no state, so the swapped-axis check uses every known state
+		if err := validation.ValidateGeoPoint(&stop.Location, "", ""); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		result, err := h.service.CreateFoodStop(ctx, stop)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
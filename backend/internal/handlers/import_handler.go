@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/importer"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+)
+
+// maxImportBytes caps the size of an uploaded import file
+const maxImportBytes = 10 << 20
+
+// ImportHandler handles HTTP requests for bulk pandal imports
+type ImportHandler struct {
+	service services.ImportService
+}
+
+// NewImportHandler creates a new handler instance
+func NewImportHandler(service services.ImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+// ImportPandals loads pandals from a CSV or GeoJSON file (admin only).
+// The file is sent either as the raw request body or as the "file" field of a multipart form.
+// POST /admin/pandals/import?format=csv|geojson&dryRun=true&onDuplicate=skip|update
+func (h *ImportHandler) ImportPandals() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Large imports need longer than the usual request budget
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+		defer cancel()
+
+		dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dryRun must be true or false"})
+			return
+		}
+
+		var updateDuplicates bool
+		switch c.DefaultQuery("onDuplicate", "skip") {
+		case "skip":
+		case "update":
+			updateDuplicates = true
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "onDuplicate must be skip or update"})
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+		var body io.Reader = c.Request.Body
+		filename := ""
+		if c.ContentType() == "multipart/form-data" {
+			file, header, err := c.Request.FormFile("file")
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "multipart uploads must carry the import in a \"file\" field"})
+				return
+			}
+			defer file.Close()
+			body = file
+			filename = header.Filename
+		}
+
+		var format importer.Format
+		if f := c.Query("format"); f != "" {
+			format, err = importer.ParseFormat(f)
+		} else {
+			format, err = importer.DetectFormat(filename, c.ContentType())
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		records, err := importer.Parse(body, format)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "import file is too large"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		report, err := h.service.ImportPandals(ctx, records, services.ImportOptions{
+			DryRun:           dryRun,
+			UpdateDuplicates: updateDuplicates,
+			ImportedBy:       c.GetString("userID"),
+		})
+		if errors.Is(err, services.ErrImportTooLarge) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		status := http.StatusOK
+		if !dryRun && report.Created+report.Updated > 0 {
+			status = http.StatusCreated
+		}
+		c.JSON(status, gin.H{"data": report})
+	}
+}
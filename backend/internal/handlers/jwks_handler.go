@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/auth"
+)
+
+// JWKSHandler publishes the public keys access tokens are signed with
+type JWKSHandler struct {
+	keys *auth.KeyManager
+}
+
+// NewJWKSHandler creates a new handler instance
+func NewJWKSHandler(keys *auth.KeyManager) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS returns the JSON Web Key Set of the current and still accepted signing keys
+// GET /.well-known/jwks.json
+func (h *JWKSHandler) GetJWKS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Short enough that verifiers pick up a newly added key well before it signs anything
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, gin.H{"keys": h.keys.JWKS()})
+	}
+}
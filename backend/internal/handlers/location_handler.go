@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"tirthankarkundu17/pandal-hopping-api/internal/validation"
 
@@ -28,3 +29,27 @@ func (h *LocationHandler) GetAdministrativeData() gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"data": data})
 	}
 }
+
+// ResolveLocation returns the state and district containing a point so forms can auto-fill them
+// GET /locations/resolve?lat=&lng=
+func (h *LocationHandler) ResolveLocation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+		lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+		if errLat != nil || errLng != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Valid lat and lng query parameters are required"})
+			return
+		}
+		if err := validation.ValidateCoordinates([]float64{lng, lat}); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		location, ok := validation.ResolveLocation(lng, lat)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No supported district surely contains this point"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": location})
+	}
+}
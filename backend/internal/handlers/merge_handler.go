@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+)
+
+// MergeHandler handles HTTP requests for folding duplicate pandals together
+type MergeHandler struct {
+	service services.PandalMergeService
+}
+
+// NewMergeHandler creates a new handler instance
+func NewMergeHandler(service services.PandalMergeService) *MergeHandler {
+	return &MergeHandler{service: service}
+}
+
+// MergePandal folds the pandal :id into targetId, moving its approvals, reviews
+// and route stops across and soft deleting it (admin only)
+// POST /admin/pandals/:id/merge
+func (h *MergeHandler) MergePandal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		sourceID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Pandal ID format"})
+			return
+		}
+
+		var req models.MergePandalRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := h.service.MergePandals(ctx, sourceID, req.TargetID)
+		if err != nil {
+			c.JSON(pandalErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Pandals merged", "data": result})
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+)
+
+// oidcStateCookie holds a hash of the state of the login the browser started, for as
+// long as the login may take, so a callback URL cannot be replayed in someone else's
+// browser to sign them in as the attacker (login CSRF)
+const (
+	oidcStateCookie = "oidc_state"
+	oidcStateMaxAge = 10 * time.Minute
+)
+
+// OIDCHandler handles sign-in through OpenID Connect providers
+type OIDCHandler struct {
+	service services.OIDCService
+	// appRedirect, when set, is where the callback sends the browser with the tokens in
+	// the URL fragment, e.g. the app's deep link; otherwise the tokens are returned as JSON
+	appRedirect string
+}
+
+// NewOIDCHandler creates a new handler instance
+func NewOIDCHandler(service services.OIDCService, appRedirect string) *OIDCHandler {
+	return &OIDCHandler{service: service, appRedirect: appRedirect}
+}
+
+// GetProviders lists the providers users can sign in with
+// GET /auth/oidc
+func (h *OIDCHandler) GetProviders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": h.service.Providers()})
+	}
+}
+
+// Login sends the browser to the provider's sign-in page
+// GET /auth/oidc/:provider?device=
+func (h *OIDCHandler) Login() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		loginURL, state, err := h.service.LoginURL(ctx, c.Param("provider"), c.Query("device"))
+		if err != nil {
+			c.JSON(oidcErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		// Lax still sends the cookie on the provider's top level redirect back to us
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oidcStateCookie, hashState(state), int(oidcStateMaxAge.Seconds()), "/", "", isHTTPS(c), true)
+		c.Redirect(http.StatusFound, loginURL)
+	}
+}
+
+// Callback is where the provider sends the browser back after sign-in
+// GET /auth/oidc/:provider/callback?code=&state=
+func (h *OIDCHandler) Callback() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		// The user cancelled or the provider refused
+		if providerErr := c.Query("error"); providerErr != "" {
+			h.fail(c, http.StatusBadRequest, providerErr+": "+c.Query("error_description"))
+			return
+		}
+		code, state := c.Query("code"), c.Query("state")
+		if code == "" || state == "" {
+			h.fail(c, http.StatusBadRequest, "code and state are required")
+			return
+		}
+
+		// Only the browser that started this login may finish it
+		binding, err := c.Cookie(oidcStateCookie)
+		if err != nil || subtle.ConstantTimeCompare([]byte(binding), []byte(hashState(state))) != 1 {
+			h.fail(c, http.StatusBadRequest, services.ErrInvalidOIDCState.Error())
+			return
+		}
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oidcStateCookie, "", -1, "/", "", isHTTPS(c), true)
+
+		accessToken, refreshToken, expiresIn, err := h.service.Callback(ctx, c.Param("provider"), state, code)
+		if err != nil {
+			h.fail(c, oidcErrorStatus(err), err.Error())
+			return
+		}
+
+		if h.appRedirect != "" {
+			// A fragment is not sent to servers, so the tokens stay out of access logs
+			fragment := url.Values{
+				"access_token":  {accessToken},
+				"refresh_token": {refreshToken},
+				"expires_in":    {strconv.FormatInt(expiresIn, 10)},
+			}
+			c.Redirect(http.StatusFound, h.appRedirect+"#"+fragment.Encode())
+			return
+		}
+
+		c.JSON(http.StatusOK, models.AuthResponse{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			ExpiresIn:    expiresIn,
+		})
+	}
+}
+
+// hashState is the cookie value binding a login's state to the browser; the state
+// itself stays out of the cookie
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// isHTTPS reports whether the client reached us over TLS, directly or through a proxy
+func isHTTPS(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
+
+// fail reports a failed sign-in to the app redirect when there is one, as JSON otherwise
+func (h *OIDCHandler) fail(c *gin.Context, status int, message string) {
+	if h.appRedirect != "" {
+		c.Redirect(http.StatusFound, h.appRedirect+"#"+url.Values{"error": {message}}.Encode())
+		return
+	}
+	c.JSON(status, gin.H{"error": message})
+}
+
+// oidcErrorStatus maps OpenID Connect sign-in failures onto HTTP status codes
+func oidcErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUnknownOIDCProvider):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidOIDCState):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrOIDCLoginFailed):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrOIDCEmailNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrOIDCProviderUnavailable):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,71 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
+	"tirthankarkundu17/pandal-hopping-api/internal/routes"
+)
+
+// stubOIDCService starts every login with the same state and counts finished ones
+type stubOIDCService struct {
+	callbacks int
+}
+
+func (s *stubOIDCService) Providers() []string { return []string{"test"} }
+
+func (s *stubOIDCService) LoginURL(ctx context.Context, provider, device string) (string, string, error) {
+	return "https://idp.example.com/authorize?state=the-state", "the-state", nil
+}
+
+func (s *stubOIDCService) Callback(ctx context.Context, provider, state, code string) (string, string, int64, error) {
+	s.callbacks++
+	return "access", "refresh", 3600, nil
+}
+
+// TestOIDCCallbackNeedsTheBrowserThatStartedTheLogin replays a callback URL without the
+// state cookie, as an attacker sending their own callback link to a victim would
+func TestOIDCCallbackNeedsTheBrowserThatStartedTheLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := &stubOIDCService{}
+	router := gin.New()
+	routes.AuthRoute(router.Group("/api/v1"), &handlers.AuthHandler{}, handlers.NewOIDCHandler(service, ""))
+
+	login := httptest.NewRecorder()
+	router.ServeHTTP(login, httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/test", nil))
+	if login.Code != http.StatusFound {
+		t.Fatalf("login returned %d", login.Code)
+	}
+	cookies := login.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode || cookies[0].Value == "the-state" {
+		t.Fatalf("login set cookies %v, want one HttpOnly SameSite=Lax cookie with a hash of the state", cookies)
+	}
+
+	callback := func(cookie *http.Cookie, state string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/test/callback?code=code&state="+state, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := callback(nil, "the-state"); code != http.StatusBadRequest {
+		t.Fatalf("callback without the cookie returned %d, want 400", code)
+	}
+	if code := callback(cookies[0], "another-state"); code != http.StatusBadRequest {
+		t.Fatalf("callback with another login's state returned %d, want 400", code)
+	}
+	if service.callbacks != 0 {
+		t.Fatal("a refused callback reached the service and used up the login")
+	}
+	if code := callback(cookies[0], "the-state"); code != http.StatusOK {
+		t.Fatalf("callback from the browser that started the login returned %d, want 200", code)
+	}
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
+)
+
+// parsePageParams reads the limit, sort and cursor query params shared by list endpoints.
+// On invalid input it writes a 400 response and returns false.
+func parsePageParams(c *gin.Context) (pagination.Params, bool) {
+	page, err := pagination.NewParams(c.Query("limit"), c.Query("sort"), c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return pagination.Params{}, false
+	}
+	return page, true
+}
+
+// listErrorStatus answers 400 for bad pagination input and 500 for everything else
+func listErrorStatus(err error) int {
+	if pagination.IsClientError(err) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
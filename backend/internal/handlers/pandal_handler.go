@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,6 +12,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"tirthankarkundu17/pandal-hopping-api/internal/export"
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
 	"tirthankarkundu17/pandal-hopping-api/internal/services"
 	"tirthankarkundu17/pandal-hopping-api/internal/validation"
@@ -26,7 +31,8 @@ func NewPandalHandler(service services.PandalService) *PandalHandler {
 	}
 }
 
-// CreatePandal is the Gin handler to insert a new pandal
+// CreatePandal is the Gin handler to insert a new pandal.
+// Likely duplicates of an existing pandal are answered with 409 unless ?force=true is set.
 func (h *PandalHandler) CreatePandal() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
@@ -45,10 +51,28 @@ func (h *PandalHandler) CreatePandal() gin.HandlerFunc {
 			return
 		}
 
+		// The pin must be a well-formed point inside the claimed district
+		if err := validation.ValidateGeoPoint(&pandal.Location, pandal.Country, pandal.State); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := validation.ValidateDistrictPoint(pandal.Country, pandal.State, pandal.District, pandal.Location.Coordinates); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		// Inject the authenticated user as the creator
 		pandal.CreatedBy = c.GetString("userID")
 
-		result, err := h.service.CreatePandal(ctx, pandal)
+		// force=true submits even when the pandal looks like a duplicate
+		force, _ := strconv.ParseBool(c.Query("force"))
+
+		result, err := h.service.CreatePandal(ctx, pandal, force)
+		var dupErr *services.DuplicatePandalError
+		if errors.As(err, &dupErr) {
+			c.JSON(http.StatusConflict, dupErr)
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error while inserting data: " + err.Error()})
 			return
@@ -59,53 +83,242 @@ func (h *PandalHandler) CreatePandal() gin.HandlerFunc {
 }
 
 // GetAllPandals handles geospatial mapping search of pandals
-// Supports optional query params: lng, lat, radius, tag, q
+// Supports optional query params: lng, lat, radius, minRadius, tag, q, district, limit, sort, cursor.
+// bbox or polygon switch to a viewport query: every match in the area, up to limit, unpaginated.
 func (h *PandalHandler) GetAllPandals() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
-		lngStr := c.Query("lng")
-		latStr := c.Query("lat")
-		radiusStr := c.Query("radius")
 		tag := c.Query("tag")
 		search := c.Query("q")
 		district := c.Query("district")
 
-		var hasCoords bool
-		var lng, lat, radius float64
+		// Viewport queries return everything in the area up to a cap instead of a page
+		area, limit, hasArea, ok := parseAreaQuery(c)
+		if !ok {
+			return
+		}
+		if hasArea {
+			pandals, truncated, err := h.service.GetPandalsInArea(ctx, area, tag, search, district, limit)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"data": pandals, "truncated": truncated, "limit": services.AreaLimit(limit)})
+			return
+		}
+
+		near, ok := parseGeoQuery(c)
+		if !ok {
+			return
+		}
+
+		page, ok := parsePageParams(c)
+		if !ok {
+			return
+		}
+
+		pandals, nextCursor, err := h.service.GetPandals(ctx, near, tag, search, district, page)
+		if err != nil {
+			c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"data": pandals, "nextCursor": nextCursor})
+	}
+}
+
+// GetPandalClusters groups approved pandals in the viewport into clusters for the map.
+// Each cluster has its size, centroid, member bounds and best rated pandal; zooming in to a
+// cluster's bounds splits it up until single pandals remain.
+// GET /pandals/clusters?bbox=minLng,minLat,maxLng,maxLat&zoom=&tag=&district=
+func (h *PandalHandler) GetPandalClusters() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		if c.Query("bbox") == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bbox query parameter is required"})
+			return
+		}
+		area, err := geo.ParseBBox(c.Query("bbox"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		zoom, err := strconv.Atoi(c.Query("zoom"))
+		if err != nil || zoom < 0 || zoom > services.MaxClusterZoom {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("zoom must be an integer from 0 to %d", services.MaxClusterZoom)})
+			return
+		}
+
+		clusters, err := h.service.GetPandalClusters(ctx, area, zoom, c.Query("tag"), c.Query("district"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": clusters})
+	}
+}
+
+// ExportPandals downloads the filtered GetAllPandals result as a GeoJSON FeatureCollection.
+// It takes the same query params as GetAllPandals; the cursor for the next page, or for
+// area queries whether the result was truncated, is sent in the X-Next-Cursor or
+// X-Truncated header so the body stays plain GeoJSON.
+// GET /pandals/export
+func (h *PandalHandler) ExportPandals() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		tag := c.Query("tag")
+		search := c.Query("q")
+		district := c.Query("district")
+
+		area, limit, hasArea, ok := parseAreaQuery(c)
+		if !ok {
+			return
+		}
 
-		if lngStr != "" && latStr != "" {
-			var err1, err2 error
-			lng, err1 = strconv.ParseFloat(lngStr, 64)
-			lat, err2 = strconv.ParseFloat(latStr, 64)
+		var pandals []models.Pandal
+		if hasArea {
+			var truncated bool
+			var err error
+			pandals, truncated, err = h.service.GetPandalsInArea(ctx, area, tag, search, district, limit)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.Header("X-Truncated", strconv.FormatBool(truncated))
+		} else {
+			near, ok := parseGeoQuery(c)
+			if !ok {
+				return
+			}
 
-			if err1 != nil || err2 != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lng or lat coordinates"})
+			page, ok := parsePageParams(c)
+			if !ok {
 				return
 			}
-			hasCoords = true
 
-			if radiusStr != "" {
-				r, err := strconv.ParseFloat(radiusStr, 64)
-				if err == nil {
-					radius = r
-				}
+			var nextCursor string
+			var err error
+			pandals, nextCursor, err = h.service.GetPandals(ctx, near, tag, search, district, page)
+			if err != nil {
+				c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			if nextCursor != "" {
+				c.Header("X-Next-Cursor", nextCursor)
 			}
-		} else if lngStr != "" || latStr != "" {
-			// If only one is provided, flag it as a bad request
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Both lng and lat query parameters are required for a geospatial search"})
-			return
 		}
 
-		pandals, err := h.service.GetPandals(ctx, lng, lat, radius, hasCoords, tag, search, district)
+		body, err := json.Marshal(export.PandalFeatures(pandals))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"data": pandals})
+		c.Header("Content-Disposition", `attachment; filename="pandals.geojson"`)
+		c.Data(http.StatusOK, export.FormatGeoJSON.ContentType(), body)
+	}
+}
+
+// parseGeoQuery reads the optional lng, lat, radius and minRadius query params.
+// near is nil when no point was given; ok is false once a 400 response has been written.
+func parseGeoQuery(c *gin.Context) (near *services.Proximity, ok bool) {
+	lngStr := c.Query("lng")
+	latStr := c.Query("lat")
+	radiusStr := c.Query("radius")
+	minRadiusStr := c.Query("minRadius")
+
+	if lngStr == "" && latStr == "" {
+		if minRadiusStr != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "minRadius needs lng and lat"})
+			return nil, false
+		}
+		return nil, true
+	}
+	if lngStr == "" || latStr == "" {
+		// If only one is provided, flag it as a bad request
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Both lng and lat query parameters are required for a geospatial search"})
+		return nil, false
+	}
+
+	lng, err1 := strconv.ParseFloat(lngStr, 64)
+	lat, err2 := strconv.ParseFloat(latStr, 64)
+	if err1 != nil || err2 != nil || validation.ValidateCoordinates([]float64{lng, lat}) != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lng or lat coordinates"})
+		return nil, false
+	}
+	near = &services.Proximity{Origin: geo.Point{Lng: lng, Lat: lat}}
+
+	if radiusStr != "" {
+		if r, err := strconv.ParseFloat(radiusStr, 64); err == nil {
+			near.Radius = r
+		}
+	}
+	if minRadiusStr != "" {
+		minRadius, err := strconv.ParseFloat(minRadiusStr, 64)
+		if err != nil || minRadius < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "minRadius must be a non-negative number of meters"})
+			return nil, false
+		}
+		near.MinRadius = minRadius
+	}
+
+	maxRadius := near.Radius
+	if maxRadius <= 0 {
+		maxRadius = services.DefaultSearchRadiusMeters
+	}
+	if near.MinRadius >= maxRadius {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "minRadius must be less than radius"})
+		return nil, false
+	}
+	return near, true
+}
+
+// parseAreaQuery reads the optional bbox=minLng,minLat,maxLng,maxLat or polygon=<GeoJSON Polygon>
+// viewport params along with their result limit.
+// It writes a 400 response and returns ok=false when the params are invalid.
+func parseAreaQuery(c *gin.Context) (area geo.Polygon, limit int, hasArea, ok bool) {
+	bbox := c.Query("bbox")
+	polygon := c.Query("polygon")
+	if bbox == "" && polygon == "" {
+		return nil, 0, false, true
+	}
+
+	if bbox != "" && polygon != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Use either bbox or polygon, not both"})
+		return nil, 0, false, false
+	}
+	if c.Query("lng") != "" || c.Query("lat") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bbox and polygon cannot be combined with lng and lat"})
+		return nil, 0, false, false
+	}
+
+	var err error
+	if bbox != "" {
+		area, err = geo.ParseBBox(bbox)
+	} else {
+		area, err = geo.ParsePolygon(polygon)
 	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, 0, false, false
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return nil, 0, false, false
+		}
+	}
+
+	return area, limit, true, true
 }
 
 // GetDistricts returns approved pandals grouped by district
@@ -133,44 +346,25 @@ func (h *PandalHandler) GetPendingPandals() gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
-		lngStr := c.Query("lng")
-		latStr := c.Query("lat")
-		radiusStr := c.Query("radius")
-
-		var hasCoords bool
-		var lng, lat, radius float64
-
-		if lngStr != "" && latStr != "" {
-			var err1, err2 error
-			lng, err1 = strconv.ParseFloat(lngStr, 64)
-			lat, err2 = strconv.ParseFloat(latStr, 64)
-
-			if err1 != nil || err2 != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lng or lat coordinates"})
-				return
-			}
-			hasCoords = true
-
-			if radiusStr != "" {
-				r, err := strconv.ParseFloat(radiusStr, 64)
-				if err == nil {
-					radius = r
-				}
-			}
-		} else if lngStr != "" || latStr != "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Both lng and lat query parameters are required for a geospatial search"})
+		near, ok := parseGeoQuery(c)
+		if !ok {
 			return
 		}
 
 		userID := c.GetString("userID")
 
-		pandals, err := h.service.GetPendingPandals(ctx, lng, lat, radius, hasCoords, userID)
+		page, ok := parsePageParams(c)
+		if !ok {
+			return
+		}
+
+		pandals, nextCursor, err := h.service.GetPendingPandals(ctx, near, userID, page)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"data": pandals})
+		c.JSON(http.StatusOK, gin.H{"data": pandals, "nextCursor": nextCursor})
 	}
 }
 
@@ -191,7 +385,7 @@ func (h *PandalHandler) ApprovePandal() gin.HandlerFunc {
 
 		pandal, err := h.service.ApprovePandal(ctx, objID, approverID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error approving pandal: " + err.Error()})
+			c.JSON(pandalErrorStatus(err), gin.H{"error": "Error approving pandal: " + err.Error()})
 			return
 		}
 
@@ -201,3 +395,175 @@ func (h *PandalHandler) ApprovePandal() gin.HandlerFunc {
 		})
 	}
 }
+
+// RejectPandal registers a rejection vote with a mandatory reason
+// PUT /pandals/:id/reject
+func (h *PandalHandler) RejectPandal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Pandal ID format"})
+			return
+		}
+
+		var req models.RejectPandalRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		pandal, err := h.service.RejectPandal(ctx, objID, c.GetString("userID"), req.Reason)
+		if err != nil {
+			c.JSON(pandalErrorStatus(err), gin.H{"error": "Error rejecting pandal: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Pandal rejection registered",
+			"data":    pandal,
+		})
+	}
+}
+
+// pandalErrorStatus maps pandal service errors onto HTTP status codes
+func pandalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidPandal), errors.Is(err, services.ErrRejectionReasonRequired),
+		errors.Is(err, services.ErrMergeIntoSelf):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrPandalNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrVoterNotFound):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrNotPandalOwner), errors.Is(err, services.ErrEmailNotVerified):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrPandalDeleted), errors.Is(err, services.ErrPandalNotDeleted),
+		errors.Is(err, services.ErrPandalRejected), errors.Is(err, services.ErrPandalApproved),
+		errors.Is(err, services.ErrAlreadyApproved), errors.Is(err, services.ErrAlreadyRejected),
+		errors.Is(err, services.ErrConflictingVote), errors.Is(err, services.ErrVoteConflict),
+		errors.Is(err, services.ErrEditConflict),
+		errors.Is(err, services.ErrPandalMerged):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ReplacePandal overwrites every editable field of a pandal
+// PUT /pandals/:id
+func (h *PandalHandler) ReplacePandal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Pandal ID format"})
+			return
+		}
+
+		var pandal models.Pandal
+		if err := c.ShouldBindJSON(&pandal); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		req := models.UpdatePandalRequest{
+			Name:        &pandal.Name,
+			Description: &pandal.Description,
+			Area:        &pandal.Area,
+			District:    &pandal.District,
+			State:       &pandal.State,
+			Country:     &pandal.Country,
+			Theme:       &pandal.Theme,
+			Tags:        &pandal.Tags,
+			Location:    &pandal.Location,
+			Images:      &pandal.Images,
+		}
+
+		updated, err := h.service.UpdatePandal(ctx, objID, req, c.GetString("userID"))
+		if err != nil {
+			c.JSON(pandalErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Pandal updated", "data": updated})
+	}
+}
+
+// PatchPandal updates only the fields present in the request body
+// PATCH /pandals/:id
+func (h *PandalHandler) PatchPandal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Pandal ID format"})
+			return
+		}
+
+		var req models.UpdatePandalRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		updated, err := h.service.UpdatePandal(ctx, objID, req, c.GetString("userID"))
+		if err != nil {
+			c.JSON(pandalErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Pandal updated", "data": updated})
+	}
+}
+
+// DeletePandal soft deletes a pandal
+// DELETE /pandals/:id
+func (h *PandalHandler) DeletePandal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Pandal ID format"})
+			return
+		}
+
+		if err := h.service.DeletePandal(ctx, objID, c.GetString("userID")); err != nil {
+			c.JSON(pandalErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Pandal deleted"})
+	}
+}
+
+// RestorePandal brings back a soft deleted pandal
+// PUT /pandals/:id/restore
+func (h *PandalHandler) RestorePandal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Pandal ID format"})
+			return
+		}
+
+		pandal, err := h.service.RestorePandal(ctx, objID, c.GetString("userID"))
+		if err != nil {
+			c.JSON(pandalErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Pandal restored", "data": pandal})
+	}
+}
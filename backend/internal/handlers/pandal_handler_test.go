@@ -0,0 +1,337 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/auth"
+	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
+	"tirthankarkundu17/pandal-hopping-api/internal/middleware"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/repository"
+	"tirthankarkundu17/pandal-hopping-api/internal/routes"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+	"tirthankarkundu17/pandal-hopping-api/internal/validation"
+)
+
+// voteFixture is a pandal API backed by a throwaway MongoDB database
+type voteFixture struct {
+	router  *gin.Engine
+	keys    *auth.KeyManager
+	users   repository.UserRepository
+	pandals repository.PandalRepository
+}
+
+// newVoteFixture needs a MongoDB server, which the votes' pipeline updates run on;
+// it is skipped unless TEST_MONGO_URI is set. The edit retry and vote reset are also
+// covered against in-memory fakes by the services tests.
+func newVoteFixture(t *testing.T) *voteFixture {
+	t.Helper()
+	uri := os.Getenv("TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database("pandal_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = db.Drop(ctx)
+		_ = client.Disconnect(ctx)
+	})
+
+	if err := validation.LoadAdministrativeData(); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := auth.NewKeyManager(auth.Config{DevMode: true, Issuer: "test", Audience: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	middleware.UseKeyManager(keys)
+
+	f := &voteFixture{
+		keys:    keys,
+		users:   repository.NewUserRepository(db.Collection("users")),
+		pandals: repository.NewPandalRepository(db.Collection("durgapuja")),
+	}
+	gin.SetMode(gin.TestMode)
+	f.router = gin.New()
+	handler := handlers.NewPandalHandler(services.NewPandalService(f.pandals, f.users, nil))
+	routes.PandalRoute(f.router.Group("/api/v1"), handler)
+	return f
+}
+
+// newVoter creates a user with a verified email and returns their ID and access token
+func (f *voteFixture) newVoter(t *testing.T) (string, string) {
+	t.Helper()
+	user := &models.User{Name: "voter", Email: primitive.NewObjectID().Hex() + "@example.com", Role: models.RoleUser, EmailVerified: true}
+	if err := f.users.CreateUser(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	token, err := f.keys.Sign(jwt.MapClaims{
+		"sub":  user.ID.Hex(),
+		"role": string(models.RoleUser),
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	}, auth.KindAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user.ID.Hex(), token
+}
+
+// newPendingPandal inserts a pending pandal created by ownerID, pinned in Kolkata
+func (f *voteFixture) newPendingPandal(t *testing.T, ownerID string) primitive.ObjectID {
+	t.Helper()
+	lng, lat := 88.3639, 22.5726
+	resolved, ok := validation.ResolveLocation(lng, lat)
+	if !ok {
+		t.Fatal("test pin is outside every district")
+	}
+	pandal := models.Pandal{
+		ID:         primitive.NewObjectID(),
+		Name:       "Test Sarbojanin",
+		Area:       "Test Para",
+		Country:    resolved.Country,
+		State:      resolved.State,
+		District:   resolved.District,
+		Location:   models.Location{Type: "Point", Coordinates: []float64{lng, lat}},
+		Images:     []string{},
+		Status:     models.StatusPending,
+		ApprovedBy: []string{},
+		RejectedBy: []string{},
+		Rejections: []models.Rejection{},
+		CreatedBy:  ownerID,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if _, err := f.pandals.Create(context.Background(), pandal); err != nil {
+		t.Fatal(err)
+	}
+	return pandal.ID
+}
+
+func (f *voteFixture) do(method, path, token, body string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+// TestConcurrentApprovals has every voter approve twice at once: each must be counted
+// exactly once, the duplicate must be refused with a 409, and the count must match
+// the voter list
+func TestConcurrentApprovals(t *testing.T) {
+	f := newVoteFixture(t)
+	t.Setenv("REQUIRED_APPROVALS", "1000")
+
+	ownerID, _ := f.newVoter(t)
+	id := f.newPendingPandal(t, ownerID)
+
+	const voters = 25
+	tokens := make([]string, voters)
+	for i := range tokens {
+		_, tokens[i] = f.newVoter(t)
+	}
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 2*voters)
+	for _, token := range tokens {
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- f.do(http.MethodPut, "/api/v1/pandals/"+id.Hex()+"/approve", token, "")
+			}()
+		}
+	}
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	if counts[http.StatusOK] != voters || counts[http.StatusConflict] != voters {
+		t.Fatalf("got status counts %v, want %d OK and %d Conflict", counts, voters, voters)
+	}
+
+	pandal, err := f.pandals.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pandal.ApprovalCount != voters || len(pandal.ApprovedBy) != voters {
+		t.Fatalf("approvalCount = %d, approvedBy has %d entries, want %d", pandal.ApprovalCount, len(pandal.ApprovedBy), voters)
+	}
+	if pandal.Status != models.StatusPending {
+		t.Fatalf("status = %s, want pending", pandal.Status)
+	}
+}
+
+// TestConcurrentApprovalsReachThreshold checks the status flips exactly once and no
+// vote is counted past the threshold
+func TestConcurrentApprovalsReachThreshold(t *testing.T) {
+	f := newVoteFixture(t)
+	t.Setenv("REQUIRED_APPROVALS", "5")
+
+	ownerID, _ := f.newVoter(t)
+	id := f.newPendingPandal(t, ownerID)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		_, token := f.newVoter(t)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if code := f.do(http.MethodPut, "/api/v1/pandals/"+id.Hex()+"/approve", token, ""); code != http.StatusOK {
+				t.Errorf("approve returned %d", code)
+			}
+		}()
+	}
+	wg.Wait()
+
+	pandal, err := f.pandals.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pandal.Status != models.StatusApproved || pandal.ApprovalCount != 5 || len(pandal.ApprovedBy) != 5 {
+		t.Fatalf("status = %s, approvalCount = %d, approvedBy = %d, want approved with 5", pandal.Status, pandal.ApprovalCount, len(pandal.ApprovedBy))
+	}
+}
+
+// TestEditDoesNotLoseConcurrentVotes races the owner's edits against approvals; every
+// approval that was accepted must still be recorded afterwards
+func TestEditDoesNotLoseConcurrentVotes(t *testing.T) {
+	f := newVoteFixture(t)
+	t.Setenv("REQUIRED_APPROVALS", "1000")
+
+	ownerID, ownerToken := f.newVoter(t)
+	id := f.newPendingPandal(t, ownerID)
+
+	const voters = 25
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted []string
+	)
+	for i := range voters {
+		voterID, token := f.newVoter(t)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if f.do(http.MethodPut, "/api/v1/pandals/"+id.Hex()+"/approve", token, "") == http.StatusOK {
+				mu.Lock()
+				accepted = append(accepted, voterID)
+				mu.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"description":"edit %d"}`, i)
+			if code := f.do(http.MethodPatch, "/api/v1/pandals/"+id.Hex(), ownerToken, body); code != http.StatusOK && code != http.StatusConflict {
+				t.Errorf("edit returned %d", code)
+			}
+		}()
+	}
+	wg.Wait()
+
+	pandal, err := f.pandals.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accepted) != voters {
+		t.Fatalf("%d of %d approvals were accepted", len(accepted), voters)
+	}
+	for _, voterID := range accepted {
+		found := false
+		for _, approver := range pandal.ApprovedBy {
+			found = found || approver == voterID
+		}
+		if !found {
+			t.Fatalf("approval of %s was lost", voterID)
+		}
+	}
+	if pandal.ApprovalCount != len(pandal.ApprovedBy) {
+		t.Fatalf("approvalCount = %d but approvedBy has %d entries", pandal.ApprovalCount, len(pandal.ApprovedBy))
+	}
+
+	// Votes were cast for this name and pin, so renaming or moving the pandal clears them
+	for _, body := range []string{
+		`{"name":"Renamed Sarbojanin"}`,
+		`{"location":{"type":"Point","coordinates":[88.3645,22.5731]}}`,
+	} {
+		_, token := f.newVoter(t)
+		if code := f.do(http.MethodPut, "/api/v1/pandals/"+id.Hex()+"/approve", token, ""); code != http.StatusOK {
+			t.Fatalf("approve returned %d", code)
+		}
+		if code := f.do(http.MethodPatch, "/api/v1/pandals/"+id.Hex(), ownerToken, body); code != http.StatusOK {
+			t.Fatalf("edit %s returned %d", body, code)
+		}
+		pandal, err := f.pandals.FindByID(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pandal.ApprovalCount != 0 || len(pandal.ApprovedBy) != 0 || pandal.Status != models.StatusPending {
+			t.Fatalf("after edit %s: status %s with %d approvals, want pending with none", body, pandal.Status, pandal.ApprovalCount)
+		}
+	}
+}
+
+// TestVotesNeedAnExistingVerifiedAccount checks both vote endpoints refuse unverified
+// users with a 403 and tokens of deleted accounts with a 401
+func TestVotesNeedAnExistingVerifiedAccount(t *testing.T) {
+	f := newVoteFixture(t)
+	ctx := context.Background()
+
+	ownerID, _ := f.newVoter(t)
+	id := f.newPendingPandal(t, ownerID)
+
+	unverifiedID, unverified := f.newVoter(t)
+	objID, _ := primitive.ObjectIDFromHex(unverifiedID)
+	if err := f.users.UpdateProfile(ctx, objID, bson.M{"emailVerified": false}); err != nil {
+		t.Fatal(err)
+	}
+	deletedID, deleted := f.newVoter(t)
+	objID, _ = primitive.ObjectIDFromHex(deletedID)
+	if err := f.users.Delete(ctx, objID); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name, path, body string
+	}{
+		{"approve", "/approve", ""},
+		{"reject", "/reject", `{"reason":"not a real pandal"}`},
+	} {
+		if code := f.do(http.MethodPut, "/api/v1/pandals/"+id.Hex()+tc.path, unverified, tc.body); code != http.StatusForbidden {
+			t.Errorf("%s by an unverified user returned %d, want 403", tc.name, code)
+		}
+		if code := f.do(http.MethodPut, "/api/v1/pandals/"+id.Hex()+tc.path, deleted, tc.body); code != http.StatusUnauthorized {
+			t.Errorf("%s by a deleted user returned %d, want 401", tc.name, code)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+)
+
+// ReviewHandler handles HTTP requests for pandal reviews
+type ReviewHandler struct {
+	service services.ReviewService
+}
+
+// NewReviewHandler creates a new handler instance
+func NewReviewHandler(service services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{service: service}
+}
+
+// reviewErrorStatus maps review service errors onto HTTP status codes
+func reviewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrReviewNotFound), errors.Is(err, services.ErrPandalNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrNotReviewOwner):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrReviewExists), errors.Is(err, services.ErrPandalNotRatable):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetReviews lists one page of a pandal's reviews, newest first by default
+// GET /pandals/:id/reviews?limit=&sort=&cursor=
+func (h *ReviewHandler) GetReviews() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		pandalID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Pandal ID format"})
+			return
+		}
+
+		page, ok := parsePageParams(c)
+		if !ok {
+			return
+		}
+
+		reviews, nextCursor, err := h.service.GetReviews(ctx, pandalID, page)
+		if err != nil {
+			c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": reviews, "nextCursor": nextCursor})
+	}
+}
+
+// CreateReview adds the caller's review of a pandal
+// POST /pandals/:id/reviews
+func (h *ReviewHandler) CreateReview() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		pandalID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Pandal ID format"})
+			return
+		}
+
+		var req models.ReviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		review, err := h.service.CreateReview(ctx, pandalID, c.GetString("userID"), req)
+		if err != nil {
+			c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Review created", "data": review})
+	}
+}
+
+// UpdateReview edits the caller's own review
+// PUT /reviews/:id
+func (h *ReviewHandler) UpdateReview() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+			return
+		}
+
+		var req models.ReviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		review, err := h.service.UpdateReview(ctx, objID, c.GetString("userID"), req)
+		if err != nil {
+			c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Review updated", "data": review})
+	}
+}
+
+// DeleteReview removes the caller's own review
+// DELETE /reviews/:id
+func (h *ReviewHandler) DeleteReview() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+			return
+		}
+
+		if err := h.service.DeleteReview(ctx, objID, c.GetString("userID")); err != nil {
+			c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Review deleted"})
+	}
+}
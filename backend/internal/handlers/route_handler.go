@@ -2,17 +2,21 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"tirthankarkundu17/pandal-hopping-api/internal/export"
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
 	"tirthankarkundu17/pandal-hopping-api/internal/services"
 )
 
-// RouteHandler handles HTTP requests for curated routes
+// RouteHandler handles HTTP requests for curated routes and user itineraries
 type RouteHandler struct {
 	service services.RouteService
 }
@@ -22,23 +26,46 @@ func NewRouteHandler(service services.RouteService) *RouteHandler {
 	return &RouteHandler{service: service}
 }
 
-// GetRoutes returns all curated routes
-// GET /routes/
+// writeRouteError maps route service errors onto HTTP responses.
+// Validation errors are returned whole so clients can see the offending stop IDs.
+func writeRouteError(c *gin.Context, err error) {
+	var verr *services.RouteValidationError
+	switch {
+	case errors.As(err, &verr):
+		c.JSON(http.StatusBadRequest, verr)
+	case errors.Is(err, services.ErrRouteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
+	case errors.Is(err, services.ErrNotRouteOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case pagination.IsClientError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetRoutes returns one page of public routes
+// GET /routes/?limit=&sort=&cursor=
 func (h *RouteHandler) GetRoutes() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 		defer cancel()
 
-		routes, err := h.service.GetRoutes(ctx)
+		page, ok := parsePageParams(c)
+		if !ok {
+			return
+		}
+
+		routes, nextCursor, err := h.service.GetRoutes(ctx, page)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(listErrorStatus(err), gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"data": routes})
+		c.JSON(http.StatusOK, gin.H{"data": routes, "nextCursor": nextCursor})
 	}
 }
 
-// GetRouteByID returns a single route by ID
+// GetRouteByID returns a single route by ID with its stops expanded into full pandals
 // GET /routes/:id
 func (h *RouteHandler) GetRouteByID() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -51,16 +78,79 @@ func (h *RouteHandler) GetRouteByID() gin.HandlerFunc {
 			return
 		}
 
-		route, err := h.service.GetRouteByID(ctx, objID)
+		route, err := h.service.GetRouteByID(ctx, objID, c.GetString("userID"))
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Route not found"})
+			writeRouteError(c, err)
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"data": route})
 	}
 }
 
-// CreateRoute inserts a new curated route (admin only)
+// ExportRoute downloads a route's stops as a GPX, KML or GeoJSON file
+// GET /routes/:id/export?format=gpx|kml|geojson
+func (h *RouteHandler) ExportRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route ID"})
+			return
+		}
+
+		format, err := export.ParseFormat(c.DefaultQuery("format", string(export.FormatGPX)))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		route, err := h.service.GetRouteByID(ctx, objID, c.GetString("userID"))
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		writeRouteExport(c, route, format)
+	}
+}
+
+// ExportSharedRoute downloads an unlisted route's stops for anyone holding its share token
+// GET /routes/shared/:token/export?format=gpx|kml|geojson
+func (h *RouteHandler) ExportSharedRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		format, err := export.ParseFormat(c.DefaultQuery("format", string(export.FormatGPX)))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		route, err := h.service.GetSharedRoute(ctx, c.Param("token"))
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		writeRouteExport(c, route, format)
+	}
+}
+
+// writeRouteExport sends route as a file download in format
+func writeRouteExport(c *gin.Context, route *models.RouteWithStops, format export.Format) {
+	body, err := export.Route(route, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("route-%s.%s", route.ID.Hex(), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, format.ContentType(), body)
+}
+
+// CreateRoute inserts a new curated route (admin or moderator only)
 // POST /routes/
 func (h *RouteHandler) CreateRoute() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -73,11 +163,278 @@ func (h *RouteHandler) CreateRoute() gin.HandlerFunc {
 			return
 		}
 
-		result, err := h.service.CreateRoute(ctx, route)
+		result, err := h.service.CreateRoute(ctx, route, c.GetString("userID"))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			writeRouteError(c, err)
 			return
 		}
 		c.JSON(http.StatusCreated, gin.H{"message": "Route created", "data": result})
 	}
 }
+
+// PlanRoute orders a set of pandals into the shortest walking tour that fits a time budget
+// POST /routes/plan
+func (h *RouteHandler) PlanRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var req models.PlanRouteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		plan, err := h.service.PlanRoute(ctx, req)
+		var verr *services.RouteValidationError
+		if errors.As(err, &verr) {
+			c.JSON(http.StatusBadRequest, verr)
+			return
+		}
+		if errors.Is(err, services.ErrInvalidPlanRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": plan})
+	}
+}
+
+// GetMyRoutes returns one page of the caller's own routes
+// GET /routes/mine?limit=&sort=&cursor=
+func (h *RouteHandler) GetMyRoutes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		page, ok := parsePageParams(c)
+		if !ok {
+			return
+		}
+
+		routes, nextCursor, err := h.service.GetMyRoutes(ctx, c.GetString("userID"), page)
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": routes, "nextCursor": nextCursor})
+	}
+}
+
+// CreatePersonalRoute creates an itinerary owned by the caller
+// POST /routes/mine
+func (h *RouteHandler) CreatePersonalRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var req models.PersonalRouteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		route, err := h.service.CreatePersonalRoute(ctx, req, c.GetString("userID"))
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Route created", "data": route})
+	}
+}
+
+// GetSharedRoute shows an unlisted route to anyone holding its share token
+// GET /routes/shared/:token
+func (h *RouteHandler) GetSharedRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		route, err := h.service.GetSharedRoute(ctx, c.Param("token"))
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": route})
+	}
+}
+
+// UpdateRoute edits the title, description or visibility of the caller's route
+// PATCH /routes/:id
+func (h *RouteHandler) UpdateRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route ID"})
+			return
+		}
+
+		var req models.UpdateRouteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		route, err := h.service.UpdateRoute(ctx, objID, req, c.GetString("userID"))
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Route updated", "data": route})
+	}
+}
+
+// AddStop inserts a pandal into the caller's route
+// POST /routes/:id/stops
+func (h *RouteHandler) AddStop() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route ID"})
+			return
+		}
+
+		var req models.AddStopRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		route, err := h.service.AddStop(ctx, objID, req, c.GetString("userID"))
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Stop added", "data": route})
+	}
+}
+
+// ReorderStops sets a new order for the caller's route stops
+// PUT /routes/:id/stops
+func (h *RouteHandler) ReorderStops() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route ID"})
+			return
+		}
+
+		var req models.ReorderStopsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		route, err := h.service.ReorderStops(ctx, objID, req.Stops, c.GetString("userID"))
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Stops reordered", "data": route})
+	}
+}
+
+// RemoveStop drops a pandal from the caller's route
+// DELETE /routes/:id/stops/:pandalId
+func (h *RouteHandler) RemoveStop() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route ID"})
+			return
+		}
+		pandalID, err := primitive.ObjectIDFromHex(c.Param("pandalId"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Pandal ID format"})
+			return
+		}
+
+		route, err := h.service.RemoveStop(ctx, objID, pandalID, c.GetString("userID"))
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Stop removed", "data": route})
+	}
+}
+
+// CloneRoute copies a visible route into a new private route owned by the caller
+// POST /routes/:id/clone
+func (h *RouteHandler) CloneRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route ID"})
+			return
+		}
+
+		route, err := h.service.CloneRoute(ctx, objID, c.GetString("userID"))
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Route cloned", "data": route})
+	}
+}
+
+// ShareRoute issues (or rotates) the share token of the caller's route
+// POST /routes/:id/share
+func (h *RouteHandler) ShareRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route ID"})
+			return
+		}
+
+		route, err := h.service.ShareRoute(ctx, objID, c.GetString("userID"))
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Route shared", "data": route})
+	}
+}
+
+// UnshareRoute revokes the share token of the caller's route
+// DELETE /routes/:id/share
+func (h *RouteHandler) UnshareRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid route ID"})
+			return
+		}
+
+		route, err := h.service.UnshareRoute(ctx, objID, c.GetString("userID"))
+		if err != nil {
+			writeRouteError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Route unshared", "data": route})
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+	"tirthankarkundu17/pandal-hopping-api/internal/tiles"
+)
+
+// TileHandler serves map vector tiles
+type TileHandler struct {
+	service services.TileService
+}
+
+// NewTileHandler creates a new handler instance
+func NewTileHandler(service services.TileService) *TileHandler {
+	return &TileHandler{service: service}
+}
+
+// GetTile returns a Mapbox Vector Tile with "pandals" and "food" point layers
+// GET /tiles/:z/:x/:y.mvt
+func (h *TileHandler) GetTile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		y, ok := strings.CutSuffix(c.Param("y"), ".mvt")
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tiles are served as .mvt"})
+			return
+		}
+		tile, err := tiles.Parse(c.Param("z"), c.Param("x"), y)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		data, err := h.service.GetTile(ctx, tile)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Tiles include pending pandals and are served to signed in users only
+		c.Header("Cache-Control", "private, max-age=60")
+		c.Data(http.StatusOK, tiles.ContentType, data)
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+)
+
+// UserHandler handles HTTP requests for user account management
+type UserHandler struct {
+	service services.UserService
+}
+
+// NewUserHandler creates a new handler instance
+func NewUserHandler(service services.UserService) *UserHandler {
+	return &UserHandler{service: service}
+}
+
+// GrantRole assigns a role to a user (admin only)
+// PUT /admin/users/:id/role
+func (h *UserHandler) GrantRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		var req models.UpdateRoleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		user, err := h.service.SetRole(ctx, objID, req.Role, c.GetString("userID"))
+		if err != nil {
+			c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Role granted", "data": user})
+	}
+}
+
+// RevokeRole drops a user back to the default role (admin only)
+// DELETE /admin/users/:id/role
+func (h *UserHandler) RevokeRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		user, err := h.service.SetRole(ctx, objID, models.RoleUser, c.GetString("userID"))
+		if err != nil {
+			c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Role revoked", "data": user})
+	}
+}
+
+// UnlockUser lifts a login lockout after too many failed attempts (admin only)
+// POST /admin/users/:id/unlock
+func (h *UserHandler) UnlockUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		objID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		user, err := h.service.Unlock(ctx, objID)
+		if err != nil {
+			c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "User unlocked", "data": user})
+	}
+}
+
+// GetMe returns the caller's own account
+// GET /users/me
+func (h *UserHandler) GetMe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		user, err := h.service.GetProfile(ctx, c.GetString("userID"))
+		if err != nil {
+			c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": user})
+	}
+}
+
+// UpdateMe changes the caller's name, avatar, preferred language or home district
+// PATCH /users/me
+func (h *UserHandler) UpdateMe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var req models.UpdateProfileRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		user, err := h.service.UpdateProfile(ctx, c.GetString("userID"), req)
+		if err != nil {
+			c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Profile updated", "data": user})
+	}
+}
+
+// ChangePassword replaces the caller's password, signing out every session
+// POST /users/me/password
+func (h *UserHandler) ChangePassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+		defer cancel()
+
+		var req models.ChangePasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := h.service.ChangePassword(ctx, c.GetString("userID"), req, c.GetTime("authTime"))
+		var throttled *services.LoginThrottledError
+		if errors.As(err, &throttled) {
+			tooManyRequests(c, err, throttled.RetryAfter)
+			return
+		}
+		if err != nil {
+			c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed, please log in again"})
+	}
+}
+
+// DeleteMe deletes the caller's account, anonymising what they contributed.
+// Accounts without a password may send no body at all. Refresh tokens are revoked,
+// but access tokens already issued stay valid until they expire, within an hour;
+// only votes check that the account still exists.
+// DELETE /users/me
+func (h *UserHandler) DeleteMe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+
+		var req models.DeleteAccountRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := h.service.DeleteAccount(ctx, c.GetString("userID"), req.Password, c.GetTime("authTime"))
+		var throttled *services.LoginThrottledError
+		if errors.As(err, &throttled) {
+			tooManyRequests(c, err, throttled.RetryAfter)
+			return
+		}
+		if err != nil {
+			c.JSON(userErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
+	}
+}
+
+// userErrorStatus maps account service errors onto HTTP status codes
+func userErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrWrongPassword):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrRecentSignInNeeded):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrAdminDeletion):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidProfile), errors.Is(err, services.ErrUnsupportedLanguage),
+		errors.Is(err, services.ErrInvalidAvatarURL), errors.Is(err, services.ErrInvalidRole),
+		errors.Is(err, services.ErrSelfDemotion):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
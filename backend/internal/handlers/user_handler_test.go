@@ -0,0 +1,60 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+)
+
+// stubUserService records account deletions; the embedded interface panics on anything else
+type stubUserService struct {
+	services.UserService
+	deleted  []string
+	password string
+}
+
+func (s *stubUserService) DeleteAccount(ctx context.Context, userID, password string, signedInAt time.Time) error {
+	s.deleted = append(s.deleted, userID)
+	s.password = password
+	return nil
+}
+
+func TestDeleteMeBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		password string
+	}{
+		{"no body", "", http.StatusOK, ""},
+		{"empty object", "{}", http.StatusOK, ""},
+		{"password", `{"password": "secret"}`, http.StatusOK, "secret"},
+		{"malformed", `{"password":`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubUserService{}
+			router := gin.New()
+			router.DELETE("/users/me", func(c *gin.Context) { c.Set("userID", "user-1") }, handlers.NewUserHandler(service).DeleteMe())
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/me", strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Fatalf("got %d (%s), want %d", w.Code, w.Body, tt.status)
+			}
+			if tt.status == http.StatusOK && (len(service.deleted) != 1 || service.password != tt.password) {
+				t.Fatalf("deleted %v with password %q, want user-1 with %q", service.deleted, service.password, tt.password)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// csvColumns are the header names ParseCSV understands. Matching is case-insensitive
+// and unknown columns are ignored. tags and images are ';' or '|' separated lists.
+var csvColumns = []string{"name", "description", "area", "district", "state", "country", "theme", "tags", "lng", "lat", "images"}
+
+// ParseCSV reads pandals from a CSV file whose first line is a header
+func ParseCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("csv file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read csv header: %w", err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"name", "lng", "lat"} {
+		if _, ok := index[required]; !ok {
+			return nil, fmt.Errorf("csv header is missing the %q column (expected %s)", required, strings.Join(csvColumns, ","))
+		}
+	}
+
+	records := []Record{}
+	for row := 1; ; row++ {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				records = append(records, Record{Row: row, Errors: []string{parseErr.Err.Error()}})
+				continue
+			}
+			return nil, err
+		}
+
+		cell := func(name string) string {
+			i, ok := index[name]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[i])
+		}
+
+		record := Record{
+			Row: row,
+			Pandal: models.Pandal{
+				Name:        cell("name"),
+				Description: cell("description"),
+				Area:        cell("area"),
+				District:    cell("district"),
+				State:       cell("state"),
+				Country:     cell("country"),
+				Theme:       cell("theme"),
+				Tags:        splitTags(cell("tags")),
+				Images:      splitTags(cell("images")),
+			},
+		}
+
+		lng, lngErr := strconv.ParseFloat(cell("lng"), 64)
+		lat, latErr := strconv.ParseFloat(cell("lat"), 64)
+		if lngErr != nil || latErr != nil {
+			record.Errors = append(record.Errors, fmt.Sprintf("invalid coordinates lng=%q lat=%q", cell("lng"), cell("lat")))
+		} else {
+			record.Pandal.Location = models.Location{Type: "Point", Coordinates: []float64{lng, lat}}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,113 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// geoJSONFile is the subset of a GeoJSON FeatureCollection the importer reads
+type geoJSONFile struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Geometry   *geoJSONGeometry `json:"geometry"`
+	Properties geoJSONProps     `json:"properties"`
+}
+
+// geoJSONGeometry keeps the coordinates raw until the type is known, so a feature of
+// another geometry type is reported on its row instead of failing the whole file
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// geoJSONProps mirrors the pandal fields; tags and images may be arrays or ';' separated strings
+type geoJSONProps struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Area        string          `json:"area"`
+	District    string          `json:"district"`
+	State       string          `json:"state"`
+	Country     string          `json:"country"`
+	Theme       string          `json:"theme"`
+	Tags        json.RawMessage `json:"tags"`
+	Images      json.RawMessage `json:"images"`
+}
+
+// ParseGeoJSON reads pandals from a FeatureCollection of Point features
+func ParseGeoJSON(r io.Reader) ([]Record, error) {
+	var file geoJSONFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("could not decode geojson: %w", err)
+	}
+	if file.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("geojson must be a FeatureCollection, got %q", file.Type)
+	}
+
+	records := make([]Record, 0, len(file.Features))
+	for i, feature := range file.Features {
+		props := feature.Properties
+		record := Record{
+			Row: i + 1,
+			Pandal: models.Pandal{
+				Name:        strings.TrimSpace(props.Name),
+				Description: strings.TrimSpace(props.Description),
+				Area:        strings.TrimSpace(props.Area),
+				District:    strings.TrimSpace(props.District),
+				State:       strings.TrimSpace(props.State),
+				Country:     strings.TrimSpace(props.Country),
+				Theme:       strings.TrimSpace(props.Theme),
+			},
+		}
+
+		var err error
+		if record.Pandal.Tags, err = stringList(props.Tags); err != nil {
+			record.Errors = append(record.Errors, "tags: "+err.Error())
+		}
+		if record.Pandal.Images, err = stringList(props.Images); err != nil {
+			record.Errors = append(record.Errors, "images: "+err.Error())
+		}
+
+		switch {
+		case feature.Geometry == nil:
+			record.Errors = append(record.Errors, "feature has no geometry")
+		case feature.Geometry.Type != "Point":
+			record.Errors = append(record.Errors, fmt.Sprintf("geometry must be a Point, got %q", feature.Geometry.Type))
+		default:
+			var coordinates []float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &coordinates); err != nil {
+				record.Errors = append(record.Errors, "point coordinates must be [lng, lat]")
+				break
+			}
+			record.Pandal.Location = models.Location{Type: feature.Geometry.Type, Coordinates: coordinates}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// stringList accepts either a JSON array of strings or a single ';' separated string
+func stringList(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return []string{}, nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list, nil
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err != nil {
+		return []string{}, fmt.Errorf("expected a string or list of strings")
+	}
+	return splitTags(single), nil
+}
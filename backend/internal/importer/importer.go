@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// ErrUnsupportedFormat is returned for import formats other than csv and geojson
+var ErrUnsupportedFormat = errors.New("unsupported import format, use csv or geojson")
+
+// Format is a file format pandals can be imported from
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatGeoJSON Format = "geojson"
+)
+
+// Record is one pandal read from an import file.
+// Row is the 1-based data row (CSV) or feature index (GeoJSON) used in reports;
+// Errors holds problems found while parsing the row, such as unreadable coordinates.
+type Record struct {
+	Row    int
+	Pandal models.Pandal
+	Errors []string
+}
+
+// ParseFormat reads a format name case-insensitively
+func ParseFormat(value string) (Format, error) {
+	switch f := Format(strings.ToLower(value)); f {
+	case FormatCSV, FormatGeoJSON:
+		return f, nil
+	case "json":
+		return FormatGeoJSON, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// DetectFormat guesses the format from a file name, falling back to a content type
+func DetectFormat(filename, contentType string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".geojson", ".json":
+		return FormatGeoJSON, nil
+	}
+
+	switch {
+	case strings.Contains(contentType, "csv"):
+		return FormatCSV, nil
+	case strings.Contains(contentType, "json"):
+		return FormatGeoJSON, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Parse reads every record of an import file.
+// It fails only when the file as a whole is unreadable; row problems are kept on each Record.
+func Parse(r io.Reader, format Format) ([]Record, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatGeoJSON:
+		return ParseGeoJSON(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// splitTags splits a tag cell on ';' or '|' (commas clash with CSV) and drops blanks
+func splitTags(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == '|' })
+	tags := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if tag := strings.TrimSpace(field); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
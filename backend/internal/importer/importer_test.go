@@ -0,0 +1,168 @@
+package importer_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/importer"
+)
+
+func TestParseCSV(t *testing.T) {
+	// Columns out of order and in mixed case, a byte order mark, an unknown column,
+	// quoted cells holding commas and quotes, and a short row
+	file := "\ufeffLat,NAME,lng,District,notes,tags,Description\n" +
+		"22.6010,Bagbazar Sarbojanin,88.3697,KOL,ignored,heritage; river|north,\"Since 1919, by the \"\"ghat\"\"\"\n" +
+		"22.5186,  Ekdalia Evergreen  ,88.3667\n"
+
+	records, err := importer.ParseCSV(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+
+	first := records[0]
+	if first.Row != 1 || len(first.Errors) != 0 {
+		t.Fatalf("row 1: got row %d with errors %v", first.Row, first.Errors)
+	}
+	if first.Pandal.Name != "Bagbazar Sarbojanin" || first.Pandal.District != "KOL" {
+		t.Fatalf("row 1: got name %q, district %q", first.Pandal.Name, first.Pandal.District)
+	}
+	if first.Pandal.Description != `Since 1919, by the "ghat"` {
+		t.Fatalf("row 1: got description %q", first.Pandal.Description)
+	}
+	if !slices.Equal(first.Pandal.Tags, []string{"heritage", "river", "north"}) {
+		t.Fatalf("row 1: got tags %q", first.Pandal.Tags)
+	}
+	if first.Pandal.Location.Type != "Point" || !slices.Equal(first.Pandal.Location.Coordinates, []float64{88.3697, 22.6010}) {
+		t.Fatalf("row 1: got location %+v, want [lng, lat]", first.Pandal.Location)
+	}
+
+	second := records[1]
+	if second.Pandal.Name != "Ekdalia Evergreen" || second.Pandal.District != "" || second.Pandal.Description != "" {
+		t.Fatalf("row 2: got %+v", second.Pandal)
+	}
+	if len(second.Pandal.Tags) != 0 || len(second.Pandal.Images) != 0 {
+		t.Fatalf("row 2: got tags %q and images %q from missing cells", second.Pandal.Tags, second.Pandal.Images)
+	}
+}
+
+func TestParseCSVRowErrors(t *testing.T) {
+	file := "name,lng,lat\n" +
+		"No latitude,88.36,\n" +
+		"Words for numbers,east,north\n" +
+		"Bad quote,\"88.36,22.57\n"
+
+	records, err := importer.ParseCSV(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+	for _, record := range records {
+		if len(record.Errors) == 0 {
+			t.Errorf("row %d (%q) has no errors", record.Row, record.Pandal.Name)
+		}
+		if len(record.Pandal.Location.Coordinates) != 0 {
+			t.Errorf("row %d has a pin %v despite its errors", record.Row, record.Pandal.Location.Coordinates)
+		}
+	}
+}
+
+func TestParseCSVFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"empty file":         "",
+		"no lat column":      "name,lng\nBagbazar,88.36\n",
+		"no name column":     "title,lng,lat\nBagbazar,88.36,22.57\n",
+		"unreadable heading": "\"name,lng,lat\n",
+	}
+	for name, file := range tests {
+		if _, err := importer.ParseCSV(strings.NewReader(file)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestParseGeoJSON(t *testing.T) {
+	file := `{
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [88.3697, 22.6010]},
+			 "properties": {"name": " Bagbazar Sarbojanin ", "district": "KOL", "tags": ["heritage", "river"], "images": "a.jpg; b.jpg"}},
+			{"type": "Feature", "geometry": null, "properties": {"name": "No geometry"}},
+			{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[88.36, 22.57], [88.37, 22.58]]}, "properties": {"name": "A line"}},
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [88.36, 22.57]}, "properties": {"name": "Numeric tags", "tags": 5}},
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": "88.36, 22.57"}, "properties": {"name": "Text coordinates"}}
+		]
+	}`
+
+	records, err := importer.ParseGeoJSON(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("got %d records, want 5", len(records))
+	}
+
+	first := records[0]
+	if first.Row != 1 || len(first.Errors) != 0 {
+		t.Fatalf("feature 1: got row %d with errors %v", first.Row, first.Errors)
+	}
+	if first.Pandal.Name != "Bagbazar Sarbojanin" || first.Pandal.District != "KOL" {
+		t.Fatalf("feature 1: got name %q, district %q", first.Pandal.Name, first.Pandal.District)
+	}
+	if !slices.Equal(first.Pandal.Tags, []string{"heritage", "river"}) || !slices.Equal(first.Pandal.Images, []string{"a.jpg", "b.jpg"}) {
+		t.Fatalf("feature 1: got tags %q and images %q", first.Pandal.Tags, first.Pandal.Images)
+	}
+	if !slices.Equal(first.Pandal.Location.Coordinates, []float64{88.3697, 22.6010}) {
+		t.Fatalf("feature 1: got coordinates %v", first.Pandal.Location.Coordinates)
+	}
+
+	for _, record := range records[1:] {
+		if len(record.Errors) == 0 {
+			t.Errorf("feature %d (%q) has no errors", record.Row, record.Pandal.Name)
+		}
+	}
+	if len(records[1].Pandal.Location.Coordinates) != 0 || len(records[2].Pandal.Location.Coordinates) != 0 || len(records[4].Pandal.Location.Coordinates) != 0 {
+		t.Error("a feature without a Point geometry was given a pin")
+	}
+}
+
+func TestParseGeoJSONFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"not json":             "name,lng,lat",
+		"a single feature":     `{"type": "Feature", "geometry": {"type": "Point", "coordinates": [88.36, 22.57]}}`,
+		"features not a list":  `{"type": "FeatureCollection", "features": {}}`,
+		"truncated collection": `{"type": "FeatureCollection", "features": [`,
+	}
+	for name, file := range tests {
+		if _, err := importer.ParseGeoJSON(strings.NewReader(file)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		filename, contentType string
+		want                  importer.Format
+		err                   error
+	}{
+		{"pandals.CSV", "", importer.FormatCSV, nil},
+		{"pandals.geojson", "text/csv", importer.FormatGeoJSON, nil},
+		{"pandals.json", "", importer.FormatGeoJSON, nil},
+		{"", "text/csv; charset=utf-8", importer.FormatCSV, nil},
+		{"upload", "application/geo+json", importer.FormatGeoJSON, nil},
+		{"pandals.xlsx", "application/octet-stream", "", importer.ErrUnsupportedFormat},
+	}
+	for _, tt := range tests {
+		got, err := importer.DetectFormat(tt.filename, tt.contentType)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("DetectFormat(%q, %q) = %q, %v; want %q, %v", tt.filename, tt.contentType, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// State is what a Store remembers about one key, such as an account or a client IP
+type State struct {
+	Failures    int
+	LockedUntil time.Time
+}
+
+// Store keeps failed attempt counts and lockouts. Entries are forgotten once they
+// expire, which resets the count.
+type Store interface {
+	// Get returns the key's state, the zero State when it is unknown or expired
+	Get(ctx context.Context, key string) (State, error)
+	// Attempt counts an attempt at now unless the key is locked, in one atomic step.
+	// A counted attempt keeps the entry for policy.Window and applies the lockout
+	// policy gives the new count. It returns the state after the attempt, or the
+	// unchanged state and false when the key was locked.
+	Attempt(ctx context.Context, key string, now time.Time, policy Policy) (State, bool, error)
+	// Refund takes back a counted attempt, lifting the lockout it applied when
+	// lockedUntil is that lockout's end and nothing has replaced it since
+	Refund(ctx context.Context, key string, lockedUntil time.Time) error
+	// Reset forgets the key
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy describes when a key gets locked and for how long
+type Policy struct {
+	// Threshold is the number of failures that triggers the first lockout
+	Threshold int
+	// BaseDelay is the first lockout; every further failure doubles it up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Window is how long after the last failure the count is kept
+	Window time.Duration
+}
+
+// delay returns the lockout earned by the given number of failures, zero below the threshold
+func (p Policy) delay(failures int) time.Duration {
+	if failures < p.Threshold {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.Threshold; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// Guard applies a Policy to the keys of a Store
+type Guard struct {
+	store  Store
+	policy Policy
+}
+
+// NewGuard creates a guard enforcing policy on store
+func NewGuard(store Store, policy Policy) *Guard {
+	return &Guard{store: store, policy: policy}
+}
+
+// Check returns how long the key is still locked for, zero when attempts are allowed
+func (g *Guard) Check(ctx context.Context, key string) (time.Duration, error) {
+	state, err := g.store.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return max(time.Until(state.LockedUntil), 0), nil
+}
+
+// Reservation is an attempt counted before it is made
+type Reservation struct {
+	// Wait is how long the key is still locked when the attempt was refused
+	Wait time.Duration
+	// Lockout is the lockout this attempt applied, zero when it earned none
+	Lockout     time.Duration
+	lockedUntil time.Time
+}
+
+// Attempt counts an attempt as failed before it is made, so concurrent attempts cannot
+// all get past the lockout the first failures trigger. A refused attempt has a Wait;
+// any other stays counted, along with the lockout it earned, until Refund.
+func (g *Guard) Attempt(ctx context.Context, key string) (Reservation, error) {
+	// Stores may keep times to the millisecond only, and Refund compares them
+	now := time.Now().Truncate(time.Millisecond)
+	state, counted, err := g.store.Attempt(ctx, key, now, g.policy)
+	if err != nil {
+		return Reservation{}, err
+	}
+	if !counted {
+		return Reservation{Wait: max(state.LockedUntil.Sub(now), time.Millisecond)}, nil
+	}
+
+	delay := g.policy.delay(state.Failures)
+	if delay == 0 {
+		return Reservation{}, nil
+	}
+	return Reservation{Lockout: delay, lockedUntil: now.Add(delay)}, nil
+}
+
+// Refund takes back an attempt that succeeded, along with the lockout it applied
+func (g *Guard) Refund(ctx context.Context, key string, r Reservation) error {
+	if r.Wait > 0 {
+		return nil
+	}
+	return g.store.Refund(ctx, key, r.lockedUntil)
+}
+
+// Reset clears the key's failures and any lockout
+func (g *Guard) Reset(ctx context.Context, key string) error {
+	return g.store.Reset(ctx, key)
+}
@@ -0,0 +1,107 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many writes the memory store takes between purges of expired entries
+const sweepEvery = 1024
+
+// MemoryStore is a Store for a single instance, such as local development and tests.
+// Its state is lost on restart and not shared between replicas.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	writes  int
+}
+
+type memoryEntry struct {
+	state     State
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry := s.live(key, time.Now()); entry != nil {
+		return entry.state, nil
+	}
+	return State{}, nil
+}
+
+func (s *MemoryStore) Attempt(ctx context.Context, key string, now time.Time, policy Policy) (State, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep()
+
+	entry := s.live(key, now)
+	if entry != nil && now.Before(entry.state.LockedUntil) {
+		return entry.state, false, nil
+	}
+	if entry == nil {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	entry.state.Failures++
+	entry.expiresAt = now.Add(policy.Window)
+	if delay := policy.delay(entry.state.Failures); delay > 0 {
+		entry.state.LockedUntil = now.Add(delay)
+	}
+	if entry.state.LockedUntil.After(entry.expiresAt) {
+		entry.expiresAt = entry.state.LockedUntil
+	}
+	return entry.state, true, nil
+}
+
+func (s *MemoryStore) Refund(ctx context.Context, key string, lockedUntil time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.live(key, time.Now())
+	if entry == nil {
+		return nil
+	}
+	entry.state.Failures = max(entry.state.Failures-1, 0)
+	if !lockedUntil.IsZero() && entry.state.LockedUntil.Equal(lockedUntil) {
+		entry.state.LockedUntil = time.Time{}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// live returns the key's entry unless it has expired; callers must hold mu
+func (s *MemoryStore) live(key string, now time.Time) *memoryEntry {
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil
+	}
+	return entry
+}
+
+// sweep drops expired entries every sweepEvery writes; callers must hold mu
+func (s *MemoryStore) sweep() {
+	s.writes++
+	if s.writes < sweepEvery {
+		return
+	}
+	s.writes = 0
+	now := time.Now()
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
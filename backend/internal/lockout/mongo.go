@@ -0,0 +1,110 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore is a Store shared by every API instance. Expired entries are purged by
+// a TTL index on expiresAt (see migrations), but are ignored before that runs too.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+type mongoEntry struct {
+	Key         string    `bson:"_id"`
+	Failures    int       `bson:"failures"`
+	LockedUntil time.Time `bson:"lockedUntil,omitempty"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
+}
+
+// NewMongoStore creates a store on collection
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+func (s *MongoStore) Get(ctx context.Context, key string) (State, error) {
+	var entry mongoEntry
+	err := s.collection.FindOne(ctx, bson.M{"_id": key, "expiresAt": bson.M{"$gt": time.Now()}}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+	return State{Failures: entry.Failures, LockedUntil: entry.LockedUntil}, nil
+}
+
+// Attempt counts the attempt and applies its lockout in a single pipeline update,
+// restarting the count when the stored entry has expired but not been purged yet.
+// The entry from before the update tells whether the key was locked.
+func (s *MongoStore) Attempt(ctx context.Context, key string, now time.Time, policy Policy) (State, bool, error) {
+	threshold := policy.Threshold
+	lockout := bson.M{"$add": bson.A{now, bson.M{"$min": bson.A{
+		policy.MaxDelay.Milliseconds(),
+		bson.M{"$multiply": bson.A{
+			policy.BaseDelay.Milliseconds(),
+			bson.M{"$pow": bson.A{2, bson.M{"$subtract": bson.A{"$failures", threshold}}}},
+		}},
+	}}}}
+	update := bson.A{
+		bson.M{"$set": bson.M{"live": bson.M{"$gt": bson.A{"$expiresAt", now}}}},
+		bson.M{"$set": bson.M{"locked": bson.M{"$and": bson.A{"$live", bson.M{"$gt": bson.A{"$lockedUntil", now}}}}}},
+		bson.M{"$set": bson.M{
+			"failures": bson.M{"$cond": bson.A{"$locked", "$failures",
+				bson.M{"$cond": bson.A{"$live", bson.M{"$add": bson.A{"$failures", 1}}, 1}}}},
+		}},
+		bson.M{"$set": bson.M{
+			"lockedUntil": bson.M{"$cond": bson.A{"$locked", "$lockedUntil",
+				bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$failures", threshold}}, lockout,
+					bson.M{"$cond": bson.A{"$live", "$lockedUntil", "$$REMOVE"}}}}}},
+			"expiresAt": bson.M{"$cond": bson.A{"$locked", "$expiresAt", now.Add(policy.Window)}},
+		}},
+		bson.M{"$set": bson.M{"expiresAt": bson.M{"$max": bson.A{"$expiresAt", "$lockedUntil"}}}},
+		bson.M{"$unset": bson.A{"live", "locked"}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+	var before mongoEntry
+	err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&before)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// The entry was just created
+		before = mongoEntry{}
+	} else if err != nil {
+		return State{}, false, err
+	}
+
+	// Work out the new state the same way the pipeline did
+	live := before.ExpiresAt.After(now)
+	if live && before.LockedUntil.After(now) {
+		return State{Failures: before.Failures, LockedUntil: before.LockedUntil}, false, nil
+	}
+	state := State{Failures: 1}
+	if live {
+		state = State{Failures: before.Failures + 1, LockedUntil: before.LockedUntil}
+	}
+	if delay := policy.delay(state.Failures); delay > 0 {
+		state.LockedUntil = now.Add(delay)
+	}
+	return state, true, nil
+}
+
+func (s *MongoStore) Refund(ctx context.Context, key string, lockedUntil time.Time) error {
+	set := bson.M{"failures": bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{"$failures", 1}}}}}
+	if !lockedUntil.IsZero() {
+		set["lockedUntil"] = bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$lockedUntil", lockedUntil}}, "$$REMOVE", "$lockedUntil"}}
+	}
+	filter := bson.M{"_id": key, "expiresAt": bson.M{"$gt": time.Now()}}
+	_, err := s.collection.UpdateOne(ctx, filter, bson.A{bson.M{"$set": set}})
+	return err
+}
+
+func (s *MongoStore) Reset(ctx context.Context, key string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
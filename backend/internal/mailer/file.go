@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileMailer is a stand-in for local development and tests. Each message is written
+// to its own file in dir, or to the log when dir is empty, and the last one sent is
+// kept for inspection.
+type FileMailer struct {
+	dir string
+
+	mu   sync.Mutex
+	last *Message
+}
+
+// NewFileMailer creates a mailer writing into dir, creating it on first use
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{dir: dir}
+}
+
+// Send records msg instead of delivering it
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	m.last = &msg
+	m.mu.Unlock()
+
+	text := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+	if m.dir == "" {
+		log.Printf("Mail not sent (no SMTP relay configured):\n%s", text)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	// Recipients become part of the file name, so keep it to safe characters
+	recipient := strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, msg.To)
+	name := fmt.Sprintf("%d-%s.txt", time.Now().UnixNano(), recipient)
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(text), 0o644)
+}
+
+// Last returns the most recently sent message, if any
+func (m *FileMailer) Last() (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.last == nil {
+		return Message{}, false
+	}
+	return *m.last, true
+}
@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+)
+
+// Message is a plain text email to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional email such as password reset and verification links
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv returns an SMTPMailer when SMTP_HOST is set. With APP_ENV=development mail is
+// otherwise written to MAIL_DIR, or to the log when that is unset too, so local setups
+// work without a relay. Anywhere else a missing relay is an error, since the fallback
+// would leave live reset and verification links lying around in files or logs.
+func FromEnv() (Mailer, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		if os.Getenv("APP_ENV") != "development" {
+			return nil, errors.New("no SMTP relay configured: set SMTP_HOST, or APP_ENV=development")
+		}
+		log.Println("SMTP_HOST not set, outgoing mail will not be delivered")
+		return NewFileMailer(os.Getenv("MAIL_DIR")), nil
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@pandal-hopping.app"
+	}
+	return NewSMTPMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from), nil
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/validation"
+)
+
+// locationDocument is the part of a pandal or food stop the location report needs
+type locationDocument struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	Name     string             `bson:"name"`
+	Country  string             `bson:"country"`
+	State    string             `bson:"state"`
+	Location *models.Location   `bson:"location"`
+}
+
+// ReportInvalidLocations logs every pandal and food stop whose stored location fails
+// validation.ValidateGeoPoint, such as swapped axes or a non-Point type.
+// Documents are only reported, never changed, since the right fix needs a human.
+func ReportInvalidLocations(ctx context.Context, pandalCollection *mongo.Collection, foodStopCollection *mongo.Collection) {
+	pandals := reportInvalidLocations(ctx, "pandal", pandalCollection)
+	foodStops := reportInvalidLocations(ctx, "food stop", foodStopCollection)
+	log.Printf("Location check: %d invalid pandal(s), %d invalid food stop(s)", pandals, foodStops)
+}
+
+// reportInvalidLocations logs the invalid locations in one collection and returns how many were found
+func reportInvalidLocations(ctx context.Context, kind string, collection *mongo.Collection) int {
+	projection := bson.M{"name": 1, "country": 1, "state": 1, "location": 1}
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		log.Printf("Location check: could not read %s documents: %v", kind, err)
+		return 0
+	}
+	defer cursor.Close(ctx)
+
+	invalid := 0
+	for cursor.Next(ctx) {
+		var doc locationDocument
+		if err := cursor.Decode(&doc); err != nil {
+			invalid++
+			log.Printf("Location check: %s %v could not be decoded: %v", kind, cursor.Current.Lookup("_id"), err)
+			continue
+		}
+		if err := validation.ValidateGeoPoint(doc.Location, doc.Country, doc.State); err != nil {
+			invalid++
+			log.Printf("Location check: %s %s (%q): %v", kind, doc.ID.Hex(), doc.Name, err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Location check: %s scan stopped early: %v", kind, err)
+	}
+	return invalid
+}
@@ -8,10 +8,12 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/repository"
 )
 
 // RunMigrations executes all necessary index creations
-func RunMigrations(pandalCollection *mongo.Collection, foodStopCollection *mongo.Collection) {
+func RunMigrations(pandalCollection *mongo.Collection, foodStopCollection *mongo.Collection, refreshTokenCollection *mongo.Collection, reviewCollection *mongo.Collection, routeCollection *mongo.Collection, emailTokenCollection *mongo.Collection, loginAttemptCollection *mongo.Collection, userCollection *mongo.Collection, oidcSessionCollection *mongo.Collection) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -57,5 +59,134 @@ func RunMigrations(pandalCollection *mongo.Collection, foodStopCollection *mongo
 	}
 	log.Printf("Food stop indexes created: %v", foodIndexNames)
 
+	// Refresh token collection indexes; expired tokens are purged by the TTL index
+	refreshTokenIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.M{"familyId": 1},
+			Options: options.Index().SetName("refresh_family_index"),
+		},
+		{
+			Keys:    bson.M{"userId": 1},
+			Options: options.Index().SetName("refresh_user_index"),
+		},
+		{
+			Keys:    bson.M{"expiresAt": 1},
+			Options: options.Index().SetName("refresh_expiry_ttl_index").SetExpireAfterSeconds(0),
+		},
+	}
+
+	refreshIndexNames, err := refreshTokenCollection.Indexes().CreateMany(ctx, refreshTokenIndexes)
+	if err != nil {
+		log.Fatalf("Failed to create refresh token indexes: %v", err)
+	}
+	log.Printf("Refresh token indexes created: %v", refreshIndexNames)
+
+	// Review collection indexes; the unique index enforces one review per user per pandal
+	reviewIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "pandalId", Value: 1}, {Key: "userId", Value: 1}},
+			Options: options.Index().SetName("review_pandal_user_unique_index").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "pandalId", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("review_pandal_created_index"),
+		},
+	}
+
+	reviewIndexNames, err := reviewCollection.Indexes().CreateMany(ctx, reviewIndexes)
+	if err != nil {
+		log.Fatalf("Failed to create review indexes: %v", err)
+	}
+	log.Printf("Review indexes created: %v", reviewIndexNames)
+
+	// Route collection indexes for owner listings and share links
+	routeIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.M{"createdBy": 1},
+			Options: options.Index().SetName("route_created_by_index"),
+		},
+		{
+			Keys:    bson.M{"shareToken": 1},
+			Options: options.Index().SetName("route_share_token_index").SetUnique(true).SetSparse(true),
+		},
+	}
+
+	routeIndexNames, err := routeCollection.Indexes().CreateMany(ctx, routeIndexes)
+	if err != nil {
+		log.Fatalf("Failed to create route indexes: %v", err)
+	}
+	log.Printf("Route indexes created: %v", routeIndexNames)
+
+	// Email token collection indexes; expired links are purged by the TTL index
+	emailTokenIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "purpose", Value: 1}},
+			Options: options.Index().SetName("email_token_user_purpose_index"),
+		},
+		{
+			Keys:    bson.M{"expiresAt": 1},
+			Options: options.Index().SetName("email_token_expiry_ttl_index").SetExpireAfterSeconds(0),
+		},
+	}
+
+	emailTokenIndexNames, err := emailTokenCollection.Indexes().CreateMany(ctx, emailTokenIndexes)
+	if err != nil {
+		log.Fatalf("Failed to create email token indexes: %v", err)
+	}
+	log.Printf("Email token indexes created: %v", emailTokenIndexNames)
+
+	// Login attempt entries are purged by the TTL index once their window has passed
+	loginAttemptIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.M{"expiresAt": 1},
+			Options: options.Index().SetName("login_attempt_expiry_ttl_index").SetExpireAfterSeconds(0),
+		},
+	}
+
+	loginAttemptIndexNames, err := loginAttemptCollection.Indexes().CreateMany(ctx, loginAttemptIndexes)
+	if err != nil {
+		log.Fatalf("Failed to create login attempt indexes: %v", err)
+	}
+	log.Printf("Login attempt indexes created: %v", loginAttemptIndexNames)
+
+	// User collection indexes; a provider account can be linked to only one user, and an
+	// email address, whatever its case, belongs to only one user
+	userIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+			Options: options.Index().SetName("user_identity_unique_index").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"identities.subject": bson.M{"$exists": true}}),
+		},
+		{
+			Keys:    bson.M{"email": 1},
+			Options: options.Index().SetName("user_email_unique_index").SetUnique(true).SetCollation(repository.EmailCollation),
+		},
+	}
+
+	userIndexNames, err := userCollection.Indexes().CreateMany(ctx, userIndexes)
+	if err != nil {
+		log.Fatalf("Failed to create user indexes (accounts whose emails differ only in case must be merged first): %v", err)
+	}
+	log.Printf("User indexes created: %v", userIndexNames)
+
+	// Pending OpenID Connect logins are purged by the TTL index once abandoned
+	oidcSessionIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.M{"expiresAt": 1},
+			Options: options.Index().SetName("oidc_session_expiry_ttl_index").SetExpireAfterSeconds(0),
+		},
+	}
+
+	oidcSessionIndexNames, err := oidcSessionCollection.Indexes().CreateMany(ctx, oidcSessionIndexes)
+	if err != nil {
+		log.Fatalf("Failed to create OIDC session indexes: %v", err)
+	}
+	log.Printf("OIDC session indexes created: %v", oidcSessionIndexNames)
+
+	// Scanning every document can outlast the index timeout, so it gets its own
+	reportCtx, cancelReport := context.WithTimeout(context.Background(), time.Minute)
+	defer cancelReport()
+	ReportInvalidLocations(reportCtx, pandalCollection, foodStopCollection)
+
 	log.Println("Migration complete.")
 }
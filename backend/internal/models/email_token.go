@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// TokenPurpose tells apart the single-use tokens sent out by email
+type TokenPurpose string
+
+const (
+	PurposePasswordReset TokenPurpose = "password_reset"
+	PurposeVerifyEmail   TokenPurpose = "verify_email"
+)
+
+// EmailToken is the server-side record of a signed link sent to a user.
+// Consuming it marks it used so the link works only once.
+type EmailToken struct {
+	ID        string       `json:"id"               bson:"_id"` // the token's jti
+	UserID    string       `json:"userId"           bson:"userId"`
+	Purpose   TokenPurpose `json:"purpose"          bson:"purpose"`
+	IssuedAt  time.Time    `json:"issuedAt"         bson:"issuedAt"`
+	ExpiresAt time.Time    `json:"expiresAt"        bson:"expiresAt"`
+	UsedAt    *time.Time   `json:"usedAt,omitempty" bson:"usedAt,omitempty"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
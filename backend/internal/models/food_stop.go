@@ -13,6 +13,11 @@ type FoodStop struct {
 	Location Location           `json:"location"     bson:"location"      binding:"required"`
 	Area     string             `json:"area"         bson:"area"`
 	District string             `json:"district"     bson:"district"`
+
+	// Set on proximity searches only, relative to the searched point
+	DistanceMeters *float64 `json:"distanceMeters,omitempty" bson:"distanceMeters,omitempty"`
+	WalkingMinutes *int     `json:"walkingMinutes,omitempty" bson:"-"`
+	BearingDegrees *float64 `json:"bearingDegrees,omitempty" bson:"-"`
 }
 
 // District is a lightweight view derived from aggregating pandal areas
@@ -0,0 +1,33 @@
+package models
+
+// ImportAction is what a bulk import did, or would do on a dry run, with one row
+type ImportAction string
+
+const (
+	ImportCreate ImportAction = "create"
+	ImportUpdate ImportAction = "update"
+	ImportSkip   ImportAction = "skip"
+	ImportError  ImportAction = "error"
+)
+
+// ImportRowResult reports the outcome of one row of an import file
+type ImportRowResult struct {
+	Row         int          `json:"row"`
+	Name        string       `json:"name"`
+	Action      ImportAction `json:"action"`
+	PandalID    string       `json:"pandalId,omitempty"`
+	DuplicateOf string       `json:"duplicateOf,omitempty"`
+	Errors      []string     `json:"errors,omitempty"`
+}
+
+// ImportReport summarises a bulk import. On a dry run nothing is written
+// and the counts describe what a real run would do.
+type ImportReport struct {
+	DryRun  bool              `json:"dryRun"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
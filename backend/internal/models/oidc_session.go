@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// OIDCSession holds what the server needs to finish an OpenID Connect login between
+// sending the user to the provider and the provider redirecting back. It is keyed by
+// the state parameter and consumed by the callback, so each login completes once.
+type OIDCSession struct {
+	ID        string    `bson:"_id"` // the state sent to the provider
+	Provider  string    `bson:"provider"`
+	Verifier  string    `bson:"verifier"` // PKCE code verifier, never leaves the server
+	Nonce     string    `bson:"nonce"`
+	Device    string    `bson:"device,omitempty"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
@@ -23,22 +23,98 @@ const (
 
 // Pandal structure
 type Pandal struct {
-	ID            primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Name          string             `json:"name" bson:"name" binding:"required"`
-	Description   string             `json:"description" bson:"description"`
-	Area          string             `json:"area" bson:"area" binding:"required"`
-	District      string             `json:"district" bson:"district" binding:"required"`
-	State         string             `json:"state" bson:"state" binding:"required"`
-	Country       string             `json:"country" bson:"country" binding:"required"`
-	Theme         string             `json:"theme" bson:"theme"`
-	Tags          []string           `json:"tags" bson:"tags"` // e.g. ["award-winning", "banedi-bari"]
-	Location      Location           `json:"location" bson:"location"`
-	Images        []string           `json:"images" bson:"images"`
-	RatingAvg     float64            `json:"ratingAvg" bson:"ratingAvg"`
-	RatingCount   int                `json:"ratingCount" bson:"ratingCount"`
-	Status        PandalStatus       `json:"status" bson:"status"`
-	ApprovalCount int                `json:"approvalCount" bson:"approvalCount"`
-	ApprovedBy    []string           `json:"approvedBy" bson:"approvedBy"`
-	CreatedBy     string             `json:"createdBy" bson:"createdBy"`
-	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	ID             primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	Name           string              `json:"name" bson:"name" binding:"required"`
+	Description    string              `json:"description" bson:"description"`
+	Area           string              `json:"area" bson:"area" binding:"required"`
+	District       string              `json:"district" bson:"district" binding:"required"`
+	State          string              `json:"state" bson:"state" binding:"required"`
+	Country        string              `json:"country" bson:"country" binding:"required"`
+	Theme          string              `json:"theme" bson:"theme"`
+	Tags           []string            `json:"tags" bson:"tags"` // e.g. ["award-winning", "banedi-bari"]
+	Location       Location            `json:"location" bson:"location"`
+	Images         []string            `json:"images" bson:"images"`
+	RatingAvg      float64             `json:"ratingAvg" bson:"ratingAvg"`
+	RatingCount    int                 `json:"ratingCount" bson:"ratingCount"`
+	Status         PandalStatus        `json:"status" bson:"status"`
+	ApprovalCount  int                 `json:"approvalCount" bson:"approvalCount"`
+	ApprovedBy     []string            `json:"approvedBy" bson:"approvedBy"`
+	RejectionCount int                 `json:"rejectionCount" bson:"rejectionCount"`
+	RejectedBy     []string            `json:"rejectedBy" bson:"rejectedBy"`
+	Rejections     []Rejection         `json:"rejections" bson:"rejections"`
+	CreatedBy      string              `json:"createdBy" bson:"createdBy"`
+	CreatedAt      time.Time           `json:"createdAt" bson:"createdAt"`
+	UpdatedAt      time.Time           `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt      *time.Time          `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	MergedInto     *primitive.ObjectID `json:"mergedInto,omitempty" bson:"mergedInto,omitempty"` // set when an admin folded this duplicate into another pandal
+
+	// Set on proximity searches only, relative to the searched point
+	DistanceMeters *float64 `json:"distanceMeters,omitempty" bson:"distanceMeters,omitempty"`
+	WalkingMinutes *int     `json:"walkingMinutes,omitempty" bson:"-"`
+	BearingDegrees *float64 `json:"bearingDegrees,omitempty" bson:"-"`
+}
+
+// Rejection records a reviewer's vote against a pandal and the reason given
+type Rejection struct {
+	UserID    string    `json:"userId" bson:"userId"`
+	Reason    string    `json:"reason" bson:"reason"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// RejectPandalRequest is the body of a rejection vote
+type RejectPandalRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// UpdatePandalRequest carries the editable fields of a pandal.
+// Nil fields are left untouched, which lets the same struct serve PUT and PATCH.
+type UpdatePandalRequest struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Area        *string   `json:"area"`
+	District    *string   `json:"district"`
+	State       *string   `json:"state"`
+	Country     *string   `json:"country"`
+	Theme       *string   `json:"theme"`
+	Tags        *[]string `json:"tags"`
+	Location    *Location `json:"location"`
+	Images      *[]string `json:"images"`
+}
+
+// DuplicateCandidate is an existing pandal that a new submission probably duplicates.
+// CanVote tells the submitter they may approve the existing pending pandal instead.
+type DuplicateCandidate struct {
+	Pandal         Pandal  `json:"pandal"`
+	DistanceMeters float64 `json:"distanceMeters"`
+	NameScore      float64 `json:"nameScore"`
+	CanVote        bool    `json:"canVote"`
+}
+
+// MergePandalRequest names the pandal a duplicate is folded into
+type MergePandalRequest struct {
+	TargetID primitive.ObjectID `json:"targetId" binding:"required"`
+}
+
+// MergeResult reports what a merge moved onto the surviving pandal
+type MergeResult struct {
+	Pandal         *Pandal `json:"pandal"`
+	ReviewsMoved   int64   `json:"reviewsMoved"`
+	ReviewsDropped int64   `json:"reviewsDropped"` // the reviewer had already reviewed the target
+	RoutesUpdated  int64   `json:"routesUpdated"`
+}
+
+// PandalCluster groups the approved pandals that share a map grid cell
+type PandalCluster struct {
+	Count    int           `json:"count" bson:"count"`
+	Centroid []float64     `json:"centroid" bson:"centroid"` // [lng, lat] mean of the members
+	Bounds   []float64     `json:"bounds" bson:"bounds"`     // [minLng, minLat, maxLng, maxLat] of the members
+	Top      ClusterMember `json:"top" bson:"top"`
+}
+
+// ClusterMember is the pandal that represents a cluster, its best rated member
+type ClusterMember struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Name        string             `json:"name" bson:"name"`
+	RatingAvg   float64            `json:"ratingAvg" bson:"ratingAvg"`
+	RatingCount int                `json:"ratingCount" bson:"ratingCount"`
 }
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken is the server-side record of an issued refresh token.
+// Every token minted from the same login shares a FamilyID so a replayed
+// token can revoke the whole chain.
+type RefreshToken struct {
+	ID        string     `json:"id"                  bson:"_id"` // the token's jti
+	FamilyID  string     `json:"familyId"            bson:"familyId"`
+	UserID    string     `json:"userId"              bson:"userId"`
+	Device    string     `json:"device"              bson:"device"`
+	IssuedAt  time.Time  `json:"issuedAt"            bson:"issuedAt"`
+	ExpiresAt time.Time  `json:"expiresAt"           bson:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"    bson:"usedAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+}
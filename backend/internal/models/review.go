@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Review is a user's star rating and write-up of a pandal; one per user per pandal
+type Review struct {
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	PandalID  primitive.ObjectID `json:"pandalId"     bson:"pandalId"`
+	UserID    string             `json:"userId"       bson:"userId"`
+	Rating    int                `json:"rating"       bson:"rating"`
+	Text      string             `json:"text"         bson:"text"`
+	Photos    []string           `json:"photos"       bson:"photos"`
+	CreatedAt time.Time          `json:"createdAt"    bson:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt"    bson:"updatedAt"`
+}
+
+// ReviewRequest is the body used to create or edit a review
+type ReviewRequest struct {
+	Rating int      `json:"rating" binding:"required,min=1,max=5"`
+	Text   string   `json:"text"   binding:"max=2000"`
+	Photos []string `json:"photos" binding:"max=5,dive,url"`
+}
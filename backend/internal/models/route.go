@@ -6,7 +6,26 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// Route represents a curated pandal-hopping itinerary
+// RouteVisibility controls who can see a route
+type RouteVisibility string
+
+const (
+	VisibilityPrivate  RouteVisibility = "private"  // owner only
+	VisibilityUnlisted RouteVisibility = "unlisted" // owner and anyone holding the share token
+	VisibilityPublic   RouteVisibility = "public"   // everyone, listed on GET /routes/
+)
+
+// IsValid reports whether v is one of the known visibilities
+func (v RouteVisibility) IsValid() bool {
+	switch v {
+	case VisibilityPrivate, VisibilityUnlisted, VisibilityPublic:
+		return true
+	}
+	return false
+}
+
+// Route represents a curated or user-created pandal-hopping itinerary.
+// Routes stored before ownership existed have no visibility and are treated as public curated routes.
 type Route struct {
 	ID          primitive.ObjectID   `json:"id,omitempty"          bson:"_id,omitempty"`
 	Title       string               `json:"title"                 bson:"title"         binding:"required"`
@@ -14,16 +33,107 @@ type Route struct {
 	Duration    string               `json:"duration"              bson:"duration"`
 	Stops       []primitive.ObjectID `json:"stops"                 bson:"stops"`
 	StopCount   int                  `json:"stopCount"             bson:"stopCount"`
+	CreatedBy   string               `json:"createdBy,omitempty"   bson:"createdBy,omitempty"`
+	Curated     bool                 `json:"curated"               bson:"curated"`
+	Visibility  RouteVisibility      `json:"visibility"            bson:"visibility"`
+	ShareToken  string               `json:"shareToken,omitempty"  bson:"shareToken,omitempty"`
+	ClonedFrom  *primitive.ObjectID  `json:"clonedFrom,omitempty"  bson:"clonedFrom,omitempty"`
 	CreatedAt   time.Time            `json:"createdAt"             bson:"createdAt"`
+	UpdatedAt   time.Time            `json:"updatedAt"             bson:"updatedAt"`
+}
+
+// EffectiveVisibility treats routes stored without a visibility as public
+func (r *Route) EffectiveVisibility() RouteVisibility {
+	if r.Visibility.IsValid() {
+		return r.Visibility
+	}
+	return VisibilityPublic
+}
+
+// PersonalRouteRequest is the body used to create a user's own itinerary
+type PersonalRouteRequest struct {
+	Title       string               `json:"title"       binding:"required"`
+	Description string               `json:"description"`
+	Stops       []primitive.ObjectID `json:"stops"`
+	Visibility  RouteVisibility      `json:"visibility"` // defaults to private
+}
+
+// UpdateRouteRequest edits a route's details; nil fields are left untouched
+type UpdateRouteRequest struct {
+	Title       *string          `json:"title"`
+	Description *string          `json:"description"`
+	Visibility  *RouteVisibility `json:"visibility"`
+}
+
+// AddStopRequest inserts a pandal into a route
+type AddStopRequest struct {
+	PandalID primitive.ObjectID `json:"pandalId" binding:"required"`
+	Position *int               `json:"position" binding:"omitempty,min=0"` // 0-based index, appends when omitted
+}
+
+// ReorderStopsRequest gives the complete new stop order of a route
+type ReorderStopsRequest struct {
+	Stops []primitive.ObjectID `json:"stops" binding:"required"`
 }
 
 // RouteWithStops is the enriched response that embeds full Pandal objects for each stop
 type RouteWithStops struct {
-	ID          primitive.ObjectID `json:"id,omitempty"`
-	Title       string             `json:"title"`
-	Description string             `json:"description"`
-	Duration    string             `json:"duration"`
-	StopCount   int                `json:"stopCount"`
-	Stops       []Pandal           `json:"stops"`
-	CreatedAt   time.Time          `json:"createdAt"`
+	ID                  primitive.ObjectID  `json:"id,omitempty"`
+	Title               string              `json:"title"`
+	Description         string              `json:"description"`
+	Duration            string              `json:"duration"`
+	StopCount           int                 `json:"stopCount"`
+	Stops               []Pandal            `json:"stops"`
+	Legs                []RouteLeg          `json:"legs"`
+	TotalDistanceMeters float64             `json:"totalDistanceMeters"`
+	Warnings            []RouteWarning      `json:"warnings"`
+	CreatedBy           string              `json:"createdBy,omitempty"`
+	Curated             bool                `json:"curated"`
+	Visibility          RouteVisibility     `json:"visibility"`
+	ShareToken          string              `json:"shareToken,omitempty"`
+	ClonedFrom          *primitive.ObjectID `json:"clonedFrom,omitempty"`
+	CreatedAt           time.Time           `json:"createdAt"`
+}
+
+// RouteLeg is the walk between two consecutive stops of a route
+type RouteLeg struct {
+	From           primitive.ObjectID `json:"from"`
+	To             primitive.ObjectID `json:"to"`
+	DistanceMeters float64            `json:"distanceMeters"`
+	WalkingMinutes float64            `json:"walkingMinutes"`
+}
+
+// RouteWarning flags a stop that could not be shown on the route
+type RouteWarning struct {
+	PandalID primitive.ObjectID `json:"pandalId"`
+	Reason   string             `json:"reason"` // "missing", "deleted", "rejected" or "pending"
+}
+
+// PlanRouteRequest asks the optimizer for a walking tour.
+// Stops come from PandalIDs when given, otherwise from the District/Tag filter.
+type PlanRouteRequest struct {
+	Start             Location             `json:"start"             binding:"required"`
+	PandalIDs         []primitive.ObjectID `json:"pandalIds"`
+	District          string               `json:"district"`
+	Tag               string               `json:"tag"`
+	TimeBudgetMinutes int                  `json:"timeBudgetMinutes" binding:"required,min=1"`
+	VisitMinutes      *int                 `json:"visitMinutes"      binding:"omitempty,min=0"` // time spent at each pandal, defaults to 15
+}
+
+// PlannedStop is one pandal of a planned tour together with the leg that reaches it
+type PlannedStop struct {
+	Order             int     `json:"order"`
+	Pandal            Pandal  `json:"pandal"`
+	LegDistanceMeters float64 `json:"legDistanceMeters"`
+	LegWalkingMinutes float64 `json:"legWalkingMinutes"`
+	ArrivalMinutes    float64 `json:"arrivalMinutes"` // minutes after leaving the start
+}
+
+// RoutePlan is the optimizer's ordered itinerary
+type RoutePlan struct {
+	Stops               []PlannedStop        `json:"stops"`
+	TotalDistanceMeters float64              `json:"totalDistanceMeters"`
+	TotalWalkingMinutes float64              `json:"totalWalkingMinutes"`
+	TotalMinutes        float64              `json:"totalMinutes"`
+	Skipped             []primitive.ObjectID `json:"skipped"` // stops that did not fit the time budget
 }
@@ -1,18 +1,72 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Role controls which privileged endpoints a user may call
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// IsValid reports whether r is one of the known roles
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 type User struct {
-	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Name      string             `json:"name" bson:"name"`
-	Email     string             `json:"email" bson:"email"`
-	Password  string             `json:"-" bson:"password"`
-	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
-	UpdatedAt time.Time          `json:"updatedAt" bson:"updatedAt"`
+	ID            primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name          string             `json:"name" bson:"name"`
+	Email         string             `json:"email" bson:"email"`
+	Password      string             `json:"-" bson:"password"`
+	Role          Role               `json:"role" bson:"role"`
+	EmailVerified bool               `json:"emailVerified" bson:"emailVerified"`                 // only verified users may approve pandals
+	LockedUntil   *time.Time         `json:"lockedUntil,omitempty" bson:"lockedUntil,omitempty"` // set while logins are locked out after failed attempts
+	AvatarURL     string             `json:"avatarUrl,omitempty" bson:"avatarUrl,omitempty"`
+	Language      string             `json:"preferredLanguage,omitempty" bson:"preferredLanguage,omitempty"`
+	HomeDistrict  string             `json:"homeDistrict,omitempty" bson:"homeDistrict,omitempty"` // district code, as used by the ?district= filters
+	Identities    []LinkedIdentity   `json:"identities,omitempty" bson:"identities,omitempty"`     // OpenID Connect accounts the user signs in with
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// LinkedIdentity is an account at an OpenID Connect provider, identified by the
+// provider's stable subject rather than the email address, which may change
+type LinkedIdentity struct {
+	Provider string `json:"provider" bson:"provider"`
+	Subject  string `json:"-" bson:"subject"`
+}
+
+// Languages the app is translated into, as accepted for a user's preferredLanguage
+var SupportedLanguages = []string{"en", "bn", "hi"}
+
+// DeletedUserPrefix starts the placeholder that replaces a deleted user's ID on the
+// pandals, reviews and routes they contributed
+const DeletedUserPrefix = "deleted-"
+
+// NormalizeEmail is the form email addresses are stored and looked up in, so the
+// same mailbox typed with different case maps to one account
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// EffectiveRole returns the user's role, treating accounts created before roles existed as plain users
+func (u *User) EffectiveRole() Role {
+	if u.Role.IsValid() {
+		return u.Role
+	}
+	return RoleUser
 }
 
 type RegisterRequest struct {
@@ -24,6 +78,7 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	Device   string `json:"device"` // optional label shown when listing or revoking sessions
 }
 
 type AuthResponse struct {
@@ -35,3 +90,29 @@ type AuthResponse struct {
 type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
+
+type UpdateRoleRequest struct {
+	Role Role `json:"role" binding:"required"`
+}
+
+// UpdateProfileRequest carries the fields a user may change on their own account.
+// Nil fields are left untouched; an empty string clears an optional field.
+type UpdateProfileRequest struct {
+	Name         *string `json:"name" binding:"omitempty,min=1,max=100"`
+	AvatarURL    *string `json:"avatarUrl" binding:"omitempty,max=2048"`
+	Language     *string `json:"preferredLanguage"`
+	HomeDistrict *string `json:"homeDistrict"`
+}
+
+// ChangePasswordRequest sets a new password. Users who only sign in with a provider
+// have no current password and prove a recent sign-in instead.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword" binding:"required,min=6"`
+}
+
+// DeleteAccountRequest confirms an account deletion with the user's password, which
+// users without one leave empty
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
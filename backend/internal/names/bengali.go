@@ -0,0 +1,86 @@
+package names
+
+import "strings"
+
+const (
+	bengaliVirama = '্' // suppresses the inherent vowel of the preceding consonant
+	bengaliNukta  = '়' // turns ড, ঢ and য into ড়, ঢ় and য়
+)
+
+// bengaliConsonants maps consonants to their usual Kolkata romanisation, without the inherent vowel
+var bengaliConsonants = map[rune]string{
+	'ক': "k", 'খ': "kh", 'গ': "g", 'ঘ': "gh", 'ঙ': "ng",
+	'চ': "ch", 'ছ': "chh", 'জ': "j", 'ঝ': "jh", 'ঞ': "n",
+	'ট': "t", 'ঠ': "th", 'ড': "d", 'ঢ': "dh", 'ণ': "n",
+	'ত': "t", 'থ': "th", 'দ': "d", 'ধ': "dh", 'ন': "n",
+	'প': "p", 'ফ': "ph", 'ব': "b", 'ভ': "bh", 'ম': "m",
+	'য': "j", 'র': "r", 'ল': "l", 'শ': "sh", 'ষ': "sh",
+	'স': "s", 'হ': "h", '\u09DC': "r", '\u09DD': "rh", '\u09DF': "y", // ড় ঢ় য়
+}
+
+// bengaliNuktaForms maps a consonant followed by a nukta onto the precomposed letter
+var bengaliNuktaForms = map[rune]rune{'ড': '\u09DC', 'ঢ': '\u09DD', 'য': '\u09DF'}
+
+// bengaliVowels maps independent vowels and vowel signs to Latin letters
+var bengaliVowels = map[rune]string{
+	'অ': "o", 'আ': "a", 'ই': "i", 'ঈ': "i", 'উ': "u", 'ঊ': "u",
+	'ঋ': "ri", 'এ': "e", 'ঐ': "oi", 'ও': "o", 'ঔ': "ou",
+	'া': "a", 'ি': "i", 'ী': "i", 'ু': "u", 'ূ': "u",
+	'ৃ': "ri", 'ে': "e", 'ৈ': "oi", 'ো': "o", 'ৌ': "ou",
+}
+
+// bengaliSigns maps the remaining letters and marks that stand on their own
+var bengaliSigns = map[rune]string{
+	'ৎ': "t", 'ং': "ng", 'ঃ': "h", 'ঁ': "",
+	'০': "0", '১': "1", '২': "2", '৩': "3", '৪': "4",
+	'৫': "5", '৬': "6", '৭': "7", '৮': "8", '৯': "9",
+}
+
+// isBengali reports whether r is in the Bengali Unicode block
+func isBengali(r rune) bool {
+	return r >= 'ঀ' && r <= '৿'
+}
+
+// transliterateBengali romanises Bengali script in word, leaving other characters untouched.
+// Consonants carry the inherent "o" unless a vowel sign or virama follows or they end the word,
+// which is close enough to everyday spellings for the folding in Normalise to line them up.
+func transliterateBengali(word string) string {
+	runes := []rune(word)
+	var b strings.Builder
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if i+1 < len(runes) && runes[i+1] == bengaliNukta {
+			if composed, ok := bengaliNuktaForms[r]; ok {
+				r = composed
+				i++
+			}
+		}
+
+		if latin, ok := bengaliConsonants[r]; ok {
+			b.WriteString(latin)
+			if i+1 < len(runes) {
+				next := runes[i+1]
+				_, isVowelSign := bengaliVowels[next]
+				if next != bengaliVirama && !isVowelSign && isBengali(next) {
+					b.WriteByte('o')
+				}
+			}
+			continue
+		}
+		if latin, ok := bengaliVowels[r]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		if latin, ok := bengaliSigns[r]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		if r == bengaliVirama || r == bengaliNukta {
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,181 @@
+package names
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MatchThreshold is the Similarity score from which two pandal names are treated as the same
+const MatchThreshold = 0.75
+
+// abbreviations expands short forms common in pandal names and addresses
+var abbreviations = map[string]string{
+	"sq":    "square",
+	"rd":    "road",
+	"st":    "street",
+	"ln":    "lane",
+	"pk":    "park",
+	"sarb":  "sarbojanin",
+	"assn":  "association",
+	"assoc": "association",
+}
+
+// stopWords are generic words that say nothing about which pandal is meant.
+// They are compared after folding, so spelling variants are covered too.
+var stopWords = foldAll(
+	"the", "of", "and", "sri", "shree", "shri",
+	"durga", "puja", "pujo", "pooja", "utsav", "utsab", "durgotsav", "durgotsab",
+	"sarbojanin", "sarbajanin", "sarvajanin", "sarbojonin",
+	"committee", "samiti", "samity", "sangha", "club", "pandal",
+)
+
+// Normalise reduces a pandal name to folded tokens so that common Bengali/English
+// spelling variants ("Sarbojanin", "Sarvajanin", "সর্বজনীন") compare equal.
+// Generic words are dropped unless nothing else is left.
+func Normalise(name string) string {
+	return strings.Join(tokens(name), " ")
+}
+
+// Similarity scores how alike two pandal names are, from 0 (unrelated) to 1 (same).
+// It is the better of a token-level Dice coefficient, where tokens may differ by a
+// typo, and the edit similarity of the names with spaces removed ("Bag Bazar" vs "Bagbazar").
+func Similarity(a, b string) float64 {
+	ta, tb := tokens(a), tokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	score := tokenDice(ta, tb)
+	if joined := ratio(strings.Join(ta, ""), strings.Join(tb, "")); joined > score {
+		score = joined
+	}
+	return score
+}
+
+// tokens splits, transliterates, expands and folds a name, dropping stop words
+func tokens(name string) []string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r)
+	})
+
+	all := make([]string, 0, len(words))
+	kept := make([]string, 0, len(words))
+	for _, word := range words {
+		word = transliterateBengali(word)
+		if full, ok := abbreviations[word]; ok {
+			word = full
+		}
+		folded := fold(word)
+		if folded == "" {
+			continue
+		}
+		all = append(all, folded)
+		if !stopWords[folded] {
+			kept = append(kept, folded)
+		}
+	}
+
+	if len(kept) == 0 {
+		return all
+	}
+	return kept
+}
+
+// foldRules rewrite spelling variants onto one form, applied in order
+var foldRules = strings.NewReplacer(
+	"chh", "c", "ch", "c", "sh", "s", "th", "t", "dh", "d",
+	"bh", "b", "ph", "f", "kh", "k", "gh", "g", "jh", "j",
+	"ee", "i", "oo", "u", "v", "b", "w", "b", "z", "j", "q", "k", "y", "i",
+)
+
+// fold maps a romanised word onto a phonetic key: aspirates and long vowels are
+// merged, "a"/"o" (both used for the Bengali inherent vowel) become "a", doubled
+// letters collapse, and a trailing "a" on longer words is dropped ("Durgotsava").
+func fold(word string) string {
+	word = foldRules.Replace(word)
+	word = strings.ReplaceAll(word, "o", "a")
+
+	var b strings.Builder
+	var prev rune
+	for _, r := range word {
+		if r != prev {
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+	word = b.String()
+
+	if len(word) > 3 && strings.HasSuffix(word, "a") {
+		word = word[:len(word)-1]
+	}
+	return word
+}
+
+// foldAll folds words into a lookup set
+func foldAll(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[fold(word)] = true
+	}
+	return set
+}
+
+// tokenDice pairs each token of a with its closest unused token of b and returns
+// the Dice coefficient, crediting pairs by their edit similarity when it is high enough
+func tokenDice(a, b []string) float64 {
+	used := make([]bool, len(b))
+	var matched float64
+
+	for _, ta := range a {
+		best, bestIdx := 0.0, -1
+		for j, tb := range b {
+			if used[j] {
+				continue
+			}
+			if r := ratio(ta, tb); r > best {
+				best, bestIdx = r, j
+			}
+		}
+		if bestIdx >= 0 && best >= 0.8 {
+			used[bestIdx] = true
+			matched += best
+		}
+	}
+
+	return 2 * matched / float64(len(a)+len(b))
+}
+
+// ratio is the Levenshtein similarity of a and b: 1 minus the edit distance over the longer length
+func ratio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the edit distance between a and b using two rolling rows
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
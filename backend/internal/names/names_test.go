@@ -0,0 +1,76 @@
+package names_test
+
+import (
+	"testing"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/names"
+)
+
+func TestNormalise(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"Sarbojanin Durgotsab", "Sarbajanin Durgotsav"},
+		{"Sarbojanin", "Sarvajanin"},
+		{"Suruchi Sangha", "Suruchee Sangha"},
+		{"Santosh Mitra Square", "Santosh Mitra Sq"},
+		{"Kumartuli Park Sarbojanin", "Kumartuli Pk Sarvajanin"},
+		{"Bagbazar Sarbojanin Durgotsab", "BAGBAZAR, sarbajanin durgotsav!"},
+	}
+	for _, tt := range tests {
+		if a, b := names.Normalise(tt.a), names.Normalise(tt.b); a != b {
+			t.Errorf("%q normalises to %q but %q to %q", tt.a, a, tt.b, b)
+		}
+	}
+}
+
+func TestNormaliseDropsGenericWords(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"Bagbazar Sarbojanin Durgotsab", "bagbajar"},
+		{"The Ahiritola Durga Puja Committee", "ahirital"},
+		// Nothing but generic words: keep them rather than leave nothing to compare
+		{"Sarbojanin Durgotsab", "sarbajanin durgatsab"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := names.Normalise(tt.name); got != tt.want {
+			t.Errorf("Normalise(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		match bool
+	}{
+		{"o and a for the inherent vowel", "Bagbazar Sarbojanin", "Bagbazar Sarbajanin", true},
+		{"b and v", "Bagbazar Durgotsab", "Bagbazar Durgotsav", true},
+		{"long vowels", "Suruchi Sangha", "Suruchee Sangha", true},
+		{"abbreviations", "Santosh Mitra Square", "Santosh Mitra Sq", true},
+		{"split words", "Bag Bazar", "Bagbazar", true},
+		{"Bengali script", "বাগবাজার সর্বজনীন", "Bagbazar Sarbojanin", true},
+		{"a typo", "Kumartuli Park", "Kumartoli Park", true},
+		{"same generic words, different place", "Ahiritola Sarbojanin", "Kumartuli Sarbojanin", false},
+		{"shared first word only", "Ekdalia Evergreen", "Ekdalia Everlasting", false},
+		{"one name extends the other", "Chaltabagan Lohapatti", "Chaltabagan", false},
+		{"empty", "", "Bagbazar", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := names.Similarity(tt.a, tt.b)
+			if score < 0 || score > 1 {
+				t.Fatalf("score %v is outside [0, 1]", score)
+			}
+			if got := score >= names.MatchThreshold; got != tt.match {
+				t.Fatalf("Similarity(%q, %q) = %.2f; match %v, want %v", tt.a, tt.b, score, got, tt.match)
+			}
+			if reverse := names.Similarity(tt.b, tt.a); reverse != score {
+				t.Fatalf("score is %.2f one way and %.2f the other", score, reverse)
+			}
+		})
+	}
+}
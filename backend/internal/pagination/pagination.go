@@ -0,0 +1,218 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Sort is a sort key accepted by list endpoints
+type Sort string
+
+const (
+	SortName      Sort = "name"
+	SortCreatedAt Sort = "createdAt"
+	SortRating    Sort = "rating"
+	SortDistance  Sort = "distance"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidCursor   = errors.New("invalid pagination cursor")
+	ErrUnsupportedSort = errors.New("unsupported sort")
+	ErrInvalidLimit    = errors.New("limit must be a positive integer")
+)
+
+// Params describes the page a client asked for
+type Params struct {
+	Limit  int
+	Sort   Sort
+	Cursor string
+}
+
+// Field maps a sort key onto a document field and direction
+type Field struct {
+	Name string
+	Desc bool
+}
+
+// Positional marks a sort whose order comes from the query itself (e.g. $nearSphere)
+// and is therefore paged by offset rather than by key
+var Positional = Field{}
+
+// token is the decoded form of an opaque cursor
+type token struct {
+	Sort   Sort               `bson:"s"`
+	Value  interface{}        `bson:"v,omitempty"`
+	ID     primitive.ObjectID `bson:"id,omitempty"`
+	Offset int64              `bson:"o,omitempty"`
+}
+
+// NewParams validates raw query values and applies the default and maximum limit
+func NewParams(limitStr, sort, cursor string) (Params, error) {
+	p := Params{Limit: DefaultLimit, Sort: Sort(sort), Cursor: cursor}
+	if limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return Params{}, ErrInvalidLimit
+		}
+		p.Limit = limit
+	}
+	if p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
+	return p, nil
+}
+
+// WithDefaultSort fills in the sort when the client gave none: nearest first for
+// proximity searches, newest first otherwise. Distance order needs coordinates.
+func (p Params) WithDefaultSort(hasCoords bool) (Params, error) {
+	if p.Sort == "" {
+		if hasCoords {
+			p.Sort = SortDistance
+		} else {
+			p.Sort = SortCreatedAt
+		}
+	}
+	if p.Sort == SortDistance && !hasCoords {
+		return Params{}, fmt.Errorf("%w: distance requires lng and lat", ErrUnsupportedSort)
+	}
+	return p, nil
+}
+
+// IsClientError reports whether err was caused by bad pagination input
+func IsClientError(err error) bool {
+	return errors.Is(err, ErrInvalidCursor) || errors.Is(err, ErrUnsupportedSort) || errors.Is(err, ErrInvalidLimit)
+}
+
+// Plan returns the filter and find options that fetch this page from a collection
+// whose sortable fields are described by fields. One extra document is requested
+// so the caller can tell whether another page exists.
+func (p Params) Plan(filter bson.M, fields map[Sort]Field) (bson.M, *options.FindOptions, error) {
+	field, ok := fields[p.Sort]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedSort, p.Sort)
+	}
+
+	tok, err := p.decode()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := options.Find().SetLimit(int64(p.Limit) + 1)
+
+	if field == Positional {
+		if tok != nil {
+			opts.SetSkip(tok.Offset)
+		}
+		return filter, opts, nil
+	}
+
+	dir := 1
+	cmp := "$gt"
+	if field.Desc {
+		dir = -1
+		cmp = "$lt"
+	}
+	if field.Name == "_id" {
+		opts.SetSort(bson.D{{Key: "_id", Value: dir}})
+	} else {
+		opts.SetSort(bson.D{{Key: field.Name, Value: dir}, {Key: "_id", Value: dir}})
+	}
+
+	if tok == nil {
+		return filter, opts, nil
+	}
+
+	// Keyset condition: strictly after the last (value, _id) pair of the previous page
+	var after bson.M
+	if field.Name == "_id" {
+		after = bson.M{"_id": bson.M{cmp: tok.ID}}
+	} else {
+		after = bson.M{"$or": bson.A{
+			bson.M{field.Name: bson.M{cmp: tok.Value}},
+			bson.M{field.Name: tok.Value, "_id": bson.M{cmp: tok.ID}},
+		}}
+	}
+
+	// Combine through $and so an existing $or (e.g. text search) is left intact
+	out := bson.M{}
+	for k, v := range filter {
+		out[k] = v
+	}
+	and, _ := out["$and"].(bson.A)
+	out["$and"] = append(and, after)
+	return out, opts, nil
+}
+
+// Next builds the cursor for the page after one ending in last
+func (p Params) Next(last bson.Raw, fields map[Sort]Field) (string, error) {
+	field := fields[p.Sort]
+	tok := token{Sort: p.Sort}
+
+	if field == Positional {
+		prev, err := p.decode()
+		if err != nil {
+			return "", err
+		}
+		if prev != nil {
+			tok.Offset = prev.Offset
+		}
+		tok.Offset += int64(p.Limit)
+		return encode(tok)
+	}
+
+	id, ok := last.Lookup("_id").ObjectIDOK()
+	if !ok {
+		return "", errors.New("cannot paginate documents without an ObjectID _id")
+	}
+	tok.ID = id
+
+	if field.Name != "_id" {
+		var value interface{}
+		if raw, err := last.LookupErr(field.Name); err == nil {
+			if err := raw.Unmarshal(&value); err != nil {
+				return "", err
+			}
+		}
+		tok.Value = value
+	}
+	return encode(tok)
+}
+
+func (p Params) decode() (*token, error) {
+	if p.Cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var tok token
+	if err := bson.Unmarshal(raw, &tok); err != nil || tok.Sort != p.Sort {
+		return nil, ErrInvalidCursor
+	}
+	// Sort keys are scalars; a document here could smuggle query operators into Plan
+	switch tok.Value.(type) {
+	case bson.D, bson.M, bson.A:
+		return nil, ErrInvalidCursor
+	}
+	return &tok, nil
+}
+
+func encode(tok token) (string, error) {
+	raw, err := bson.Marshal(tok)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
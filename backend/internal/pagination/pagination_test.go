@@ -0,0 +1,314 @@
+package pagination_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
+)
+
+// testFields is a collection sortable by name, newest first and best rated first,
+// plus one positional sort standing in for distance
+var testFields = map[pagination.Sort]pagination.Field{
+	pagination.SortName:      {Name: "name"},
+	pagination.SortCreatedAt: {Name: "_id", Desc: true},
+	pagination.SortRating:    {Name: "rating", Desc: true},
+	pagination.SortDistance:  pagination.Positional,
+}
+
+func TestNewParams(t *testing.T) {
+	tests := []struct {
+		limit string
+		want  int
+		err   error
+	}{
+		{"", pagination.DefaultLimit, nil},
+		{"5", 5, nil},
+		{"1000", pagination.MaxLimit, nil},
+		{"0", 0, pagination.ErrInvalidLimit},
+		{"-3", 0, pagination.ErrInvalidLimit},
+		{"ten", 0, pagination.ErrInvalidLimit},
+	}
+	for _, tt := range tests {
+		page, err := pagination.NewParams(tt.limit, "", "")
+		if !errors.Is(err, tt.err) || page.Limit != tt.want {
+			t.Errorf("limit %q: got %d, %v; want %d, %v", tt.limit, page.Limit, err, tt.want, tt.err)
+		}
+	}
+}
+
+// cursorAfter returns the cursor Next builds after doc
+func cursorAfter(t *testing.T, page pagination.Params, doc bson.M) string {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := page.Next(raw, testFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cursor
+}
+
+// rawCursor encodes a hand-made token the way a client could tamper with one
+func rawCursor(t *testing.T, token bson.M) string {
+	t.Helper()
+	raw, err := bson.Marshal(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	page := pagination.Params{Limit: 10, Sort: pagination.SortRating}
+	page.Cursor = cursorAfter(t, page, bson.M{"_id": id, "rating": 4.5})
+
+	filter, opts, err := page.Plan(bson.M{"status": "approved"}, testFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter["status"] != "approved" {
+		t.Fatalf("the caller's filter was lost: %v", filter)
+	}
+	// Strictly after (4.5, id) in descending order
+	want := bson.A{bson.M{"$or": bson.A{
+		bson.M{"rating": bson.M{"$lt": 4.5}},
+		bson.M{"rating": 4.5, "_id": bson.M{"$lt": id}},
+	}}}
+	if fmt.Sprint(filter["$and"]) != fmt.Sprint(want) {
+		t.Fatalf("got keyset condition %v, want %v", filter["$and"], want)
+	}
+	if *opts.Limit != 11 {
+		t.Fatalf("got limit %d, want one extra document to detect a next page", *opts.Limit)
+	}
+}
+
+func TestInvalidCursorsAreRefused(t *testing.T) {
+	valid := cursorAfter(t, pagination.Params{Limit: 10, Sort: pagination.SortRating}, bson.M{"_id": primitive.NewObjectID(), "rating": 4.5})
+
+	tests := map[string]struct {
+		sort   pagination.Sort
+		cursor string
+	}{
+		"not base64":            {pagination.SortRating, "not a cursor!"},
+		"base64 of plain text":  {pagination.SortRating, base64.RawURLEncoding.EncodeToString([]byte("hello"))},
+		"truncated":             {pagination.SortRating, valid[:len(valid)/2]},
+		"padded base64":         {pagination.SortRating, base64.URLEncoding.EncodeToString([]byte("hello"))},
+		"reused for other sort": {pagination.SortName, valid},
+		"sort rewritten":        {pagination.SortRating, rawCursor(t, bson.M{"s": "name", "v": "Bagbazar", "id": primitive.NewObjectID()})},
+		"operator as the value": {pagination.SortRating, rawCursor(t, bson.M{"s": "rating", "v": bson.M{"$ne": nil}, "id": primitive.NewObjectID()})},
+		"list as the value":     {pagination.SortRating, rawCursor(t, bson.M{"s": "rating", "v": bson.A{1, 2}, "id": primitive.NewObjectID()})},
+		"id of the wrong type":  {pagination.SortRating, rawCursor(t, bson.M{"s": "rating", "v": 4.5, "id": "not an id"})},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			page := pagination.Params{Limit: 10, Sort: tt.sort, Cursor: tt.cursor}
+			_, _, err := page.Plan(bson.M{}, testFields)
+			if !errors.Is(err, pagination.ErrInvalidCursor) {
+				t.Fatalf("got %v, want ErrInvalidCursor", err)
+			}
+			if !pagination.IsClientError(err) {
+				t.Fatal("an invalid cursor is not reported as the client's fault")
+			}
+		})
+	}
+}
+
+func TestUnsupportedSortIsRefused(t *testing.T) {
+	page := pagination.Params{Limit: 10, Sort: "popularity"}
+	if _, _, err := page.Plan(bson.M{}, testFields); !errors.Is(err, pagination.ErrUnsupportedSort) {
+		t.Fatalf("got %v, want ErrUnsupportedSort", err)
+	}
+	if _, err := (pagination.Params{Sort: pagination.SortDistance}).WithDefaultSort(false); !errors.Is(err, pagination.ErrUnsupportedSort) {
+		t.Fatalf("distance without coordinates: got %v, want ErrUnsupportedSort", err)
+	}
+}
+
+// matches evaluates the subset of the query language Plan produces
+func matches(t *testing.T, doc bson.M, filter bson.M) bool {
+	t.Helper()
+	for key, cond := range filter {
+		switch key {
+		case "$and":
+			for _, sub := range cond.(bson.A) {
+				if !matches(t, doc, sub.(bson.M)) {
+					return false
+				}
+			}
+		case "$or":
+			matched := false
+			for _, sub := range cond.(bson.A) {
+				matched = matched || matches(t, doc, sub.(bson.M))
+			}
+			if !matched {
+				return false
+			}
+		default:
+			ops, isOp := cond.(bson.M)
+			if !isOp {
+				if compare(t, doc[key], cond) != 0 {
+					return false
+				}
+				continue
+			}
+			for op, value := range ops {
+				c := compare(t, doc[key], value)
+				if (op == "$lt" && c >= 0) || (op == "$gt" && c <= 0) {
+					return false
+				}
+				if op != "$lt" && op != "$gt" {
+					t.Fatalf("unexpected operator %s", op)
+				}
+			}
+		}
+	}
+	return true
+}
+
+// compare orders the value types the test documents use
+func compare(t *testing.T, a, b interface{}) int {
+	t.Helper()
+	switch a := a.(type) {
+	case float64:
+		return cmpFloat(a, b.(float64))
+	case string:
+		return strings.Compare(a, b.(string))
+	case primitive.ObjectID:
+		b := b.(primitive.ObjectID)
+		return bytes.Compare(a[:], b[:])
+	}
+	t.Fatalf("cannot compare %T", a)
+	return 0
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// findPage runs a planned page over docs in memory, as Mongo would
+func findPage(t *testing.T, docs []bson.M, page pagination.Params) ([]bson.M, string) {
+	t.Helper()
+	filter, opts, err := page.Plan(bson.M{}, testFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found []bson.M
+	for _, doc := range docs {
+		if matches(t, doc, filter) {
+			found = append(found, doc)
+		}
+	}
+	sortKeys := opts.Sort.(bson.D)
+	slices.SortStableFunc(found, func(a, b bson.M) int {
+		for _, key := range sortKeys {
+			if c := compare(t, a[key.Key], b[key.Key]); c != 0 {
+				return c * key.Value.(int)
+			}
+		}
+		return 0
+	})
+	if len(found) > int(*opts.Limit) {
+		found = found[:*opts.Limit]
+	}
+
+	// As in repository.collectPage: the extra document only signals another page
+	if len(found) <= page.Limit {
+		return found, ""
+	}
+	found = found[:page.Limit]
+	return found, cursorAfter(t, page, found[len(found)-1])
+}
+
+func TestPagesWithEqualSortKeysNeitherRepeatNorSkip(t *testing.T) {
+	// Most pandals share a rating, so page boundaries fall inside runs of equal keys
+	ratings := []float64{4.5, 3.0, 4.5, 4.5, 5.0, 3.0, 4.5, 4.5, 3.0, 4.5}
+	docs := make([]bson.M, 0, len(ratings))
+	for i, rating := range ratings {
+		docs = append(docs, bson.M{"_id": primitive.NewObjectID(), "rating": rating, "name": fmt.Sprintf("pandal %d", i%3)})
+	}
+
+	for _, sort := range []pagination.Sort{pagination.SortRating, pagination.SortName, pagination.SortCreatedAt} {
+		for limit := 1; limit <= len(docs); limit++ {
+			t.Run(fmt.Sprintf("%s/limit %d", sort, limit), func(t *testing.T) {
+				page := pagination.Params{Limit: limit, Sort: sort}
+				seen := make(map[primitive.ObjectID]bool)
+				var previous bson.M
+				for pages := 0; ; pages++ {
+					if pages > len(docs) {
+						t.Fatal("paging does not end")
+					}
+					found, next := findPage(t, docs, page)
+					for _, doc := range found {
+						id := doc["_id"].(primitive.ObjectID)
+						if seen[id] {
+							t.Fatalf("%v is repeated on a later page", doc)
+						}
+						seen[id] = true
+						if previous != nil && !inOrder(t, sort, previous, doc) {
+							t.Fatalf("%v comes after %v", doc, previous)
+						}
+						previous = doc
+					}
+					if next == "" {
+						break
+					}
+					page.Cursor = next
+				}
+				if len(seen) != len(docs) {
+					t.Fatalf("saw %d of %d documents", len(seen), len(docs))
+				}
+			})
+		}
+	}
+}
+
+// inOrder reports whether b may follow a in the given sort
+func inOrder(t *testing.T, sort pagination.Sort, a, b bson.M) bool {
+	field := testFields[sort]
+	dir := 1
+	if field.Desc {
+		dir = -1
+	}
+	if c := compare(t, a[field.Name], b[field.Name]) * dir; c != 0 {
+		return c < 0
+	}
+	return compare(t, a["_id"], b["_id"])*dir < 0
+}
+
+func TestPositionalPagesAdvanceByOffset(t *testing.T) {
+	page := pagination.Params{Limit: 20, Sort: pagination.SortDistance}
+	for want := int64(0); want <= 40; want += 20 {
+		_, opts, err := page.Plan(bson.M{}, testFields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		skip := int64(0)
+		if opts.Skip != nil {
+			skip = *opts.Skip
+		}
+		if skip != want {
+			t.Fatalf("got skip %d, want %d", skip, want)
+		}
+		if page.Cursor, err = page.Next(nil, testFields); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
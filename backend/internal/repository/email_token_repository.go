@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// EmailTokenRepository defines database operations for single-use email tokens
+type EmailTokenRepository interface {
+	Create(ctx context.Context, token models.EmailToken) error
+	Consume(ctx context.Context, jti string, purpose models.TokenPurpose) (*models.EmailToken, error)
+	ConsumeAllForUser(ctx context.Context, userID string, purpose models.TokenPurpose) error
+}
+
+type emailTokenRepository struct {
+	collection *mongo.Collection
+}
+
+// NewEmailTokenRepository creates a new instance
+func NewEmailTokenRepository(collection *mongo.Collection) EmailTokenRepository {
+	return &emailTokenRepository{collection: collection}
+}
+
+func (r *emailTokenRepository) Create(ctx context.Context, token models.EmailToken) error {
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+// Consume atomically marks an unused, unexpired token of the given purpose as used and
+// returns it. mongo.ErrNoDocuments means the token is unknown, used or expired.
+func (r *emailTokenRepository) Consume(ctx context.Context, jti string, purpose models.TokenPurpose) (*models.EmailToken, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":       jti,
+		"purpose":   purpose,
+		"usedAt":    bson.M{"$exists": false},
+		"expiresAt": bson.M{"$gt": now},
+	}
+	var token models.EmailToken
+	err := r.collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{"usedAt": now}}).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ConsumeAllForUser burns every outstanding token of a purpose, e.g. older reset links
+// once the password has been changed
+func (r *emailTokenRepository) ConsumeAllForUser(ctx context.Context, userID string, purpose models.TokenPurpose) error {
+	filter := bson.M{
+		"userId":  userID,
+		"purpose": purpose,
+		"usedAt":  bson.M{"$exists": false},
+	}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"usedAt": time.Now()}})
+	return err
+}
@@ -7,16 +7,29 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
 )
 
 // FoodStopRepository defines database operations for food stops
 type FoodStopRepository interface {
 	Create(ctx context.Context, stop models.FoodStop) (*mongo.InsertOneResult, error)
 	FindAll(ctx context.Context, filter bson.M) ([]models.FoodStop, error)
+	FindPage(ctx context.Context, filter bson.M, page pagination.Params) ([]models.FoodStop, string, error)
+	FindNearPage(ctx context.Context, origin geo.Point, minMeters, maxMeters float64, filter bson.M, page pagination.Params) ([]models.FoodStop, string, error)
+	FindCapped(ctx context.Context, filter bson.M, limit int) ([]models.FoodStop, bool, error)
 	FindByID(ctx context.Context, id primitive.ObjectID) (*models.FoodStop, error)
 }
 
+// foodStopSortFields maps list sort keys onto food stop fields.
+// Food stops have no createdAt, so creation order falls back to the ObjectID.
+var foodStopSortFields = map[pagination.Sort]pagination.Field{
+	pagination.SortName:      {Name: "name"},
+	pagination.SortCreatedAt: {Name: "_id", Desc: true},
+	pagination.SortDistance:  pagination.Positional,
+}
+
 type foodStopRepository struct {
 	collection *mongo.Collection
 }
@@ -47,6 +60,21 @@ func (r *foodStopRepository) FindAll(ctx context.Context, filter bson.M) ([]mode
 	return stops, nil
 }
 
+func (r *foodStopRepository) FindPage(ctx context.Context, filter bson.M, page pagination.Params) ([]models.FoodStop, string, error) {
+	return findPage[models.FoodStop](ctx, r.collection, filter, page, foodStopSortFields)
+}
+
+// FindNearPage returns one page of food stops within [minMeters, maxMeters] of origin,
+// each with its distanceMeters set
+func (r *foodStopRepository) FindNearPage(ctx context.Context, origin geo.Point, minMeters, maxMeters float64, filter bson.M, page pagination.Params) ([]models.FoodStop, string, error) {
+	return findNearPage[models.FoodStop](ctx, r.collection, origin, minMeters, maxMeters, filter, page, foodStopSortFields)
+}
+
+// FindCapped returns up to limit food stops, oldest first, and whether more matched
+func (r *foodStopRepository) FindCapped(ctx context.Context, filter bson.M, limit int) ([]models.FoodStop, bool, error) {
+	return findCapped[models.FoodStop](ctx, r.collection, filter, bson.D{{Key: "_id", Value: 1}}, limit)
+}
+
 func (r *foodStopRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.FoodStop, error) {
 	var stop models.FoodStop
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&stop)
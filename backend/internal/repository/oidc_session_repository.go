@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// OIDCSessionRepository defines database operations for pending OpenID Connect logins
+type OIDCSessionRepository interface {
+	Create(ctx context.Context, session models.OIDCSession) error
+	Consume(ctx context.Context, state, provider string) (*models.OIDCSession, error)
+}
+
+type oidcSessionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewOIDCSessionRepository creates a new instance
+func NewOIDCSessionRepository(collection *mongo.Collection) OIDCSessionRepository {
+	return &oidcSessionRepository{collection: collection}
+}
+
+func (r *oidcSessionRepository) Create(ctx context.Context, session models.OIDCSession) error {
+	_, err := r.collection.InsertOne(ctx, session)
+	return err
+}
+
+// Consume atomically removes and returns an unexpired session for the provider.
+// mongo.ErrNoDocuments means the state is unknown, already used or expired.
+func (r *oidcSessionRepository) Consume(ctx context.Context, state, provider string) (*models.OIDCSession, error) {
+	filter := bson.M{
+		"_id":       state,
+		"provider":  provider,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+	var session models.OIDCSession
+	if err := r.collection.FindOneAndDelete(ctx, filter).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
+)
+
+// findPage runs a paginated Find and decodes the results into T.
+// It returns the items of the requested page and the cursor of the next one,
+// which is empty on the last page.
+func findPage[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, page pagination.Params, fields map[pagination.Sort]pagination.Field) ([]T, string, error) {
+	pageFilter, opts, err := page.Plan(filter, fields)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cursor, err := collection.Find(ctx, pageFilter, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	return collectPage[T](ctx, cursor, page, fields)
+}
+
+// findNearPage pages through the documents matching filter ordered by distance from
+// origin, or by the page's sort key, using a $geoNear aggregation so every document
+// carries its distanceMeters. A zero maxMeters means no upper bound.
+func findNearPage[T any](ctx context.Context, collection *mongo.Collection, origin geo.Point, minMeters, maxMeters float64, filter bson.M, page pagination.Params, fields map[pagination.Sort]pagination.Field) ([]T, string, error) {
+	pageFilter, opts, err := page.Plan(filter, fields)
+	if err != nil {
+		return nil, "", err
+	}
+
+	geoNear := bson.M{
+		"near":          bson.M{"type": "Point", "coordinates": bson.A{origin.Lng, origin.Lat}},
+		"distanceField": "distanceMeters",
+		"spherical":     true,
+		"query":         pageFilter,
+	}
+	if minMeters > 0 {
+		geoNear["minDistance"] = minMeters
+	}
+	if maxMeters > 0 {
+		geoNear["maxDistance"] = maxMeters
+	}
+
+	// $geoNear already yields nearest first; Plan's sort, skip and limit become stages
+	pipeline := mongo.Pipeline{{{Key: "$geoNear", Value: geoNear}}}
+	if opts.Sort != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: opts.Sort}})
+	}
+	if opts.Skip != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: *opts.Skip}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$limit", Value: *opts.Limit}})
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cursor.Close(ctx)
+
+	return collectPage[T](ctx, cursor, page, fields)
+}
+
+// collectPage decodes a page from cursor and builds the cursor of the next page
+func collectPage[T any](ctx context.Context, cursor *mongo.Cursor, page pagination.Params, fields map[pagination.Sort]pagination.Field) ([]T, string, error) {
+	var raws []bson.Raw
+	for cursor.Next(ctx) {
+		raws = append(raws, append(bson.Raw(nil), cursor.Current...))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, "", err
+	}
+
+	// Plan asks for one extra document to detect whether another page exists
+	hasMore := len(raws) > page.Limit
+	if hasMore {
+		raws = raws[:page.Limit]
+	}
+
+	items := make([]T, 0, len(raws))
+	for _, raw := range raws {
+		var item T
+		if err := bson.Unmarshal(raw, &item); err != nil {
+			return nil, "", err
+		}
+		items = append(items, item)
+	}
+
+	nextCursor := ""
+	if hasMore {
+		var err error
+		nextCursor, err = page.Next(raws[len(raws)-1], fields)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return items, nextCursor, nil
+}
+
+// findCapped runs an unpaginated Find returning at most limit documents in sort order.
+// truncated reports whether more documents matched than were returned.
+func findCapped[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, sort bson.D, limit int) ([]T, bool, error) {
+	opts := options.Find().SetSort(sort).SetLimit(int64(limit) + 1)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	defer cursor.Close(ctx)
+
+	items := make([]T, 0)
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, false, err
+	}
+
+	// One extra document is requested to detect truncation
+	truncated := len(items) > limit
+	if truncated {
+		items = items[:limit]
+	}
+	return items, truncated, nil
+}
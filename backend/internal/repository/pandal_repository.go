@@ -6,17 +6,37 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
 )
 
 // PandalRepository defines the interface for database operations
 type PandalRepository interface {
 	Create(ctx context.Context, pandal models.Pandal) (*mongo.InsertOneResult, error)
 	FindAll(ctx context.Context, filter bson.M) ([]models.Pandal, error)
+	FindPage(ctx context.Context, filter bson.M, page pagination.Params) ([]models.Pandal, string, error)
+	FindNearPage(ctx context.Context, origin geo.Point, minMeters, maxMeters float64, filter bson.M, page pagination.Params) ([]models.Pandal, string, error)
+	FindCapped(ctx context.Context, filter bson.M, limit int) ([]models.Pandal, bool, error)
 	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Pandal, error)
 	Update(ctx context.Context, id primitive.ObjectID, update bson.M) (*mongo.UpdateResult, error)
+	FindOneAndUpdate(ctx context.Context, filter bson.M, update interface{}) (*models.Pandal, error)
+	ApplyRatingChange(ctx context.Context, id primitive.ObjectID, oldRating, newRating int) error
 	AggregateDistricts(ctx context.Context, country, state string) ([]models.District, error)
+	AggregateClusters(ctx context.Context, filter bson.M, cellLng, cellLat float64, limit int) ([]models.PandalCluster, error)
+	BulkUpsert(ctx context.Context, pandals []models.Pandal) (*mongo.BulkWriteResult, error)
+	ReplaceUser(ctx context.Context, userID, replacement string) error
+}
+
+// pandalSortFields maps list sort keys onto pandal document fields.
+// Distance order comes from the $geoNear stage of FindNearPage.
+var pandalSortFields = map[pagination.Sort]pagination.Field{
+	pagination.SortName:      {Name: "name"},
+	pagination.SortCreatedAt: {Name: "createdAt", Desc: true},
+	pagination.SortRating:    {Name: "ratingAvg", Desc: true},
+	pagination.SortDistance:  pagination.Positional,
 }
 
 // pandalRepository implements the PandalRepository interface
@@ -70,6 +90,24 @@ func (r *pandalRepository) FindAll(ctx context.Context, filter bson.M) ([]models
 	return pandals, nil
 }
 
+// FindPage retrieves one page of pandals matching filter and the cursor of the next page
+func (r *pandalRepository) FindPage(ctx context.Context, filter bson.M, page pagination.Params) ([]models.Pandal, string, error) {
+	return findPage[models.Pandal](ctx, r.collection, filter, page, pandalSortFields)
+}
+
+// FindNearPage returns one page of pandals within [minMeters, maxMeters] of origin,
+// each with its distanceMeters set
+func (r *pandalRepository) FindNearPage(ctx context.Context, origin geo.Point, minMeters, maxMeters float64, filter bson.M, page pagination.Params) ([]models.Pandal, string, error) {
+	return findNearPage[models.Pandal](ctx, r.collection, origin, minMeters, maxMeters, filter, page, pandalSortFields)
+}
+
+// FindCapped returns up to limit pandals and whether more matched.
+// The best rated come first so a truncated map still shows the pandals most worth a visit.
+func (r *pandalRepository) FindCapped(ctx context.Context, filter bson.M, limit int) ([]models.Pandal, bool, error) {
+	sort := bson.D{{Key: "ratingAvg", Value: -1}, {Key: "_id", Value: 1}}
+	return findCapped[models.Pandal](ctx, r.collection, filter, sort, limit)
+}
+
 // FindByID retrieves a pandal by its ID
 func (r *pandalRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Pandal, error) {
 	var pandal models.Pandal
@@ -85,11 +123,151 @@ func (r *pandalRepository) Update(ctx context.Context, id primitive.ObjectID, up
 	return r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
 }
 
+// FindOneAndUpdate atomically applies update to the first pandal matching filter
+// and returns the document as it is after the update.
+// Returns mongo.ErrNoDocuments when nothing matches the filter.
+func (r *pandalRepository) FindOneAndUpdate(ctx context.Context, filter bson.M, update interface{}) (*models.Pandal, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var pandal models.Pandal
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&pandal); err != nil {
+		return nil, err
+	}
+	return &pandal, nil
+}
+
+// BulkUpsert writes pandals in a single unordered bulk operation keyed by _id.
+// Descriptive fields are always overwritten; status, votes, ratings and the
+// creation fields are only set when the pandal is inserted. A pending pandal whose
+// name, district or pin changes loses its votes, as it does when edited, since votes
+// cast for one name or pin must not count towards another.
+func (r *pandalRepository) BulkUpsert(ctx context.Context, pandals []models.Pandal) (*mongo.BulkWriteResult, error) {
+	if len(pandals) == 0 {
+		return &mongo.BulkWriteResult{}, nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(pandals))
+	for _, pandal := range pandals {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": pandal.ID}).
+			SetUpdate(upsertUpdate(pandal)).
+			SetUpsert(true))
+	}
+
+	return r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+}
+
+// upsertUpdate builds the pipeline update of BulkUpsert. A pipeline has no
+// $setOnInsert, so an inserted document is recognised by having no createdAt yet.
+// Values are wrapped in $literal so text starting with "$" is not read as a field path.
+func upsertUpdate(pandal models.Pandal) mongo.Pipeline {
+	inserted := bson.M{"$eq": bson.A{bson.M{"$type": "$createdAt"}, "missing"}}
+	changed := func(field string, value any) bson.M {
+		return bson.M{"$ne": bson.A{"$" + field, bson.M{"$literal": value}}}
+	}
+	resetVotes := bson.M{"$and": bson.A{
+		bson.M{"$eq": bson.A{"$status", models.StatusPending}},
+		bson.M{"$or": bson.A{
+			changed("name", pandal.Name),
+			changed("country", pandal.Country),
+			changed("state", pandal.State),
+			changed("district", pandal.District),
+			changed("location.coordinates", pandal.Location.Coordinates),
+		}},
+	}}
+
+	onInsert := func(field string, value any) bson.M {
+		return bson.M{"$cond": bson.A{inserted, bson.M{"$literal": value}, "$" + field}}
+	}
+	vote := func(field string, value, reset any) bson.M {
+		return bson.M{"$cond": bson.A{
+			inserted,
+			bson.M{"$literal": value},
+			bson.M{"$cond": bson.A{resetVotes, bson.M{"$literal": reset}, "$" + field}},
+		}}
+	}
+
+	// Every expression of one $set stage reads the document as it was before the stage
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"name":           bson.M{"$literal": pandal.Name},
+			"description":    bson.M{"$literal": pandal.Description},
+			"area":           bson.M{"$literal": pandal.Area},
+			"district":       bson.M{"$literal": pandal.District},
+			"state":          bson.M{"$literal": pandal.State},
+			"country":        bson.M{"$literal": pandal.Country},
+			"theme":          bson.M{"$literal": pandal.Theme},
+			"tags":           bson.M{"$literal": pandal.Tags},
+			"location":       bson.M{"$literal": pandal.Location},
+			"images":         bson.M{"$literal": pandal.Images},
+			"updatedAt":      pandal.UpdatedAt,
+			"ratingAvg":      onInsert("ratingAvg", pandal.RatingAvg),
+			"ratingCount":    onInsert("ratingCount", pandal.RatingCount),
+			"status":         onInsert("status", pandal.Status),
+			"createdBy":      onInsert("createdBy", pandal.CreatedBy),
+			"createdAt":      onInsert("createdAt", pandal.CreatedAt),
+			"approvalCount":  vote("approvalCount", pandal.ApprovalCount, 0),
+			"approvedBy":     vote("approvedBy", pandal.ApprovedBy, bson.A{}),
+			"rejectionCount": vote("rejectionCount", pandal.RejectionCount, 0),
+			"rejectedBy":     vote("rejectedBy", pandal.RejectedBy, bson.A{}),
+			"rejections":     vote("rejections", pandal.Rejections, bson.A{}),
+		}}},
+	}
+}
+
+// ApplyRatingChange folds a review change into ratingAvg/ratingCount in one atomic update.
+// oldRating is 0 for a new review and newRating is 0 for a deleted one.
+// A new review only counts towards a live pandal: mongo.ErrNoDocuments reports that the
+// pandal was deleted or merged away since the review was checked.
+func (r *pandalRepository) ApplyRatingChange(ctx context.Context, id primitive.ObjectID, oldRating, newRating int) error {
+	countDelta := 0
+	if oldRating > 0 {
+		countDelta--
+	}
+	if newRating > 0 {
+		countDelta++
+	}
+
+	avg := bson.M{"$ifNull": bson.A{"$ratingAvg", 0}}
+	count := bson.M{"$ifNull": bson.A{"$ratingCount", 0}}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"ratingAvg": bson.M{"$let": bson.M{
+				"vars": bson.M{
+					"sum":   bson.M{"$add": bson.A{bson.M{"$multiply": bson.A{avg, count}}, newRating - oldRating}},
+					"count": bson.M{"$add": bson.A{count, countDelta}},
+				},
+				"in": bson.M{"$cond": bson.A{
+					bson.M{"$gt": bson.A{"$$count", 0}},
+					bson.M{"$divide": bson.A{"$$sum", "$$count"}},
+					0,
+				}},
+			}},
+			"ratingCount": bson.M{"$max": bson.A{bson.M{"$add": bson.A{count, countDelta}}, 0}},
+		}}},
+	}
+
+	filter := bson.M{"_id": id}
+	if oldRating == 0 {
+		filter["deletedAt"] = bson.M{"$exists": false}
+	}
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
 // AggregateDistricts groups approved pandals by district and returns counts
 func (r *pandalRepository) AggregateDistricts(ctx context.Context, country, state string) ([]models.District, error) {
 	matchStage := bson.M{
-		"status":   "approved",
-		"district": bson.M{"$ne": ""},
+		"status":    "approved",
+		"district":  bson.M{"$ne": ""},
+		"deletedAt": bson.M{"$exists": false},
 	}
 	if country != "" {
 		matchStage["country"] = country
@@ -122,3 +300,92 @@ func (r *pandalRepository) AggregateDistricts(ctx context.Context, country, stat
 	}
 	return districts, nil
 }
+
+// AggregateClusters groups the pandals matching filter into a grid of cellLng by cellLat
+// degree cells anchored at (-180, -90), so cells stay put as the map pans.
+// At most limit clusters are returned, largest first.
+func (r *pandalRepository) AggregateClusters(ctx context.Context, filter bson.M, cellLng, cellLat float64, limit int) ([]models.PandalCluster, error) {
+	lng := bson.M{"$arrayElemAt": bson.A{"$location.coordinates", 0}}
+	lat := bson.M{"$arrayElemAt": bson.A{"$location.coordinates", 1}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$project", Value: bson.M{
+			"name":        1,
+			"ratingAvg":   1,
+			"ratingCount": 1,
+			"lng":         lng,
+			"lat":         lat,
+		}}},
+		// Sorted first so $first below picks each cell's best rated pandal
+		{{Key: "$sort", Value: bson.D{{Key: "ratingAvg", Value: -1}, {Key: "ratingCount", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"x": bson.M{"$floor": bson.M{"$divide": bson.A{bson.M{"$add": bson.A{"$lng", 180}}, cellLng}}},
+				"y": bson.M{"$floor": bson.M{"$divide": bson.A{bson.M{"$add": bson.A{"$lat", 90}}, cellLat}}},
+			},
+			"count":  bson.M{"$sum": 1},
+			"lng":    bson.M{"$avg": "$lng"},
+			"lat":    bson.M{"$avg": "$lat"},
+			"minLng": bson.M{"$min": "$lng"},
+			"minLat": bson.M{"$min": "$lat"},
+			"maxLng": bson.M{"$max": "$lng"},
+			"maxLat": bson.M{"$max": "$lat"},
+			"top": bson.M{"$first": bson.M{
+				"_id":         "$_id",
+				"name":        "$name",
+				"ratingAvg":   "$ratingAvg",
+				"ratingCount": "$ratingCount",
+			}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$project", Value: bson.M{
+			"_id":      0,
+			"count":    1,
+			"top":      1,
+			"centroid": bson.A{"$lng", "$lat"},
+			"bounds":   bson.A{"$minLng", "$minLat", "$maxLng", "$maxLat"},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	clusters := []models.PandalCluster{}
+	if err := cursor.All(ctx, &clusters); err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// ReplaceUser swaps userID for replacement wherever it appears on pandals: as the
+// submitter, among the approvers and rejecters, and on rejection reasons
+func (r *pandalRepository) ReplaceUser(ctx context.Context, userID, replacement string) error {
+	updates := []struct {
+		filter bson.M
+		update bson.M
+	}{
+		{bson.M{"createdBy": userID}, bson.M{"$set": bson.M{"createdBy": replacement}}},
+		{bson.M{"approvedBy": userID}, bson.M{"$set": bson.M{"approvedBy.$": replacement}}},
+		{bson.M{"rejectedBy": userID}, bson.M{"$set": bson.M{"rejectedBy.$": replacement}}},
+	}
+	for _, u := range updates {
+		if _, err := r.collection.UpdateMany(ctx, u.filter, u.update); err != nil {
+			return err
+		}
+	}
+
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"r.userId": userID}},
+	})
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"rejections.userId": userID},
+		bson.M{"$set": bson.M{"rejections.$[r].userId": replacement}},
+		opts,
+	)
+	return err
+}
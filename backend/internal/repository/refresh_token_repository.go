@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// RefreshTokenRepository defines database operations for persisted refresh tokens
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token models.RefreshToken) error
+	FindByID(ctx context.Context, jti string) (*models.RefreshToken, error)
+	MarkUsed(ctx context.Context, jti string) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID string) error
+}
+
+type refreshTokenRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRefreshTokenRepository creates a new instance
+func NewRefreshTokenRepository(collection *mongo.Collection) RefreshTokenRepository {
+	return &refreshTokenRepository{collection: collection}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token models.RefreshToken) error {
+	_, err := r.collection.InsertOne(ctx, token)
+	return err
+}
+
+func (r *refreshTokenRepository) FindByID(ctx context.Context, jti string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.collection.FindOne(ctx, bson.M{"_id": jti}).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed atomically consumes an unused, unrevoked token.
+// It returns false when the token was already used or revoked, which signals reuse.
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, jti string) (bool, error) {
+	filter := bson.M{
+		"_id":       jti,
+		"usedAt":    bson.M{"$exists": false},
+		"revokedAt": bson.M{"$exists": false},
+	}
+	res, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"usedAt": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// RevokeFamily revokes every token descended from the same login
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	filter := bson.M{
+		"familyId":  familyID,
+		"revokedAt": bson.M{"$exists": false},
+	}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
+
+// RevokeAllForUser revokes every outstanding token of a user, across all devices
+func (r *refreshTokenRepository) RevokeAllForUser(ctx context.Context, userID string) error {
+	filter := bson.M{
+		"userId":    userID,
+		"revokedAt": bson.M{"$exists": false},
+	}
+	_, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revokedAt": time.Now()}})
+	return err
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
+)
+
+// ReviewRepository defines database operations for pandal reviews
+type ReviewRepository interface {
+	Create(ctx context.Context, review models.Review) (*mongo.InsertOneResult, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Review, error)
+	FindPage(ctx context.Context, pandalID primitive.ObjectID, page pagination.Params) ([]models.Review, string, error)
+	Update(ctx context.Context, id primitive.ObjectID, update bson.M) (*mongo.UpdateResult, error)
+	FindOneAndUpdateBefore(ctx context.Context, filter bson.M, update bson.M) (*models.Review, error)
+	Delete(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error)
+	DeleteFromPandal(ctx context.Context, id, pandalID primitive.ObjectID) (*mongo.DeleteResult, error)
+	ReassignPandal(ctx context.Context, from, to primitive.ObjectID) (moved, dropped int64, err error)
+	RatingSummary(ctx context.Context, pandalID primitive.ObjectID) (avg float64, count int, err error)
+	ReplaceUser(ctx context.Context, userID, replacement string) error
+}
+
+// reviewSortFields maps list sort keys onto review fields
+var reviewSortFields = map[pagination.Sort]pagination.Field{
+	pagination.SortCreatedAt: {Name: "createdAt", Desc: true},
+	pagination.SortRating:    {Name: "rating", Desc: true},
+}
+
+type reviewRepository struct {
+	collection *mongo.Collection
+}
+
+// NewReviewRepository creates a new instance
+func NewReviewRepository(collection *mongo.Collection) ReviewRepository {
+	return &reviewRepository{collection: collection}
+}
+
+func (r *reviewRepository) Create(ctx context.Context, review models.Review) (*mongo.InsertOneResult, error) {
+	return r.collection.InsertOne(ctx, review)
+}
+
+func (r *reviewRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Review, error) {
+	var review models.Review
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&review)
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// FindPage retrieves one page of a pandal's reviews and the cursor of the next page
+func (r *reviewRepository) FindPage(ctx context.Context, pandalID primitive.ObjectID, page pagination.Params) ([]models.Review, string, error) {
+	return findPage[models.Review](ctx, r.collection, bson.M{"pandalId": pandalID}, page, reviewSortFields)
+}
+
+func (r *reviewRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M) (*mongo.UpdateResult, error) {
+	return r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+}
+
+// FindOneAndUpdateBefore atomically applies update to the first review matching filter
+// and returns the document as it was before the update.
+// Returns mongo.ErrNoDocuments when nothing matches the filter.
+func (r *reviewRepository) FindOneAndUpdateBefore(ctx context.Context, filter bson.M, update bson.M) (*models.Review, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	var review models.Review
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&review); err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) Delete(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
+	return r.collection.DeleteOne(ctx, bson.M{"_id": id})
+}
+
+// DeleteFromPandal deletes a review only while it still belongs to pandalID
+func (r *reviewRepository) DeleteFromPandal(ctx context.Context, id, pandalID primitive.ObjectID) (*mongo.DeleteResult, error) {
+	return r.collection.DeleteOne(ctx, bson.M{"_id": id, "pandalId": pandalID})
+}
+
+// ReassignPandal moves every review of pandal from onto pandal to. Reviews by users
+// who already reviewed to are deleted instead, keeping one review per user per pandal.
+// Each review is moved on its own and the unique (pandalId, userId) index decides which
+// are dropped, so a review written to to meanwhile never leaves the move half done.
+func (r *reviewRepository) ReassignPandal(ctx context.Context, from, to primitive.ObjectID) (int64, int64, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"pandalId": from}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, 0, err
+	}
+	var reviews []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return 0, 0, err
+	}
+
+	var moved, dropped int64
+	for _, review := range reviews {
+		res, err := r.collection.UpdateOne(ctx, bson.M{"_id": review.ID, "pandalId": from}, bson.M{"$set": bson.M{"pandalId": to}})
+		if mongo.IsDuplicateKeyError(err) {
+			deleted, err := r.collection.DeleteOne(ctx, bson.M{"_id": review.ID, "pandalId": from})
+			if err != nil {
+				return moved, dropped, err
+			}
+			dropped += deleted.DeletedCount
+			continue
+		}
+		if err != nil {
+			return moved, dropped, err
+		}
+		moved += res.ModifiedCount
+	}
+	return moved, dropped, nil
+}
+
+// RatingSummary recomputes a pandal's average rating and review count from its reviews
+func (r *reviewRepository) RatingSummary(ctx context.Context, pandalID primitive.ObjectID) (float64, int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"pandalId": pandalID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"avg":   bson.M{"$avg": "$rating"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var summary []struct {
+		Avg   float64 `bson:"avg"`
+		Count int     `bson:"count"`
+	}
+	if err := cursor.All(ctx, &summary); err != nil {
+		return 0, 0, err
+	}
+	if len(summary) == 0 {
+		return 0, 0, nil
+	}
+	return summary[0].Avg, summary[0].Count, nil
+}
+
+// ReplaceUser re-attributes a user's reviews to replacement, keeping their ratings
+func (r *reviewRepository) ReplaceUser(ctx context.Context, userID, replacement string) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"userId": userID}, bson.M{"$set": bson.M{"userId": replacement}})
+	return err
+}
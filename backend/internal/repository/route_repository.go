@@ -8,13 +8,25 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
 )
 
 // RouteRepository defines database operations for routes
 type RouteRepository interface {
 	Create(ctx context.Context, route models.Route) (*mongo.InsertOneResult, error)
 	FindAll(ctx context.Context) ([]models.Route, error)
+	FindPage(ctx context.Context, filter bson.M, page pagination.Params) ([]models.Route, string, error)
 	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Route, error)
+	FindOneWithStops(ctx context.Context, filter bson.M) (*models.Route, []models.Pandal, error)
+	Update(ctx context.Context, id primitive.ObjectID, update bson.M) (*mongo.UpdateResult, error)
+	ReplaceStop(ctx context.Context, from, to primitive.ObjectID) (int64, error)
+	ReplaceOwner(ctx context.Context, userID, replacement string) error
+}
+
+// routeSortFields maps list sort keys onto route fields
+var routeSortFields = map[pagination.Sort]pagination.Field{
+	pagination.SortName:      {Name: "title"},
+	pagination.SortCreatedAt: {Name: "createdAt", Desc: true},
 }
 
 type routeRepository struct {
@@ -51,6 +63,10 @@ func (r *routeRepository) FindAll(ctx context.Context) ([]models.Route, error) {
 	return routes, nil
 }
 
+func (r *routeRepository) FindPage(ctx context.Context, filter bson.M, page pagination.Params) ([]models.Route, string, error) {
+	return findPage[models.Route](ctx, r.collection, filter, page, routeSortFields)
+}
+
 func (r *routeRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Route, error) {
 	var route models.Route
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&route)
@@ -59,3 +75,96 @@ func (r *routeRepository) FindByID(ctx context.Context, id primitive.ObjectID) (
 	}
 	return &route, nil
 }
+
+func (r *routeRepository) Update(ctx context.Context, id primitive.ObjectID, update bson.M) (*mongo.UpdateResult, error) {
+	return r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+}
+
+// FindOneWithStops loads the route matching filter together with the pandal documents of its stops in one
+// $lookup aggregation. $lookup does not keep array order, so the pandals are re-ordered to
+// follow route.Stops; stops whose pandal no longer exists are simply absent.
+func (r *routeRepository) FindOneWithStops(ctx context.Context, filter bson.M) (*models.Route, []models.Pandal, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$limit", Value: 1}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         r.pandalCollection.Name(),
+			"localField":   "stops",
+			"foreignField": "_id",
+			"as":           "stopPandals",
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		models.Route `bson:",inline"`
+		StopPandals  []models.Pandal `bson:"stopPandals"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, nil, err
+	}
+	if len(results) == 0 {
+		return nil, nil, mongo.ErrNoDocuments
+	}
+
+	byID := make(map[primitive.ObjectID]models.Pandal, len(results[0].StopPandals))
+	for _, pandal := range results[0].StopPandals {
+		byID[pandal.ID] = pandal
+	}
+
+	stops := make([]models.Pandal, 0, len(results[0].Stops))
+	for _, stopID := range results[0].Stops {
+		if pandal, ok := byID[stopID]; ok {
+			stops = append(stops, pandal)
+		}
+	}
+
+	route := results[0].Route
+	return &route, stops, nil
+}
+
+// ReplaceStop swaps pandal from for pandal to in every route that stops at from.
+// Order is kept, and if a route already visits to the repeated stop is dropped.
+func (r *routeRepository) ReplaceStop(ctx context.Context, from, to primitive.ObjectID) (int64, error) {
+	replaced := bson.M{"$map": bson.M{
+		"input": "$stops",
+		"as":    "stop",
+		"in":    bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$$stop", from}}, to, "$$stop"}},
+	}}
+	deduped := bson.M{"$reduce": bson.M{
+		"input":        replaced,
+		"initialValue": bson.A{},
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$in": bson.A{"$$this", "$$value"}},
+			"$$value",
+			bson.M{"$concatArrays": bson.A{"$$value", bson.A{"$$this"}}},
+		}},
+	}}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"stops": deduped}}},
+		{{Key: "$set", Value: bson.M{"stopCount": bson.M{"$size": "$stops"}}}},
+	}
+
+	result, err := r.collection.UpdateMany(ctx, bson.M{"stops": from}, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// ReplaceOwner removes a user's private routes, which nobody else can see, and
+// re-attributes their shared ones to replacement so links and clones keep working
+func (r *routeRepository) ReplaceOwner(ctx context.Context, userID, replacement string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"createdBy": userID, "visibility": models.VisibilityPrivate})
+	if err != nil {
+		return err
+	}
+	_, err = r.collection.UpdateMany(ctx, bson.M{"createdBy": userID}, bson.M{"$set": bson.M{"createdBy": replacement}})
+	return err
+}
@@ -3,20 +3,37 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *models.User) error
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error)
+	LinkIdentity(ctx context.Context, id primitive.ObjectID, identity models.LinkedIdentity) error
+	UpdateRole(ctx context.Context, id primitive.ObjectID, role models.Role) error
+	UpdatePassword(ctx context.Context, id primitive.ObjectID, hashedPassword string) error
+	MarkEmailVerified(ctx context.Context, id primitive.ObjectID) error
+	SetLockedUntil(ctx context.Context, id primitive.ObjectID, until *time.Time) error
+	UpdateProfile(ctx context.Context, id primitive.ObjectID, fields bson.M) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
 }
 
+// ErrUserNotFound is returned when no user has the given ID, email or identity
+var ErrUserNotFound = errors.New("user not found")
+
+// EmailCollation compares email addresses ignoring case. The unique email index uses
+// it too, so accounts stored before addresses were lowercased still match lookups.
+var EmailCollation = &options.Collation{Locale: "en", Strength: 2}
+
 type userRepository struct {
 	collection *mongo.Collection
 }
@@ -34,12 +51,14 @@ func (r *userRepository) CreateUser(ctx context.Context, user *models.User) erro
 	return nil
 }
 
+// FindByEmail finds the user with the address, whatever its case
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	opts := options.FindOne().SetCollation(EmailCollation)
+	err := r.collection.FindOne(ctx, bson.M{"email": models.NormalizeEmail(email)}, opts).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -51,9 +70,136 @@ func (r *userRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 	return &user, nil
 }
+
+// FindByIdentity finds the user who linked the given OpenID Connect account
+func (r *userRepository) FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	var user models.User
+	filter := bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}}
+	err := r.collection.FindOne(ctx, filter).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// LinkIdentity adds an OpenID Connect account to the user. The provider verified the
+// email address it matched on, so the user's email counts as verified too.
+func (r *userRepository) LinkIdentity(ctx context.Context, id primitive.ObjectID, identity models.LinkedIdentity) error {
+	update := bson.M{
+		"$addToSet": bson.M{"identities": identity},
+		"$set":      bson.M{"emailVerified": true, "updatedAt": time.Now()},
+	}
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id primitive.ObjectID, role models.Role) error {
+	update := bson.M{
+		"$set": bson.M{
+			"role":      role,
+			"updatedAt": time.Now(),
+		},
+	}
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id primitive.ObjectID, hashedPassword string) error {
+	return r.set(ctx, id, bson.M{"password": hashedPassword})
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID) error {
+	return r.set(ctx, id, bson.M{"emailVerified": true})
+}
+
+// SetLockedUntil records a login lockout, or clears it when until is nil
+func (r *userRepository) SetLockedUntil(ctx context.Context, id primitive.ObjectID, until *time.Time) error {
+	if until != nil {
+		return r.set(ctx, id, bson.M{"lockedUntil": *until})
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"updatedAt": time.Now()},
+		"$unset": bson.M{"lockedUntil": ""},
+	}
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateProfile sets the given profile fields; empty strings remove optional ones
+func (r *userRepository) UpdateProfile(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	set := bson.M{}
+	unset := bson.M{}
+	for field, value := range fields {
+		if value == "" {
+			unset[field] = ""
+		} else {
+			set[field] = value
+		}
+	}
+	set["updatedAt"] = time.Now()
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// set updates fields of a user, bumping updatedAt
+func (r *userRepository) set(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	fields["updatedAt"] = time.Now()
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
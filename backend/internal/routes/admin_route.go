@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
+	"tirthankarkundu17/pandal-hopping-api/internal/middleware"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRoute defines admin-only endpoints for managing users and curating pandal data
+func AdminRoute(router *gin.RouterGroup, userHandler *handlers.UserHandler, importHandler *handlers.ImportHandler, mergeHandler *handlers.MergeHandler) {
+	r := router.Group("/admin", middleware.AuthMiddleware(), middleware.RequireRole(models.RoleAdmin))
+	{
+		r.PUT("/users/:id/role", userHandler.GrantRole())
+		r.DELETE("/users/:id/role", userHandler.RevokeRole())
+		r.POST("/users/:id/unlock", userHandler.UnlockUser())
+		r.POST("/pandals/import", importHandler.ImportPandals())
+		r.POST("/pandals/:id/merge", mergeHandler.MergePandal())
+	}
+}
@@ -2,15 +2,25 @@ package routes
 
 import (
 	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
+	"tirthankarkundu17/pandal-hopping-api/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRoute(router *gin.RouterGroup, authHandler *handlers.AuthHandler) {
+func AuthRoute(router *gin.RouterGroup, authHandler *handlers.AuthHandler, oidcHandler *handlers.OIDCHandler) {
 	authRoutes := router.Group("/auth")
 	{
 		authRoutes.POST("/register", authHandler.Register)
 		authRoutes.POST("/login", authHandler.Login)
 		authRoutes.POST("/refresh", authHandler.Refresh)
+		authRoutes.POST("/logout", authHandler.Logout)
+		authRoutes.POST("/logout-all", middleware.AuthMiddleware(), authHandler.LogoutAll)
+		authRoutes.POST("/forgot-password", authHandler.ForgotPassword)
+		authRoutes.POST("/reset-password", authHandler.ResetPassword)
+		authRoutes.GET("/verify-email", authHandler.VerifyEmail)
+		authRoutes.POST("/verify-email/resend", middleware.AuthMiddleware(), authHandler.ResendVerification)
+		authRoutes.GET("/oidc", oidcHandler.GetProviders())
+		authRoutes.GET("/oidc/:provider", oidcHandler.Login())
+		authRoutes.GET("/oidc/:provider/callback", oidcHandler.Callback())
 	}
 }
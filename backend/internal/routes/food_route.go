@@ -3,6 +3,7 @@ package routes
 import (
 	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
 	"tirthankarkundu17/pandal-hopping-api/internal/middleware"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,6 +14,6 @@ func FoodRoute(router *gin.RouterGroup, handler *handlers.FoodStopHandler) {
 	{
 		r.GET("/", handler.GetFoodStops())
 		r.GET("/:id", handler.GetFoodStopByID())
-		r.POST("/", handler.CreateFoodStop())
+		r.POST("/", middleware.RequireRole(models.RoleAdmin, models.RoleModerator), handler.CreateFoodStop())
 	}
 }
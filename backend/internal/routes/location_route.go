@@ -12,5 +12,6 @@ func LocationRoute(router *gin.RouterGroup, handler *handlers.LocationHandler) {
 	{
 		// Open endpoint (no AuthMiddleware required) for frontend forms to fetch
 		r.GET("/administrative", handler.GetAdministrativeData())
+		r.GET("/resolve", handler.ResolveLocation())
 	}
 }
@@ -15,6 +15,13 @@ func PandalRoute(router *gin.RouterGroup, handler *handlers.PandalHandler) {
 		pandalRoutes.GET("/", handler.GetAllPandals())
 		pandalRoutes.GET("/pending", handler.GetPendingPandals())
 		pandalRoutes.GET("/districts", handler.GetDistricts())
+		pandalRoutes.GET("/export", handler.ExportPandals())
+		pandalRoutes.GET("/clusters", handler.GetPandalClusters())
+		pandalRoutes.PUT("/:id", handler.ReplacePandal())
+		pandalRoutes.PATCH("/:id", handler.PatchPandal())
+		pandalRoutes.DELETE("/:id", handler.DeletePandal())
 		pandalRoutes.PUT("/:id/approve", handler.ApprovePandal())
+		pandalRoutes.PUT("/:id/reject", handler.RejectPandal())
+		pandalRoutes.PUT("/:id/restore", handler.RestorePandal())
 	}
 }
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
+	"tirthankarkundu17/pandal-hopping-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewRoute defines endpoints for pandal ratings and reviews
+func ReviewRoute(router *gin.RouterGroup, handler *handlers.ReviewHandler) {
+	pandalReviews := router.Group("/pandals/:id/reviews", middleware.AuthMiddleware())
+	{
+		pandalReviews.GET("", handler.GetReviews())
+		pandalReviews.POST("", handler.CreateReview())
+	}
+
+	r := router.Group("/reviews", middleware.AuthMiddleware())
+	{
+		r.PUT("/:id", handler.UpdateReview())
+		r.DELETE("/:id", handler.DeleteReview())
+	}
+}
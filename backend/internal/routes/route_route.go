@@ -3,16 +3,32 @@ package routes
 import (
 	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
 	"tirthankarkundu17/pandal-hopping-api/internal/middleware"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-// RouteRoute defines endpoints for curated pandal routes
+// RouteRoute defines endpoints for curated pandal routes and user itineraries
 func RouteRoute(router *gin.RouterGroup, handler *handlers.RouteHandler) {
 	r := router.Group("/routes", middleware.AuthMiddleware())
 	{
 		r.GET("/", handler.GetRoutes())
+		r.GET("/mine", handler.GetMyRoutes())
+		r.GET("/shared/:token", handler.GetSharedRoute())
+		r.GET("/shared/:token/export", handler.ExportSharedRoute())
 		r.GET("/:id", handler.GetRouteByID())
-		r.POST("/", handler.CreateRoute())
+		r.GET("/:id/export", handler.ExportRoute())
+		r.POST("/plan", handler.PlanRoute())
+		r.POST("/", middleware.RequireRole(models.RoleAdmin, models.RoleModerator), handler.CreateRoute())
+
+		// User-owned itineraries
+		r.POST("/mine", handler.CreatePersonalRoute())
+		r.PATCH("/:id", handler.UpdateRoute())
+		r.POST("/:id/clone", handler.CloneRoute())
+		r.POST("/:id/stops", handler.AddStop())
+		r.PUT("/:id/stops", handler.ReorderStops())
+		r.DELETE("/:id/stops/:pandalId", handler.RemoveStop())
+		r.POST("/:id/share", handler.ShareRoute())
+		r.DELETE("/:id/share", handler.UnshareRoute())
 	}
 }
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
+	"tirthankarkundu17/pandal-hopping-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TileRoute defines the map vector tile endpoint
+func TileRoute(router *gin.RouterGroup, handler *handlers.TileHandler) {
+	r := router.Group("/tiles", middleware.AuthMiddleware())
+	{
+		// The last segment is "<y>.mvt"; the handler strips the extension
+		r.GET("/:z/:x/:y", handler.GetTile())
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
+	"tirthankarkundu17/pandal-hopping-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserRoute defines the endpoints users call to manage their own account
+func UserRoute(router *gin.RouterGroup, userHandler *handlers.UserHandler) {
+	r := router.Group("/users/me", middleware.AuthMiddleware())
+	{
+		r.GET("", userHandler.GetMe())
+		r.PATCH("", userHandler.UpdateMe())
+		r.DELETE("", userHandler.DeleteMe())
+		r.POST("/password", userHandler.ChangePassword())
+	}
+}
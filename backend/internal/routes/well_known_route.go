@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"tirthankarkundu17/pandal-hopping-api/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WellKnownRoute serves discovery documents under /.well-known, outside the API prefix
+func WellKnownRoute(router *gin.RouterGroup, jwksHandler *handlers.JWKSHandler) {
+	r := router.Group("/.well-known")
+	{
+		r.GET("/jwks.json", jwksHandler.GetJWKS())
+	}
+}
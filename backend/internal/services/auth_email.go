@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/auth"
+	"tirthankarkundu17/pandal-hopping-api/internal/lockout"
+	"tirthankarkundu17/pandal-hopping-api/internal/mailer"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// How long emailed links stay valid
+const (
+	passwordResetTTL = time.Hour
+	verifyEmailTTL   = 48 * time.Hour
+)
+
+// resetMailTimeout bounds the background lookup and delivery of a reset link
+const resetMailTimeout = time.Minute
+
+// Reset links are limited per address and per client IP so the endpoint cannot be
+// used to flood someone's inbox. Every request counts, whether or not the address
+// has an account.
+var (
+	ResetAddressPolicy = lockout.Policy{
+		Threshold: 3,
+		BaseDelay: 15 * time.Minute,
+		MaxDelay:  24 * time.Hour,
+		Window:    24 * time.Hour,
+	}
+	ResetClientPolicy = lockout.Policy{
+		Threshold: 20,
+		BaseDelay: 15 * time.Minute,
+		MaxDelay:  24 * time.Hour,
+		Window:    time.Hour,
+	}
+)
+
+var (
+	ErrInvalidEmailToken    = errors.New("invalid or expired link")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+)
+
+// ResetThrottledError is returned while an address or client has asked for too many
+// reset links
+type ResetThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ResetThrottledError) Error() string {
+	return "too many password reset requests, please try again later"
+}
+
+// publicURL reads a base URL from the environment, falling back to def, without a trailing slash
+func publicURL(key, def string) string {
+	base := os.Getenv(key)
+	if base == "" {
+		base = def
+	}
+	return strings.TrimRight(base, "/")
+}
+
+// ForgotPassword emails a password reset link. The account is looked up and the mail
+// sent in the background, so neither the result nor the time taken reveals whether the
+// address has an account; failures are only logged.
+func (s *authService) ForgotPassword(ctx context.Context, email, clientIP string) error {
+	if err := s.throttleResetRequest(ctx, email, clientIP); err != nil {
+		return err
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resetMailTimeout)
+		defer cancel()
+		if err := s.sendResetEmail(ctx, email); err != nil {
+			log.Printf("Error sending password reset email: %v", err)
+		}
+	}()
+	return nil
+}
+
+// throttleResetRequest counts a reset request against the address and the client,
+// returning a ResetThrottledError when either has asked too often
+func (s *authService) throttleResetRequest(ctx context.Context, email, clientIP string) error {
+	reservation, err := s.resetAddresses.Attempt(ctx, "reset:"+strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return err
+	}
+	if reservation.Wait > 0 {
+		return &ResetThrottledError{RetryAfter: reservation.Wait}
+	}
+	if clientIP == "" {
+		return nil
+	}
+	reservation, err = s.resetClients.Attempt(ctx, "reset-ip:"+clientIP)
+	if err != nil {
+		return err
+	}
+	if reservation.Wait > 0 {
+		return &ResetThrottledError{RetryAfter: reservation.Wait}
+	}
+	return nil
+}
+
+// sendResetEmail emails a reset link to the account with the address, if there is one
+func (s *authService) sendResetEmail(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil
+	}
+
+	token, err := s.issueEmailToken(ctx, user, models.PurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	link := publicURL("APP_URL", "http://localhost:8081") + "/reset-password?token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Pandal Hopping password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your Pandal Hopping account. "+
+			"If it was you, open the link below within an hour to choose a new one:\n\n%s\n\n"+
+			"If it wasn't, you can ignore this email and your password stays the same.\n", user.Name, link),
+	})
+}
+
+// ResetPassword sets a new password using an emailed reset token and lifts any login
+// lockout. Every other reset link and every session of the user is revoked, in case
+// the old password leaked.
+func (s *authService) ResetPassword(ctx context.Context, req models.ResetPasswordRequest) error {
+	user, err := s.consumeEmailToken(ctx, req.Token, models.PurposePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, string(hashedPassword)); err != nil {
+		return err
+	}
+
+	// Receiving the reset link proves the user owns the address
+	if !user.EmailVerified {
+		if err := s.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+	if err := promoteBootstrapAdmin(ctx, s.userRepo, user); err != nil {
+		return err
+	}
+
+	// A new password makes earlier failed guesses irrelevant, so lift any lockout
+	if err := s.throttle.reset(ctx, user.Email); err != nil {
+		return err
+	}
+	if user.LockedUntil != nil {
+		if err := s.userRepo.SetLockedUntil(ctx, user.ID, nil); err != nil {
+			return err
+		}
+	}
+
+	if err := s.emailTokenRepo.ConsumeAllForUser(ctx, user.ID.Hex(), models.PurposePasswordReset); err != nil {
+		return err
+	}
+	return s.tokenRepo.RevokeAllForUser(ctx, user.ID.Hex())
+}
+
+// VerifyEmail marks the user's address verified using an emailed verification token
+func (s *authService) VerifyEmail(ctx context.Context, token string) error {
+	user, err := s.consumeEmailToken(ctx, token, models.PurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	if !user.EmailVerified {
+		if err := s.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+	return promoteBootstrapAdmin(ctx, s.userRepo, user)
+}
+
+// promoteBootstrapAdmin makes the BOOTSTRAP_ADMIN_EMAIL account an admin once an emailed
+// link or a provider sign-in has proven the user owns that address, so signing up with
+// it is not enough
+func promoteBootstrapAdmin(ctx context.Context, users repository.UserRepository, user *models.User) error {
+	if !isBootstrapAdmin(user.Email) || user.Role == models.RoleAdmin {
+		return nil
+	}
+	if err := users.UpdateRole(ctx, user.ID, models.RoleAdmin); err != nil {
+		return err
+	}
+	user.Role = models.RoleAdmin
+	log.Printf("Promoted bootstrap admin %s", user.Email)
+	return nil
+}
+
+// ResendVerification emails a fresh verification link to a user who has not verified yet
+func (s *authService) ResendVerification(ctx context.Context, userID string) error {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.FindByID(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	return s.sendVerificationEmail(ctx, user)
+}
+
+// sendVerificationEmail emails the user a link to GET /auth/verify-email
+func (s *authService) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, err := s.issueEmailToken(ctx, user, models.PurposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	link := publicURL("API_URL", "http://localhost:8080/api/v1") + "/auth/verify-email?token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Pandal Hopping email address",
+		Body: fmt.Sprintf("Hi %s,\n\nWelcome to Pandal Hopping! Please confirm your email address by opening the link below "+
+			"within two days:\n\n%s\n\nOnce verified you can help approve new pandals.\n", user.Name, link),
+	})
+}
+
+// issueEmailToken signs a single-use token for purpose and records it so it can be consumed once
+func (s *authService) issueEmailToken(ctx context.Context, user *models.User, purpose models.TokenPurpose, ttl time.Duration) (string, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	jti := primitive.NewObjectID().Hex()
+
+	signed, err := s.keys.Sign(jwt.MapClaims{
+		"sub":     user.ID.Hex(),
+		"jti":     jti,
+		"purpose": string(purpose),
+		"exp":     expiresAt.Unix(),
+	}, auth.KindEmail)
+	if err != nil {
+		return "", err
+	}
+
+	record := models.EmailToken{
+		ID:        jti,
+		UserID:    user.ID.Hex(),
+		Purpose:   purpose,
+		IssuedAt:  now,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.emailTokenRepo.Create(ctx, record); err != nil {
+		return "", err
+	}
+	return signed, nil
+}
+
+// consumeEmailToken verifies a token's signature, expiry and purpose, marks it used and
+// returns its user. Unknown, used, expired or tampered tokens give ErrInvalidEmailToken.
+func (s *authService) consumeEmailToken(ctx context.Context, tokenString string, purpose models.TokenPurpose) (*models.User, error) {
+	claims, err := s.keys.Parse(tokenString, auth.KindEmail)
+	if err != nil {
+		return nil, ErrInvalidEmailToken
+	}
+	userID, _ := claims["sub"].(string)
+	jti, _ := claims["jti"].(string)
+	if claimed, _ := claims["purpose"].(string); claimed != string(purpose) || jti == "" {
+		return nil, ErrInvalidEmailToken
+	}
+
+	stored, err := s.emailTokenRepo.Consume(ctx, jti, purpose)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidEmailToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.UserID != userID {
+		return nil, ErrInvalidEmailToken
+	}
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidEmailToken
+	}
+	user, err := s.userRepo.FindByID(ctx, objID)
+	if err != nil {
+		return nil, ErrInvalidEmailToken
+	}
+	return user, nil
+}
@@ -0,0 +1,195 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/auth"
+	"tirthankarkundu17/pandal-hopping-api/internal/lockout"
+	"tirthankarkundu17/pandal-hopping-api/internal/mailer"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+)
+
+// authFixture is an AuthService over in-memory repositories and a mailer that hands
+// every message to the test
+type authFixture struct {
+	service       services.AuthService
+	keys          *auth.KeyManager
+	users         *memoryUsers
+	refreshTokens *memoryRefreshTokens
+	emailTokens   *memoryEmailTokens
+	attempts      lockout.Store
+	mail          chan mailer.Message
+}
+
+func newAuthFixture(t *testing.T) *authFixture {
+	t.Helper()
+	keys, err := auth.NewKeyManager(auth.Config{DevMode: true, Issuer: "test", Audience: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &authFixture{
+		keys:          keys,
+		users:         newMemoryUsers(),
+		refreshTokens: newMemoryRefreshTokens(),
+		emailTokens:   newMemoryEmailTokens(),
+		attempts:      lockout.NewMemoryStore(),
+		mail:          make(chan mailer.Message, 16),
+	}
+	f.service = services.NewAuthService(f.users, f.refreshTokens, f.emailTokens, channelMailer(f.mail), f.attempts, keys)
+	return f
+}
+
+// channelMailer delivers messages into a channel, so tests can wait for mail sent in
+// the background
+type channelMailer chan<- mailer.Message
+
+func (m channelMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m <- msg
+	return nil
+}
+
+// register signs a user up and returns them with the token from their verification email
+func (f *authFixture) register(t *testing.T, email string) (*models.User, string) {
+	t.Helper()
+	user, err := f.service.Register(context.Background(), models.RegisterRequest{Name: "Test", Email: email, Password: "old-password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, f.nextToken(t)
+}
+
+// nextToken waits for the next email and returns the token from its link
+func (f *authFixture) nextToken(t *testing.T) string {
+	t.Helper()
+	select {
+	case msg := <-f.mail:
+		token := tokenFromLink(msg.Body)
+		if token == "" {
+			t.Fatalf("email has no link with a token:\n%s", msg.Body)
+		}
+		return token
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email was sent")
+		return ""
+	}
+}
+
+// forgotPassword requests a reset link and returns its token
+func (f *authFixture) forgotPassword(t *testing.T, email string) string {
+	t.Helper()
+	if err := f.service.ForgotPassword(context.Background(), email, "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	return f.nextToken(t)
+}
+
+func TestVerifyEmailLinkIsSingleUse(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	user, token := f.register(t, "single@example.com")
+
+	if err := f.service.VerifyEmail(ctx, token); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	stored, _ := f.users.FindByID(ctx, user.ID)
+	if !stored.EmailVerified {
+		t.Fatal("email is not verified after following the link")
+	}
+	if err := f.service.VerifyEmail(ctx, token); !errors.Is(err, services.ErrInvalidEmailToken) {
+		t.Fatalf("second use: got %v, want ErrInvalidEmailToken", err)
+	}
+}
+
+func TestEmailTokenPurposeIsChecked(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	_, verifyToken := f.register(t, "purpose@example.com")
+	resetToken := f.forgotPassword(t, "purpose@example.com")
+
+	err := f.service.ResetPassword(ctx, models.ResetPasswordRequest{Token: verifyToken, Password: "new-password"})
+	if !errors.Is(err, services.ErrInvalidEmailToken) {
+		t.Fatalf("verification token used for a reset: got %v, want ErrInvalidEmailToken", err)
+	}
+	if err := f.service.VerifyEmail(ctx, resetToken); !errors.Is(err, services.ErrInvalidEmailToken) {
+		t.Fatalf("reset token used for verification: got %v, want ErrInvalidEmailToken", err)
+	}
+
+	// Misusing a link must not burn it for its real purpose
+	if err := f.service.VerifyEmail(ctx, verifyToken); err != nil {
+		t.Fatalf("verification after misuse: %v", err)
+	}
+	if err := f.service.ResetPassword(ctx, models.ResetPasswordRequest{Token: resetToken, Password: "new-password"}); err != nil {
+		t.Fatalf("reset after misuse: %v", err)
+	}
+}
+
+func TestExpiredEmailTokenIsRefused(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	user, token := f.register(t, "expired@example.com")
+
+	f.emailTokens.expire()
+	if err := f.service.VerifyEmail(ctx, token); !errors.Is(err, services.ErrInvalidEmailToken) {
+		t.Fatalf("got %v, want ErrInvalidEmailToken", err)
+	}
+	stored, _ := f.users.FindByID(ctx, user.ID)
+	if stored.EmailVerified {
+		t.Fatal("an expired link verified the email")
+	}
+}
+
+func TestResetPasswordRevokesSessionsAndOtherLinks(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	f.register(t, "reset@example.com")
+
+	_, refreshToken, _, err := f.service.Login(ctx, models.LoginRequest{Email: "reset@example.com", Password: "old-password"}, "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	olderLink := f.forgotPassword(t, "reset@example.com")
+	link := f.forgotPassword(t, "reset@example.com")
+
+	if err := f.service.ResetPassword(ctx, models.ResetPasswordRequest{Token: link, Password: "new-password"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, _, err := f.service.Refresh(ctx, models.RefreshRequest{RefreshToken: refreshToken}); err == nil {
+		t.Fatal("a session from before the reset can still refresh")
+	}
+	err = f.service.ResetPassword(ctx, models.ResetPasswordRequest{Token: olderLink, Password: "another-password"})
+	if !errors.Is(err, services.ErrInvalidEmailToken) {
+		t.Fatalf("older reset link: got %v, want ErrInvalidEmailToken", err)
+	}
+	if _, _, _, err := f.service.Login(ctx, models.LoginRequest{Email: "reset@example.com", Password: "old-password"}, "192.0.2.1"); err == nil {
+		t.Fatal("the old password still works")
+	}
+	if _, _, _, err := f.service.Login(ctx, models.LoginRequest{Email: "reset@example.com", Password: "new-password"}, "192.0.2.1"); err != nil {
+		t.Fatalf("new password: %v", err)
+	}
+}
+
+func TestForgotPasswordIsThrottledPerAddress(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+
+	// Unknown and registered addresses get the same answers
+	for _, email := range []string{"nobody@example.com", "throttled@example.com"} {
+		if email == "throttled@example.com" {
+			f.register(t, email)
+		}
+		for i := range services.ResetAddressPolicy.Threshold {
+			if err := f.service.ForgotPassword(ctx, email, "192.0.2.1"); err != nil {
+				t.Fatalf("%s request %d: %v", email, i+1, err)
+			}
+		}
+		var throttled *services.ResetThrottledError
+		if err := f.service.ForgotPassword(ctx, email, "192.0.2.1"); !errors.As(err, &throttled) || throttled.RetryAfter <= 0 {
+			t.Fatalf("%s request past the limit: got %v, want ResetThrottledError", email, err)
+		}
+	}
+}
@@ -3,50 +3,80 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
 	"os"
+	"strings"
 	"time"
 
+	"tirthankarkundu17/pandal-hopping-api/internal/auth"
+	"tirthankarkundu17/pandal-hopping-api/internal/lockout"
+	"tirthankarkundu17/pandal-hopping-api/internal/mailer"
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
 	"tirthankarkundu17/pandal-hopping-api/internal/repository"
 
 	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService interface {
 	Register(ctx context.Context, req models.RegisterRequest) (*models.User, error)
-	Login(ctx context.Context, req models.LoginRequest) (string, string, int64, error)
+	Login(ctx context.Context, req models.LoginRequest, clientIP string) (string, string, int64, error)
 	Refresh(ctx context.Context, req models.RefreshRequest) (string, string, int64, error)
+	Logout(ctx context.Context, req models.RefreshRequest) error
+	LogoutAll(ctx context.Context, userID string) error
+	ForgotPassword(ctx context.Context, email, clientIP string) error
+	ResetPassword(ctx context.Context, req models.ResetPasswordRequest) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, userID string) error
 }
 
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, all sessions from this login have been revoked")
+	ErrEmailInUse          = errors.New("email already in use")
+)
+
 type authService struct {
-	userRepo repository.UserRepository
+	tokenIssuer
+	userRepo       repository.UserRepository
+	emailTokenRepo repository.EmailTokenRepository
+	mailer         mailer.Mailer
+	throttle       *loginThrottle
+	resetAddresses *lockout.Guard
+	resetClients   *lockout.Guard
 }
 
-func NewAuthService(userRepo repository.UserRepository) AuthService {
-	return &authService{userRepo: userRepo}
+// tokenIssuer mints the app's own token pairs, whichever way the user signed in
+type tokenIssuer struct {
+	tokenRepo repository.RefreshTokenRepository
+	keys      *auth.KeyManager
 }
 
-func getJWTAccessSecret() []byte {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "supersecretkey"
+func NewAuthService(userRepo repository.UserRepository, tokenRepo repository.RefreshTokenRepository, emailTokenRepo repository.EmailTokenRepository, mailer mailer.Mailer, attempts lockout.Store, keys *auth.KeyManager) AuthService {
+	return &authService{
+		tokenIssuer:    tokenIssuer{tokenRepo: tokenRepo, keys: keys},
+		userRepo:       userRepo,
+		emailTokenRepo: emailTokenRepo,
+		mailer:         mailer,
+		throttle:       newLoginThrottle(attempts),
+		resetAddresses: lockout.NewGuard(attempts, ResetAddressPolicy),
+		resetClients:   lockout.NewGuard(attempts, ResetClientPolicy),
 	}
-	return []byte(secret)
 }
 
-func getJWTRefreshSecret() []byte {
-	secret := os.Getenv("JWT_REFRESH_SECRET")
-	if secret == "" {
-		secret = "supersecretrefreshkey"
-	}
-	return []byte(secret)
+// isBootstrapAdmin reports whether email matches the BOOTSTRAP_ADMIN_EMAIL setting
+func isBootstrapAdmin(email string) bool {
+	adminEmail := os.Getenv("BOOTSTRAP_ADMIN_EMAIL")
+	return adminEmail != "" && strings.EqualFold(strings.TrimSpace(adminEmail), email)
 }
 
 func (s *authService) Register(ctx context.Context, req models.RegisterRequest) (*models.User, error) {
-	existingUser, _ := s.userRepo.FindByEmail(ctx, req.Email)
+	email := models.NormalizeEmail(req.Email)
+	existingUser, _ := s.userRepo.FindByEmail(ctx, email)
 	if existingUser != nil {
-		return nil, errors.New("email already in use")
+		return nil, ErrEmailInUse
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -54,111 +84,205 @@ func (s *authService) Register(ctx context.Context, req models.RegisterRequest)
 		return nil, err
 	}
 
+	// Even the configured bootstrap admin starts as a plain user; they are promoted
+	// once they prove they own the address (see promoteBootstrapAdmin)
 	user := &models.User{
 		Name:      req.Name,
-		Email:     req.Email,
+		Email:     email,
 		Password:  string(hashedPassword),
+		Role:      models.RoleUser,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
 	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		// Someone signed up with the address at the same moment
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrEmailInUse
+		}
 		return nil, err
 	}
 
+	// The account is usable straight away, so a failed send only costs the user a resend
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		log.Printf("Error sending verification email to user %s: %v", user.ID.Hex(), err)
+	}
+
 	return user, nil
 }
 
-func (s *authService) Login(ctx context.Context, req models.LoginRequest) (string, string, int64, error) {
-	user, err := s.userRepo.FindByEmail(ctx, req.Email)
+// Login checks the credentials, refusing attempts while the account or client IP is locked
+// out. Each attempt is counted towards both lockouts before the password is checked and
+// taken back when it succeeds; an account lockout is also recorded on the user so it
+// survives a restart of an in-memory attempt store.
+func (s *authService) Login(ctx context.Context, req models.LoginRequest, clientIP string) (string, string, int64, error) {
+	attempt, err := s.throttle.begin(ctx, req.Email, clientIP)
 	if err != nil {
-		return "", "", 0, errors.New("invalid email or password")
+		return "", "", 0, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+	user, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err == nil && user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		if err := attempt.cancel(ctx); err != nil {
+			return "", "", 0, err
+		}
+		return "", "", 0, &LoginThrottledError{RetryAfter: time.Until(*user.LockedUntil)}
+	}
+
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		if lockedFor := attempt.lockout(); lockedFor > 0 && user != nil {
+			until := time.Now().Add(lockedFor)
+			if err := s.userRepo.SetLockedUntil(ctx, user.ID, &until); err != nil {
+				return "", "", 0, err
+			}
+		}
 		return "", "", 0, errors.New("invalid email or password")
 	}
 
-	// Access Token: 1 hour expiry
-	accessTokenExp := time.Now().Add(time.Hour * 1)
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID.Hex(),
-		"exp": accessTokenExp.Unix(),
-	})
+	if err := attempt.succeeded(ctx); err != nil {
+		return "", "", 0, err
+	}
+	if user.LockedUntil != nil {
+		if err := s.userRepo.SetLockedUntil(ctx, user.ID, nil); err != nil {
+			return "", "", 0, err
+		}
+	}
+
+	// Every login starts a new refresh token family
+	return s.issueTokens(ctx, user, primitive.NewObjectID().Hex(), req.Device, time.Now())
+}
 
-	accessTokenString, err := accessToken.SignedString(getJWTAccessSecret())
+// Refresh rotates a refresh token: the presented token is consumed and a new pair is issued
+// in the same family. Presenting an already used token is treated as theft and revokes the family.
+func (s *authService) Refresh(ctx context.Context, req models.RefreshRequest) (string, string, int64, error) {
+	claims, err := s.parseRefreshToken(req.RefreshToken)
 	if err != nil {
 		return "", "", 0, err
 	}
 
-	// Refresh Token: 7 days expiry
-	refreshTokenExp := time.Now().Add(time.Hour * 24 * 7)
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID.Hex(),
-		"exp": refreshTokenExp.Unix(),
-	})
+	stored, err := s.tokenRepo.FindByID(ctx, claims.jti)
+	if err != nil || stored.UserID != claims.userID {
+		return "", "", 0, ErrInvalidRefreshToken
+	}
 
-	refreshTokenString, err := refreshToken.SignedString(getJWTRefreshSecret())
+	used, err := s.tokenRepo.MarkUsed(ctx, stored.ID)
 	if err != nil {
 		return "", "", 0, err
 	}
+	if !used {
+		// Replay of a rotated or revoked token: burn the whole family
+		if err := s.tokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return "", "", 0, err
+		}
+		return "", "", 0, ErrRefreshTokenReused
+	}
 
-	expiresIn := int64(time.Until(accessTokenExp).Seconds())
+	// Make sure the user still exists and pick up their current role
+	userID, err := primitive.ObjectIDFromHex(claims.userID)
+	if err != nil {
+		return "", "", 0, ErrInvalidRefreshToken
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		_ = s.tokenRepo.RevokeFamily(ctx, stored.FamilyID)
+		return "", "", 0, ErrInvalidRefreshToken
+	}
 
-	return accessTokenString, refreshTokenString, expiresIn, nil
+	return s.issueTokens(ctx, user, stored.FamilyID, stored.Device, time.Time{})
 }
 
-func (s *authService) Refresh(ctx context.Context, req models.RefreshRequest) (string, string, int64, error) {
-	token, err := jwt.Parse(req.RefreshToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return getJWTRefreshSecret(), nil
-	})
+// Logout revokes the refresh token family the presented token belongs to
+func (s *authService) Logout(ctx context.Context, req models.RefreshRequest) error {
+	claims, err := s.parseRefreshToken(req.RefreshToken)
+	if err != nil {
+		return err
+	}
 
-	if err != nil || !token.Valid {
-		return "", "", 0, errors.New("invalid or expired refresh token")
+	stored, err := s.tokenRepo.FindByID(ctx, claims.jti)
+	if err != nil || stored.UserID != claims.userID {
+		return ErrInvalidRefreshToken
 	}
+	return s.tokenRepo.RevokeFamily(ctx, stored.FamilyID)
+}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", "", 0, errors.New("invalid refresh token claims")
+// LogoutAll revokes every refresh token of the user on all devices
+func (s *authService) LogoutAll(ctx context.Context, userID string) error {
+	return s.tokenRepo.RevokeAllForUser(ctx, userID)
+}
+
+// refreshClaims are the fields of a verified refresh token the service relies on
+type refreshClaims struct {
+	userID string
+	jti    string
+}
+
+// parseRefreshToken verifies a refresh token's signature, expiry, issuer and audience and extracts its claims
+func (s *authService) parseRefreshToken(tokenString string) (*refreshClaims, error) {
+	claims, err := s.keys.Parse(tokenString, auth.KindRefresh)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
 	}
 
 	userIDHex, ok := claims["sub"].(string)
 	if !ok {
-		return "", "", 0, errors.New("invalid subject in refresh token")
+		return nil, errors.New("invalid subject in refresh token")
 	}
 
-	// Assuming we still want to generate new access and refresh tokens here
-	// First let's do a quick safety check, we COULD verify if the user still exists in repo here
-	// for enhanced security, although it costs a db trip. For now omit or include it.
-	// We will just generate right away.
+	// Tokens minted before rotation existed have no jti and can no longer be used
+	jti, ok := claims["jti"].(string)
+	if !ok || jti == "" {
+		return nil, ErrInvalidRefreshToken
+	}
 
-	// Access Token: 1 hour expiry
-	accessTokenExp := time.Now().Add(time.Hour * 1)
-	newAccessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": userIDHex,
-		"exp": accessTokenExp.Unix(),
-	})
+	return &refreshClaims{userID: userIDHex, jti: jti}, nil
+}
 
-	accessTokenString, err := newAccessToken.SignedString(getJWTAccessSecret())
+// issueTokens signs a fresh access/refresh token pair for the user and persists the
+// refresh token under familyID.
+// The access token embeds the user's role so middleware can authorise without a DB trip.
+func (s *tokenIssuer) issueTokens(ctx context.Context, user *models.User, familyID, device string, authTime time.Time) (string, string, int64, error) {
+	now := time.Now()
+
+	// Access Token: 1 hour expiry
+	accessTokenExp := now.Add(time.Hour * 1)
+	accessClaims := jwt.MapClaims{
+		"sub":  user.ID.Hex(),
+		"role": string(user.EffectiveRole()),
+		"exp":  accessTokenExp.Unix(),
+	}
+	// Only tokens from a sign-in say when it happened; refreshed ones prove nothing recent
+	if !authTime.IsZero() {
+		accessClaims["auth_time"] = authTime.Unix()
+	}
+	accessTokenString, err := s.keys.Sign(accessClaims, auth.KindAccess)
 	if err != nil {
 		return "", "", 0, err
 	}
 
 	// Refresh Token: 7 days expiry
-	refreshTokenExp := time.Now().Add(time.Hour * 24 * 7)
-	newRefreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": userIDHex,
+	refreshTokenExp := now.Add(time.Hour * 24 * 7)
+	jti := primitive.NewObjectID().Hex()
+	refreshTokenString, err := s.keys.Sign(jwt.MapClaims{
+		"sub": user.ID.Hex(),
+		"jti": jti,
 		"exp": refreshTokenExp.Unix(),
-	})
-
-	refreshTokenString, err := newRefreshToken.SignedString(getJWTRefreshSecret())
+	}, auth.KindRefresh)
 	if err != nil {
 		return "", "", 0, err
 	}
 
+	record := models.RefreshToken{
+		ID:        jti,
+		FamilyID:  familyID,
+		UserID:    user.ID.Hex(),
+		Device:    device,
+		IssuedAt:  now,
+		ExpiresAt: refreshTokenExp,
+	}
+	if err := s.tokenRepo.Create(ctx, record); err != nil {
+		return "", "", 0, err
+	}
+
 	expiresIn := int64(time.Until(accessTokenExp).Seconds())
 
 	return accessTokenString, refreshTokenString, expiresIn, nil
@@ -0,0 +1,75 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+)
+
+// TestConcurrentLoginsCannotOutrunLockout fires a burst of wrong passwords at once: no
+// more of them may be checked than the lockout threshold allows
+func TestConcurrentLoginsCannotOutrunLockout(t *testing.T) {
+	f := newAuthFixture(t)
+	f.register(t, "burst@example.com")
+
+	const guesses = 40
+	var (
+		wg                 sync.WaitGroup
+		mu                 sync.Mutex
+		checked, throttled int
+	)
+	for i := range guesses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := models.LoginRequest{Email: "burst@example.com", Password: "wrong-password"}
+			// Spread over a few addresses so only the account lockout applies
+			_, _, _, err := f.service.Login(context.Background(), req, fmt.Sprintf("192.0.2.%d", i%5))
+
+			var throttleErr *services.LoginThrottledError
+			mu.Lock()
+			defer mu.Unlock()
+			if errors.As(err, &throttleErr) {
+				throttled++
+			} else {
+				checked++
+			}
+		}()
+	}
+	wg.Wait()
+
+	if checked > services.AccountLockoutPolicy.Threshold {
+		t.Fatalf("%d passwords were checked, want at most %d", checked, services.AccountLockoutPolicy.Threshold)
+	}
+	if checked+throttled != guesses {
+		t.Fatalf("checked %d and throttled %d of %d guesses", checked, throttled, guesses)
+	}
+}
+
+// TestSuccessfulLoginsDoNotLockTheClient brings a client to one failure short of its
+// lockout: its successful logins are taken back from the count, so they keep working
+func TestSuccessfulLoginsDoNotLockTheClient(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	f.register(t, "shared@example.com")
+
+	const ip = "198.51.100.7"
+	for i := range services.ClientLockoutPolicy.Threshold - 1 {
+		req := models.LoginRequest{Email: fmt.Sprintf("unknown%d@example.com", i), Password: "guess"}
+		if _, _, _, err := f.service.Login(ctx, req, ip); err == nil {
+			t.Fatal("an unknown account logged in")
+		}
+	}
+
+	req := models.LoginRequest{Email: "shared@example.com", Password: "old-password"}
+	for i := range 3 {
+		if _, _, _, err := f.service.Login(ctx, req, ip); err != nil {
+			t.Fatalf("login %d: %v", i+1, err)
+		}
+	}
+}
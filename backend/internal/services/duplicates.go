@@ -0,0 +1,110 @@
+package services
+
+import (
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/names"
+)
+
+const (
+	// duplicateRadiusMeters is how close an existing pandal of the same name must be
+	// pinned to count as a duplicate
+	duplicateRadiusMeters = 250.0
+
+	// duplicateCellDegrees is the grid cell size of a duplicateIndex. A cell spans more
+	// than duplicateRadiusMeters both ways anywhere pandals are pinned (below 60°), so
+	// every duplicate of a pin lies in its cell or one of the eight around it.
+	duplicateCellDegrees = 0.005
+)
+
+// duplicateCandidateFilter matches the pandals a new one may duplicate: live ones that
+// were not voted out. A rejected pandal says nothing about whether the place exists.
+func duplicateCandidateFilter() bson.M {
+	return bson.M{
+		"status":    bson.M{"$ne": models.StatusRejected},
+		"deletedAt": bson.M{"$exists": false},
+	}
+}
+
+// matchDuplicate reports whether existing looks like the same pandal as pandal: both
+// are pinned within duplicateRadiusMeters of each other and their names score at
+// least names.MatchThreshold. It returns the distance between the pins and the score.
+// A missing pin on either side never matches, so a same-named pandal elsewhere is not
+// taken for this one on the strength of the name alone.
+func matchDuplicate(pandal, existing models.Pandal) (distance, score float64, ok bool) {
+	a, okA := geo.FromCoordinates(pandal.Location.Coordinates)
+	b, okB := geo.FromCoordinates(existing.Location.Coordinates)
+	if !okA || !okB {
+		return 0, 0, false
+	}
+	distance = geo.Haversine(a, b)
+	if distance > duplicateRadiusMeters {
+		return 0, 0, false
+	}
+	score = names.Similarity(pandal.Name, existing.Name)
+	if score < names.MatchThreshold {
+		return 0, 0, false
+	}
+	return distance, score, true
+}
+
+// duplicateIndex buckets pinned items by grid cell so a pin is only compared with
+// items pinned around it
+type duplicateIndex[T any] struct {
+	pandal func(T) models.Pandal
+	cells  map[[2]int64][]T
+}
+
+func newDuplicateIndex[T any](pandal func(T) models.Pandal) *duplicateIndex[T] {
+	return &duplicateIndex[T]{pandal: pandal, cells: make(map[[2]int64][]T)}
+}
+
+// add indexes item; items without a pin can never match and are left out
+func (x *duplicateIndex[T]) add(item T) {
+	if cell, ok := duplicateCell(x.pandal(item).Location); ok {
+		x.cells[cell] = append(x.cells[cell], item)
+	}
+}
+
+// best returns the indexed item that best matches pandal: the highest name score,
+// the nearest pin among equal scores
+func (x *duplicateIndex[T]) best(pandal models.Pandal) (T, bool) {
+	var found T
+	cell, ok := duplicateCell(pandal.Location)
+	if !ok {
+		return found, false
+	}
+
+	bestScore, bestDistance := 0.0, 0.0
+	matched := false
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for _, item := range x.cells[[2]int64{cell[0] + dx, cell[1] + dy}] {
+				distance, score, ok := matchDuplicate(pandal, x.pandal(item))
+				if !ok {
+					continue
+				}
+				if !matched || score > bestScore || (score == bestScore && distance < bestDistance) {
+					found, bestScore, bestDistance, matched = item, score, distance, true
+				}
+			}
+		}
+	}
+	return found, matched
+}
+
+// duplicateCell returns the grid cell of a pin
+func duplicateCell(location models.Location) ([2]int64, bool) {
+	p, ok := geo.FromCoordinates(location.Coordinates)
+	if !ok {
+		return [2]int64{}, false
+	}
+	return [2]int64{
+		int64(math.Floor(p.Lng / duplicateCellDegrees)),
+		int64(math.Floor(p.Lat / duplicateCellDegrees)),
+	}, true
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// bagbazar is where the pandals below are pinned
+var bagbazar = geo.Point{Lng: 88.3697, Lat: 22.6010}
+
+// pandalEastOf returns a pandal called name pinned meters due east of bagbazar
+func pandalEastOf(name string, meters float64) models.Pandal {
+	// A degree of longitude spans cos(lat) times a degree of latitude
+	lng := bagbazar.Lng + meters/(geo.EarthRadiusMeters*math.Pi/180*math.Cos(bagbazar.Lat*math.Pi/180))
+	return models.Pandal{
+		Name:     name,
+		Status:   models.StatusApproved,
+		Location: models.Location{Type: "Point", Coordinates: []float64{lng, bagbazar.Lat}},
+	}
+}
+
+func TestMatchDuplicate(t *testing.T) {
+	pinless := func(name string) models.Pandal { return models.Pandal{Name: name, Status: models.StatusApproved} }
+
+	tests := []struct {
+		name             string
+		pandal, existing models.Pandal
+		match            bool
+	}{
+		{"transliterated spelling, same pin", pandalEastOf("Bagbazar Sarbojanin Durgotsab", 0), pandalEastOf("Bagbazar Sarbajanin Durgotsav", 0), true},
+		{"transliterated spelling, across the street", pandalEastOf("Bagbazar Sarbojanin", 0), pandalEastOf("Bag Bazar Sarvajanin", 80), true},
+		{"just inside the radius", pandalEastOf("Bagbazar Sarbojanin", 0), pandalEastOf("Bagbazar Sarbojanin", duplicateRadiusMeters-5), true},
+		{"just outside the radius", pandalEastOf("Bagbazar Sarbojanin", 0), pandalEastOf("Bagbazar Sarbojanin", duplicateRadiusMeters+5), false},
+		{"same name across town", pandalEastOf("Bagbazar Sarbojanin", 0), pandalEastOf("Bagbazar Sarbojanin", 5000), false},
+		{"different pandal next door", pandalEastOf("Bagbazar Sarbojanin", 0), pandalEastOf("Kumartuli Sarbojanin", 50), false},
+		{"new pandal has no pin", pinless("Bagbazar Sarbojanin"), pandalEastOf("Bagbazar Sarbojanin", 0), false},
+		{"existing pandal has no pin", pandalEastOf("Bagbazar Sarbojanin", 0), pinless("Bagbazar Sarbojanin"), false},
+		{"neither has a pin", pinless("Bagbazar Sarbojanin"), pinless("Bagbazar Sarbojanin"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance, score, ok := matchDuplicate(tt.pandal, tt.existing)
+			if ok != tt.match {
+				t.Fatalf("got match %v (%.0f m, score %.2f), want %v", ok, distance, score, tt.match)
+			}
+			if ok && distance > duplicateRadiusMeters {
+				t.Fatalf("matched at %.0f m, past the %.0f m radius", distance, duplicateRadiusMeters)
+			}
+		})
+	}
+}
+
+// isCandidate evaluates duplicateCandidateFilter against pandal the way MongoDB would
+func isCandidate(t *testing.T, filter bson.M, pandal models.Pandal) bool {
+	t.Helper()
+	for field, cond := range filter {
+		switch field {
+		case "status":
+			if pandal.Status == cond.(bson.M)["$ne"] {
+				return false
+			}
+		case "deletedAt":
+			if (pandal.DeletedAt != nil) != cond.(bson.M)["$exists"] {
+				return false
+			}
+		default:
+			t.Fatalf("unexpected condition on %s", field)
+		}
+	}
+	return true
+}
+
+func TestDuplicateCandidateFilter(t *testing.T) {
+	deletedAt := time.Now()
+	withStatus := func(status models.PandalStatus) models.Pandal {
+		pandal := pandalEastOf("Bagbazar Sarbojanin", 0)
+		pandal.Status = status
+		return pandal
+	}
+	deleted := withStatus(models.StatusApproved)
+	deleted.DeletedAt = &deletedAt
+
+	tests := []struct {
+		name      string
+		pandal    models.Pandal
+		candidate bool
+	}{
+		{"approved", withStatus(models.StatusApproved), true},
+		{"pending", withStatus(models.StatusPending), true},
+		{"rejected", withStatus(models.StatusRejected), false},
+		{"deleted", deleted, false},
+	}
+	for _, tt := range tests {
+		if got := isCandidate(t, duplicateCandidateFilter(), tt.pandal); got != tt.candidate {
+			t.Errorf("%s pandal: candidate %v, want %v", tt.name, got, tt.candidate)
+		}
+	}
+}
+
+func TestDuplicateIndexBest(t *testing.T) {
+	index := newDuplicateIndex(func(pandal models.Pandal) models.Pandal { return pandal })
+	farther := pandalEastOf("Bagbazar Sarbojanin", 200)
+	nearer := pandalEastOf("Bagbazar Sarbojanin", 100)
+	closerButWorseName := pandalEastOf("Bagbazer Sarbojanin", 10)
+	for _, pandal := range []models.Pandal{farther, closerButWorseName, nearer, pandalEastOf("Bagbazar Sarbojanin", 400), {Name: "Bagbazar Sarbojanin"}} {
+		index.add(pandal)
+	}
+
+	if _, score, ok := matchDuplicate(pandalEastOf("Bagbazar Sarbajanin", 0), closerButWorseName); !ok || score == 1 {
+		t.Fatalf("the misspelt pandal should match with a lower score, got %v at %.2f", ok, score)
+	}
+	best, ok := index.best(pandalEastOf("Bagbazar Sarbajanin", 0))
+	if !ok {
+		t.Fatal("no match found")
+	}
+	if best.Location.Coordinates[0] != nearer.Location.Coordinates[0] {
+		t.Fatalf("got %q pinned at %v, want the nearest pandal with the best name", best.Name, best.Location.Coordinates)
+	}
+
+	if _, ok := index.best(models.Pandal{Name: "Bagbazar Sarbojanin"}); ok {
+		t.Fatal("a pandal without a pin matched")
+	}
+	if _, ok := index.best(pandalEastOf("Bagbazar Sarbojanin", 1000)); ok {
+		t.Fatal("matched a pandal 600 m away")
+	}
+}
+
+func TestDuplicateIndexLooksAcrossCellEdges(t *testing.T) {
+	index := newDuplicateIndex(func(pandal models.Pandal) models.Pandal { return pandal })
+	// Two pins 100 m apart either side of a cell edge
+	edge := math.Ceil(bagbazar.Lng/duplicateCellDegrees) * duplicateCellDegrees
+	west := models.Pandal{Name: "Bagbazar Sarbojanin", Location: models.Location{Type: "Point", Coordinates: []float64{edge - 0.0005, bagbazar.Lat}}}
+	east := models.Pandal{Name: "Bagbazar Sarbajanin", Location: models.Location{Type: "Point", Coordinates: []float64{edge + 0.0005, bagbazar.Lat}}}
+	index.add(west)
+
+	if _, ok := index.best(east); !ok {
+		t.Fatal("a duplicate in the neighbouring cell was missed")
+	}
+}
@@ -0,0 +1,447 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/repository"
+)
+
+// memoryUsers is an in-memory repository.UserRepository
+type memoryUsers struct {
+	mu    sync.Mutex
+	users map[primitive.ObjectID]models.User
+}
+
+func newMemoryUsers() *memoryUsers {
+	return &memoryUsers{users: make(map[primitive.ObjectID]models.User)}
+}
+
+// CreateUser refuses a second user with the same email, whatever its case, like the
+// unique email index
+func (r *memoryUsers) CreateUser(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if strings.EqualFold(existing.Email, user.Email) {
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key"}}}
+		}
+	}
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *memoryUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return strings.EqualFold(u.Email, strings.TrimSpace(email)) })
+}
+
+func (r *memoryUsers) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.ID == id })
+}
+
+func (r *memoryUsers) FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	return r.find(func(u models.User) bool {
+		for _, identity := range u.Identities {
+			if identity.Provider == provider && identity.Subject == subject {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *memoryUsers) LinkIdentity(ctx context.Context, id primitive.ObjectID, identity models.LinkedIdentity) error {
+	return r.update(id, func(u *models.User) {
+		u.Identities = append(u.Identities, identity)
+		u.EmailVerified = true
+	})
+}
+
+func (r *memoryUsers) UpdateRole(ctx context.Context, id primitive.ObjectID, role models.Role) error {
+	return r.update(id, func(u *models.User) { u.Role = role })
+}
+
+func (r *memoryUsers) UpdatePassword(ctx context.Context, id primitive.ObjectID, hashedPassword string) error {
+	return r.update(id, func(u *models.User) { u.Password = hashedPassword })
+}
+
+func (r *memoryUsers) MarkEmailVerified(ctx context.Context, id primitive.ObjectID) error {
+	return r.update(id, func(u *models.User) { u.EmailVerified = true })
+}
+
+func (r *memoryUsers) SetLockedUntil(ctx context.Context, id primitive.ObjectID, until *time.Time) error {
+	return r.update(id, func(u *models.User) { u.LockedUntil = until })
+}
+
+// UpdateProfile round-trips the user through BSON so fields are named as in MongoDB
+func (r *memoryUsers) UpdateProfile(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	var err error
+	updateErr := r.update(id, func(u *models.User) {
+		var raw []byte
+		if raw, err = bson.Marshal(u); err != nil {
+			return
+		}
+		doc := bson.M{}
+		if err = bson.Unmarshal(raw, &doc); err != nil {
+			return
+		}
+		// Like the MongoDB repository, empty strings clear a field
+		for key, value := range fields {
+			if value == "" {
+				delete(doc, key)
+			} else {
+				doc[key] = value
+			}
+		}
+		if raw, err = bson.Marshal(doc); err != nil {
+			return
+		}
+		var updated models.User
+		if err = bson.Unmarshal(raw, &updated); err == nil {
+			*u = updated
+		}
+	})
+	if updateErr != nil {
+		return updateErr
+	}
+	return err
+}
+
+func (r *memoryUsers) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return repository.ErrUserNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+func (r *memoryUsers) find(match func(models.User) bool) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (r *memoryUsers) update(id primitive.ObjectID, change func(*models.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	change(&user)
+	r.users[id] = user
+	return nil
+}
+
+// memoryEmailTokens is an in-memory repository.EmailTokenRepository
+type memoryEmailTokens struct {
+	mu     sync.Mutex
+	tokens map[string]models.EmailToken
+}
+
+func newMemoryEmailTokens() *memoryEmailTokens {
+	return &memoryEmailTokens{tokens: make(map[string]models.EmailToken)}
+}
+
+func (r *memoryEmailTokens) Create(ctx context.Context, token models.EmailToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.ID] = token
+	return nil
+}
+
+func (r *memoryEmailTokens) Consume(ctx context.Context, jti string, purpose models.TokenPurpose) (*models.EmailToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	token, ok := r.tokens[jti]
+	if !ok || token.Purpose != purpose || token.UsedAt != nil || !token.ExpiresAt.After(now) {
+		return nil, mongo.ErrNoDocuments
+	}
+	token.UsedAt = &now
+	r.tokens[jti] = token
+	return &token, nil
+}
+
+func (r *memoryEmailTokens) ConsumeAllForUser(ctx context.Context, userID string, purpose models.TokenPurpose) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &now
+			r.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+// expire moves every stored token's expiry into the past
+func (r *memoryEmailTokens) expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, token := range r.tokens {
+		token.ExpiresAt = time.Now().Add(-time.Minute)
+		r.tokens[id] = token
+	}
+}
+
+// memoryRefreshTokens is an in-memory repository.RefreshTokenRepository
+type memoryRefreshTokens struct {
+	mu     sync.Mutex
+	tokens map[string]models.RefreshToken
+}
+
+func newMemoryRefreshTokens() *memoryRefreshTokens {
+	return &memoryRefreshTokens{tokens: make(map[string]models.RefreshToken)}
+}
+
+func (r *memoryRefreshTokens) Create(ctx context.Context, token models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.ID] = token
+	return nil
+}
+
+func (r *memoryRefreshTokens) FindByID(ctx context.Context, jti string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[jti]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &token, nil
+}
+
+func (r *memoryRefreshTokens) MarkUsed(ctx context.Context, jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[jti]
+	if !ok || token.UsedAt != nil || token.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	r.tokens[jti] = token
+	return true, nil
+}
+
+func (r *memoryRefreshTokens) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.revoke(func(t models.RefreshToken) bool { return t.FamilyID == familyID })
+}
+
+func (r *memoryRefreshTokens) RevokeAllForUser(ctx context.Context, userID string) error {
+	return r.revoke(func(t models.RefreshToken) bool { return t.UserID == userID })
+}
+
+func (r *memoryRefreshTokens) revoke(match func(models.RefreshToken) bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, token := range r.tokens {
+		if match(token) && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			r.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+// memoryOIDCSessions is an in-memory repository.OIDCSessionRepository
+type memoryOIDCSessions struct {
+	mu       sync.Mutex
+	sessions map[string]models.OIDCSession
+}
+
+func newMemoryOIDCSessions() *memoryOIDCSessions {
+	return &memoryOIDCSessions{sessions: make(map[string]models.OIDCSession)}
+}
+
+func (r *memoryOIDCSessions) Create(ctx context.Context, session models.OIDCSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *memoryOIDCSessions) Consume(ctx context.Context, state, provider string) (*models.OIDCSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[state]
+	if !ok || session.Provider != provider || !session.ExpiresAt.After(time.Now()) {
+		return nil, mongo.ErrNoDocuments
+	}
+	delete(r.sessions, state)
+	return &session, nil
+}
+
+// tokenFromLink pulls the token query parameter out of an emailed link
+func tokenFromLink(body string) string {
+	_, rest, found := strings.Cut(body, "token=")
+	if !found {
+		return ""
+	}
+	token, _, _ := strings.Cut(rest, "\n")
+	return strings.TrimSpace(token)
+}
+
+// memoryPandals serves a fixed set of pandals. Only the lookups the tests reach are
+// implemented; the embedded interface panics on anything else.
+type memoryPandals struct {
+	repository.PandalRepository
+	pandals  []models.Pandal
+	upserted []models.Pandal
+
+	// interleave, when set, runs before each conditional write, standing in for a
+	// request that lands between the service's read and its write
+	interleave func(r *memoryPandals)
+}
+
+func (r *memoryPandals) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Pandal, error) {
+	for _, pandal := range r.pandals {
+		if pandal.ID == id {
+			return &pandal, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// FindOneAndUpdate applies update to the first pandal matching filter. It understands
+// equality and $exists conditions, $set and $unset updates, and the $set pipeline
+// stages the pandal service builds from $literal, $cond, $eq and field paths.
+func (r *memoryPandals) FindOneAndUpdate(ctx context.Context, filter bson.M, update interface{}) (*models.Pandal, error) {
+	if r.interleave != nil {
+		r.interleave(r)
+	}
+	for i, pandal := range r.pandals {
+		doc := toDoc(pandal)
+		if !matchesDoc(doc, toDoc(filter)) {
+			continue
+		}
+		switch update := update.(type) {
+		case mongo.Pipeline:
+			for _, stage := range update {
+				if stage[0].Key != "$set" {
+					panic("unsupported stage " + stage[0].Key)
+				}
+				set := bson.M{}
+				for field, expr := range stage[0].Value.(bson.M) {
+					set[field] = evalExpr(doc, expr)
+				}
+				for field, value := range toDoc(set) {
+					doc[field] = value
+				}
+			}
+		case bson.M:
+			for field, value := range toDoc(update["$set"]) {
+				doc[field] = value
+			}
+			for field := range toDoc(update["$unset"]) {
+				delete(doc, field)
+			}
+		default:
+			panic("unsupported update")
+		}
+
+		var updated models.Pandal
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		if err := bson.Unmarshal(raw, &updated); err != nil {
+			return nil, err
+		}
+		r.pandals[i] = updated
+		return &updated, nil
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+// toDoc round trips v through BSON, so documents and filters compare as stored values
+func toDoc(v interface{}) bson.M {
+	doc := bson.M{}
+	if v == nil {
+		return doc
+	}
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+func matchesDoc(doc, filter bson.M) bool {
+	for field, cond := range filter {
+		value, present := doc[field]
+		if ops, ok := cond.(bson.M); ok {
+			if exists, ok := ops["$exists"]; ok && present != exists {
+				return false
+			}
+			continue
+		}
+		if !present || !reflect.DeepEqual(value, cond) {
+			return false
+		}
+	}
+	return true
+}
+
+// evalExpr evaluates an aggregation expression against doc
+func evalExpr(doc bson.M, expr interface{}) interface{} {
+	switch expr := expr.(type) {
+	case string:
+		if strings.HasPrefix(expr, "$") {
+			return doc[strings.TrimPrefix(expr, "$")]
+		}
+		return expr
+	case bson.M:
+		if value, ok := expr["$literal"]; ok {
+			return value
+		}
+		if args, ok := expr["$cond"].(bson.A); ok {
+			if evalExpr(doc, args[0]) == true {
+				return evalExpr(doc, args[1])
+			}
+			return evalExpr(doc, args[2])
+		}
+		if args, ok := expr["$eq"].(bson.A); ok {
+			a, b := toDoc(bson.M{"v": evalExpr(doc, args[0])}), toDoc(bson.M{"v": evalExpr(doc, args[1])})
+			return reflect.DeepEqual(a, b)
+		}
+		panic(fmt.Sprintf("unsupported expression %v", expr))
+	}
+	return expr
+}
+
+// FindAll ignores the filter; callers such as findPlannablePandals re-check what they get
+func (r *memoryPandals) FindAll(ctx context.Context, filter bson.M) ([]models.Pandal, error) {
+	return r.pandals, nil
+}
+
+// BulkUpsert records what would be written
+func (r *memoryPandals) BulkUpsert(ctx context.Context, pandals []models.Pandal) (*mongo.BulkWriteResult, error) {
+	r.upserted = append(r.upserted, pandals...)
+	return &mongo.BulkWriteResult{}, nil
+}
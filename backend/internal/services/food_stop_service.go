@@ -7,52 +7,65 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
 	"tirthankarkundu17/pandal-hopping-api/internal/repository"
 )
 
 // FoodStopService defines business logic for food stops
 type FoodStopService interface {
 	CreateFoodStop(ctx context.Context, stop models.FoodStop) (*models.FoodStop, error)
-	GetFoodStops(ctx context.Context, lng, lat, radius float64, hasCoords bool) ([]models.FoodStop, error)
+	GetFoodStops(ctx context.Context, near *Proximity, page pagination.Params) ([]models.FoodStop, string, error)
+	GetFoodStopsInArea(ctx context.Context, area geo.Polygon, limit int) ([]models.FoodStop, bool, error)
 	GetFoodStopByID(ctx context.Context, id primitive.ObjectID) (*models.FoodStop, error)
 }
 
 type foodStopService struct {
-	repo repository.FoodStopRepository
+	repo      repository.FoodStopRepository
+	tileCache TileInvalidator
 }
 
 // NewFoodStopService creates a new service instance
-func NewFoodStopService(repo repository.FoodStopRepository) FoodStopService {
-	return &foodStopService{repo: repo}
+func NewFoodStopService(repo repository.FoodStopRepository, tileCache TileInvalidator) FoodStopService {
+	return &foodStopService{repo: repo, tileCache: tileCache}
 }
 
 func (s *foodStopService) CreateFoodStop(ctx context.Context, stop models.FoodStop) (*models.FoodStop, error) {
 	stop.ID = primitive.NewObjectID()
+	// Proximity results are computed per search and never stored
+	stop.DistanceMeters = nil
 	_, err := s.repo.Create(ctx, stop)
 	if err != nil {
 		return nil, err
 	}
+	invalidateTiles(s.tileCache, stop.Location)
 	return &stop, nil
 }
 
-func (s *foodStopService) GetFoodStops(ctx context.Context, lng, lat, radius float64, hasCoords bool) ([]models.FoodStop, error) {
-	filter := bson.M{}
-	if hasCoords {
-		if radius <= 0 {
-			radius = 5000.0
-		}
-		filter["location"] = bson.M{
-			"$nearSphere": bson.M{
-				"$geometry": bson.M{
-					"type":        "Point",
-					"coordinates": []float64{lng, lat},
-				},
-				"$maxDistance": radius,
-			},
-		}
+// GetFoodStops returns one page of food stops, nearest first with distances when near is set
+func (s *foodStopService) GetFoodStops(ctx context.Context, near *Proximity, page pagination.Params) ([]models.FoodStop, string, error) {
+	page, err := page.WithDefaultSort(near != nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if near == nil {
+		return s.repo.FindPage(ctx, bson.M{}, page)
+	}
+
+	stops, nextCursor, err := s.repo.FindNearPage(ctx, near.Origin, near.MinRadius, near.maxMeters(), bson.M{}, page)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range stops {
+		stops[i].WalkingMinutes, stops[i].BearingDegrees = proximityDetails(near.Origin, stops[i].Location, stops[i].DistanceMeters)
 	}
-	return s.repo.FindAll(ctx, filter)
+	return stops, nextCursor, nil
+}
+
+// GetFoodStopsInArea returns the food stops inside area, capped at limit (see AreaLimit)
+func (s *foodStopService) GetFoodStopsInArea(ctx context.Context, area geo.Polygon, limit int) ([]models.FoodStop, bool, error) {
+	return s.repo.FindCapped(ctx, bson.M{"location": withinFilter(area)}, AreaLimit(limit))
 }
 
 func (s *foodStopService) GetFoodStopByID(ctx context.Context, id primitive.ObjectID) (*models.FoodStop, error) {
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/importer"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/repository"
+	"tirthankarkundu17/pandal-hopping-api/internal/validation"
+)
+
+const (
+	// maxImportRows caps a single import so one request cannot hold the database for long
+	maxImportRows = 5000
+
+	// importBatchSize is the number of pandals written per bulk operation
+	importBatchSize = 500
+)
+
+// ErrImportTooLarge is returned when an import file has more rows than maxImportRows
+var ErrImportTooLarge = fmt.Errorf("import files are limited to %d rows", maxImportRows)
+
+// ImportOptions controls how ImportPandals treats a file
+type ImportOptions struct {
+	// DryRun validates and reports without writing anything
+	DryRun bool
+	// UpdateDuplicates overwrites existing pandals that a row duplicates instead of skipping the row
+	UpdateDuplicates bool
+	// ImportedBy is recorded as the creator of new pandals
+	ImportedBy string
+}
+
+// ImportService defines the business logic for bulk pandal imports
+type ImportService interface {
+	ImportPandals(ctx context.Context, records []importer.Record, opts ImportOptions) (*models.ImportReport, error)
+}
+
+// importService implements ImportService
+type importService struct {
+	repo      repository.PandalRepository
+	tileCache TileInvalidator
+}
+
+// NewImportService creates a new import service.
+// tileCache may be nil when no map tiles are served from this process.
+func NewImportService(repo repository.PandalRepository, tileCache TileInvalidator) ImportService {
+	return &importService{repo: repo, tileCache: tileCache}
+}
+
+// ImportPandals validates every record, matches it against existing pandals and,
+// unless this is a dry run, upserts the accepted rows in batches.
+// Imported pandals are entered by an admin and so skip community approval.
+func (s *importService) ImportPandals(ctx context.Context, records []importer.Record, opts ImportOptions) (*models.ImportReport, error) {
+	if len(records) > maxImportRows {
+		return nil, ErrImportTooLarge
+	}
+
+	report := &models.ImportReport{
+		DryRun: opts.DryRun,
+		Total:  len(records),
+		Rows:   make([]models.ImportRowResult, 0, len(records)),
+	}
+
+	existing, err := s.existingPandals(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	seen := newDuplicateIndex(func(record importer.Record) models.Pandal { return record.Pandal })
+	writes := make([]models.Pandal, 0, len(records))
+	changed := make([]models.Location, 0, len(records))
+
+	for _, record := range records {
+		pandal := record.Pandal
+		result := models.ImportRowResult{Row: record.Row, Name: pandal.Name}
+
+		errs := append([]string{}, record.Errors...)
+		errs = append(errs, validateImportedPandal(&pandal)...)
+
+		if len(errs) == 0 {
+			if earlier, ok := seen.best(pandal); ok {
+				errs = append(errs, fmt.Sprintf("duplicates row %d of this file", earlier.Row))
+			}
+		}
+
+		if len(errs) > 0 {
+			result.Action = models.ImportError
+			result.Errors = errs
+			report.Failed++
+			report.Rows = append(report.Rows, result)
+			continue
+		}
+		seen.add(importer.Record{Row: record.Row, Pandal: pandal})
+
+		normalisePandalForImport(&pandal, opts.ImportedBy, now)
+
+		if match, ok := existing.best(pandal); ok {
+			result.DuplicateOf = match.ID.Hex()
+			if !opts.UpdateDuplicates {
+				result.Action = models.ImportSkip
+				report.Skipped++
+				report.Rows = append(report.Rows, result)
+				continue
+			}
+			pandal.ID = match.ID
+			changed = append(changed, match.Location)
+			result.Action = models.ImportUpdate
+			result.PandalID = match.ID.Hex()
+			report.Updated++
+		} else {
+			pandal.ID = primitive.NewObjectID()
+			result.Action = models.ImportCreate
+			if !opts.DryRun {
+				result.PandalID = pandal.ID.Hex()
+			}
+			report.Created++
+		}
+
+		writes = append(writes, pandal)
+		changed = append(changed, pandal.Location)
+		report.Rows = append(report.Rows, result)
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	// Even a partly written import may have moved pins, so tiles are dropped either way
+	defer invalidateTiles(s.tileCache, changed...)
+
+	for start := 0; start < len(writes); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(writes) {
+			end = len(writes)
+		}
+		if _, err := s.repo.BulkUpsert(ctx, writes[start:end]); err != nil {
+			return nil, fmt.Errorf("import stopped after writing %d of %d pandals: %w", start, len(writes), err)
+		}
+	}
+
+	return report, nil
+}
+
+// existingPandals indexes the pandals in the districts named by records that a row may
+// duplicate. Rejected pandals are left out, as they are for a submitted pandal.
+func (s *importService) existingPandals(ctx context.Context, records []importer.Record) (*duplicateIndex[models.Pandal], error) {
+	districts := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, record := range records {
+		if d := record.Pandal.District; d != "" && !seen[d] {
+			seen[d] = true
+			districts = append(districts, d)
+		}
+	}
+
+	index := newDuplicateIndex(func(pandal models.Pandal) models.Pandal { return pandal })
+	if len(districts) == 0 {
+		return index, nil
+	}
+
+	filter := duplicateCandidateFilter()
+	filter["district"] = bson.M{"$in": districts}
+	pandals, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, pandal := range pandals {
+		index.add(pandal)
+	}
+	return index, nil
+}
+
+// validateImportedPandal applies the checks CreatePandal relies on binding and handler validation for.
+// The location is normalised in place.
+func validateImportedPandal(pandal *models.Pandal) []string {
+	var errs []string
+
+	required := []struct{ field, value string }{
+		{"name", pandal.Name},
+		{"area", pandal.Area},
+		{"district", pandal.District},
+		{"state", pandal.State},
+		{"country", pandal.Country},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			errs = append(errs, r.field+" is required")
+		}
+	}
+
+	if pandal.Country != "" && pandal.State != "" && pandal.District != "" {
+		if err := validation.ValidateLocation(pandal.Country, pandal.State, pandal.District); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	// Like a submitted pandal, every imported one needs a whole pin inside its district
+	if pandal.Location.Type == "" && len(pandal.Location.Coordinates) == 0 {
+		errs = append(errs, "location is required")
+	} else if err := validation.ValidateGeoPoint(&pandal.Location, pandal.Country, pandal.State); err != nil {
+		errs = append(errs, err.Error())
+	} else if len(errs) == 0 {
+		if err := validation.ValidateDistrictPoint(pandal.Country, pandal.State, pandal.District, pandal.Location.Coordinates); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	return errs
+}
+
+// normalisePandalForImport fills in the defaults an admin-imported pandal starts with
+func normalisePandalForImport(pandal *models.Pandal, importedBy string, now time.Time) {
+	if pandal.Tags == nil {
+		pandal.Tags = []string{}
+	}
+	if pandal.Images == nil {
+		pandal.Images = []string{}
+	}
+	pandal.Status = models.StatusApproved
+	pandal.ApprovedBy = []string{}
+	pandal.RejectedBy = []string{}
+	pandal.Rejections = []models.Rejection{}
+	pandal.CreatedBy = importedBy
+	pandal.CreatedAt = now
+	pandal.UpdatedAt = now
+	pandal.RatingAvg = 0
+	pandal.RatingCount = 0
+	pandal.DistanceMeters = nil
+}
@@ -0,0 +1,120 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/importer"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+	"tirthankarkundu17/pandal-hopping-api/internal/validation"
+)
+
+// importFile has one row for each outcome of an import, around Gariahat in Kolkata
+const importFile = `name,area,district,state,country,lng,lat
+Singhi Park,Gariahat,KOL,WB,IN,88.3700,22.5200
+No Pin,Gariahat,KOL,WB,IN,,
+Wrong District,Gariahat,KOL,WB,IN,77.2090,28.6139
+No Area,,KOL,WB,IN,88.3650,22.5190
+Ekdalia Evergreen Club,Gariahat,KOL,WB,IN,88.3668,22.5187
+Singhee Park,Gariahat,KOL,WB,IN,88.3701,22.5201
+`
+
+// existingEkdalia is already stored where row 5 of importFile is pinned
+func existingEkdalia() models.Pandal {
+	return models.Pandal{
+		ID:       primitive.NewObjectID(),
+		Name:     "Ekdalia Evergreen",
+		District: "KOL",
+		Status:   models.StatusApproved,
+		Location: models.Location{Type: "Point", Coordinates: []float64{88.3667, 22.5186}},
+	}
+}
+
+func parseImportFile(t *testing.T) []importer.Record {
+	t.Helper()
+	if err := validation.LoadAdministrativeData(); err != nil {
+		t.Fatal(err)
+	}
+	records, err := importer.ParseCSV(strings.NewReader(importFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestImportDryRunReport(t *testing.T) {
+	existing := existingEkdalia()
+	repo := &memoryPandals{pandals: []models.Pandal{existing}}
+	service := services.NewImportService(repo, nil)
+
+	report, err := service.ImportPandals(context.Background(), parseImportFile(t), services.ImportOptions{DryRun: true, ImportedBy: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.upserted) != 0 {
+		t.Fatalf("a dry run wrote %d pandals", len(repo.upserted))
+	}
+	if !report.DryRun || report.Total != 6 || report.Created != 1 || report.Updated != 0 || report.Skipped != 1 || report.Failed != 4 {
+		t.Fatalf("got report %+v", report)
+	}
+
+	want := []struct {
+		action models.ImportAction
+		error  string
+	}{
+		{models.ImportCreate, ""},
+		{models.ImportError, "location is required"},
+		{models.ImportError, "district"},
+		{models.ImportError, "area is required"},
+		{models.ImportSkip, ""},
+		{models.ImportError, "duplicates row 1"},
+	}
+	for i, row := range report.Rows {
+		if row.Row != i+1 || row.Action != want[i].action {
+			t.Fatalf("row %d: got %s, want %s (%v)", i+1, row.Action, want[i].action, row.Errors)
+		}
+		if want[i].error != "" && !strings.Contains(strings.Join(row.Errors, "; "), want[i].error) {
+			t.Fatalf("row %d: got errors %q, want one about %q", i+1, row.Errors, want[i].error)
+		}
+	}
+	if report.Rows[0].PandalID != "" {
+		t.Fatal("a dry run handed out the ID of a pandal it did not create")
+	}
+	if report.Rows[4].DuplicateOf != existing.ID.Hex() {
+		t.Fatalf("row 5: got duplicateOf %q, want %s", report.Rows[4].DuplicateOf, existing.ID.Hex())
+	}
+}
+
+func TestImportUpdatesDuplicates(t *testing.T) {
+	existing := existingEkdalia()
+	repo := &memoryPandals{pandals: []models.Pandal{existing}}
+	service := services.NewImportService(repo, nil)
+
+	report, err := service.ImportPandals(context.Background(), parseImportFile(t), services.ImportOptions{UpdateDuplicates: true, ImportedBy: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 1 || report.Updated != 1 || report.Failed != 4 {
+		t.Fatalf("got report %+v", report)
+	}
+	if len(repo.upserted) != 2 {
+		t.Fatalf("wrote %d pandals, want 2", len(repo.upserted))
+	}
+
+	created, updated := repo.upserted[0], repo.upserted[1]
+	if created.ID.Hex() != report.Rows[0].PandalID || created.Status != models.StatusApproved || created.CreatedBy != "admin" {
+		t.Fatalf("created pandal %+v does not match row 1 of the report", created)
+	}
+	if updated.ID != existing.ID || updated.Name != "Ekdalia Evergreen Club" {
+		t.Fatalf("row 5 wrote %q as %s, want an update of %s", updated.Name, updated.ID.Hex(), existing.ID.Hex())
+	}
+	for _, pandal := range repo.upserted {
+		if pandal.DistanceMeters != nil {
+			t.Fatalf("%q was written with a search distance", pandal.Name)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/lockout"
+)
+
+// Failed logins lock an account after a handful of attempts. Client IPs get more
+// leeway since a whole pandal's crowd can share one mobile carrier NAT address.
+var (
+	AccountLockoutPolicy = lockout.Policy{
+		Threshold: 5,
+		BaseDelay: 30 * time.Second,
+		MaxDelay:  time.Hour,
+		Window:    24 * time.Hour,
+	}
+	ClientLockoutPolicy = lockout.Policy{
+		Threshold: 30,
+		BaseDelay: time.Minute,
+		MaxDelay:  time.Hour,
+		Window:    time.Hour,
+	}
+)
+
+// LoginThrottledError is returned while an account or client is locked out after too
+// many failed logins
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return "too many failed login attempts, please try again later"
+}
+
+// loginThrottle tracks failed logins per account and per client IP
+type loginThrottle struct {
+	accounts *lockout.Guard
+	clients  *lockout.Guard
+}
+
+func newLoginThrottle(store lockout.Store) *loginThrottle {
+	return &loginThrottle{
+		accounts: lockout.NewGuard(store, AccountLockoutPolicy),
+		clients:  lockout.NewGuard(store, ClientLockoutPolicy),
+	}
+}
+
+// accountKey keys attempts by email rather than user ID, so unknown addresses are
+// throttled the same way and the lockout does not reveal which accounts exist
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func clientKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginAttempt is a login counted against the account and the client before the
+// password is checked
+type loginAttempt struct {
+	throttle *loginThrottle
+	email    string
+	ip       string
+	account  lockout.Reservation
+	client   lockout.Reservation
+}
+
+// begin reserves a login attempt, or returns a LoginThrottledError when either the
+// account or the client is locked. Reserving before the slow password check means a
+// burst of concurrent guesses cannot all get in ahead of the lockout.
+func (t *loginThrottle) begin(ctx context.Context, email, ip string) (*loginAttempt, error) {
+	attempt := &loginAttempt{throttle: t, email: email, ip: ip}
+
+	var err error
+	if attempt.account, err = t.accounts.Attempt(ctx, accountKey(email)); err != nil {
+		return nil, err
+	}
+	if attempt.account.Wait > 0 {
+		return nil, &LoginThrottledError{RetryAfter: attempt.account.Wait}
+	}
+	if ip != "" {
+		if attempt.client, err = t.clients.Attempt(ctx, clientKey(ip)); err != nil {
+			return nil, err
+		}
+		if attempt.client.Wait > 0 {
+			if err := t.accounts.Refund(ctx, accountKey(email), attempt.account); err != nil {
+				return nil, err
+			}
+			return nil, &LoginThrottledError{RetryAfter: attempt.client.Wait}
+		}
+	}
+	return attempt, nil
+}
+
+// lockout is the account lockout the attempt earns by failing, if any
+func (a *loginAttempt) lockout() time.Duration {
+	return a.account.Lockout
+}
+
+// cancel takes the attempt back from both counts, for a login refused before any
+// password was checked
+func (a *loginAttempt) cancel(ctx context.Context) error {
+	if err := a.throttle.accounts.Refund(ctx, accountKey(a.email), a.account); err != nil {
+		return err
+	}
+	return a.refundClient(ctx)
+}
+
+// succeeded clears the account's failures. The client's earlier failures are kept, so
+// logging into an account of their own does not let an attacker go on guessing other
+// passwords; only this attempt is taken back.
+func (a *loginAttempt) succeeded(ctx context.Context) error {
+	if err := a.throttle.reset(ctx, a.email); err != nil {
+		return err
+	}
+	return a.refundClient(ctx)
+}
+
+func (a *loginAttempt) refundClient(ctx context.Context) error {
+	if a.ip == "" {
+		return nil
+	}
+	return a.throttle.clients.Refund(ctx, clientKey(a.ip), a.client)
+}
+
+// reset clears the account's failures and lockout
+func (t *loginThrottle) reset(ctx context.Context, email string) error {
+	return t.accounts.Reset(ctx, accountKey(email))
+}
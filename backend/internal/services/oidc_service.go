@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/auth"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// oidcSessionTTL is how long a user has to sign in at the provider and come back
+const oidcSessionTTL = 10 * time.Minute
+
+// OIDCService signs users in through OpenID Connect providers with the authorization
+// code flow and PKCE, then issues the app's own tokens as a password login would
+type OIDCService interface {
+	Providers() []string
+	LoginURL(ctx context.Context, provider, device string) (string, string, error)
+	Callback(ctx context.Context, provider, state, code string) (string, string, int64, error)
+}
+
+var (
+	ErrUnknownOIDCProvider     = errors.New("unknown sign-in provider")
+	ErrOIDCProviderUnavailable = errors.New("sign-in provider is unavailable, please try again later")
+	ErrInvalidOIDCState        = errors.New("sign-in session is invalid or has expired, please start again")
+	ErrOIDCLoginFailed         = errors.New("sign-in with the provider failed")
+	ErrOIDCEmailNotVerified    = errors.New("the provider has not verified your email address")
+)
+
+type oidcService struct {
+	tokenIssuer
+	userRepo    repository.UserRepository
+	sessionRepo repository.OIDCSessionRepository
+	providers   map[string]*auth.OIDCProvider
+}
+
+// NewOIDCService sets up the configured providers. A provider without a redirect URL
+// gets the API's own callback route under API_URL.
+func NewOIDCService(userRepo repository.UserRepository, tokenRepo repository.RefreshTokenRepository, sessionRepo repository.OIDCSessionRepository, keys *auth.KeyManager, configs []auth.OIDCConfig) OIDCService {
+	providers := make(map[string]*auth.OIDCProvider, len(configs))
+	for _, cfg := range configs {
+		if cfg.RedirectURL == "" {
+			cfg.RedirectURL = publicURL("API_URL", "http://localhost:8080/api/v1") + "/auth/oidc/" + cfg.Name + "/callback"
+		}
+		providers[cfg.Name] = auth.NewOIDCProvider(cfg, nil)
+	}
+	return &oidcService{
+		tokenIssuer: tokenIssuer{tokenRepo: tokenRepo, keys: keys},
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		providers:   providers,
+	}
+}
+
+// Providers lists the configured provider names, for the app to show sign-in buttons
+func (s *oidcService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoginURL starts a login: it stores a fresh state, nonce and PKCE verifier and returns
+// the provider URL to send the user to along with the state, which the caller ties to
+// the user's browser
+func (s *oidcService) LoginURL(ctx context.Context, provider, device string) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownOIDCProvider
+	}
+
+	state, err := auth.RandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := auth.RandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, challenge, err := auth.NewPKCE()
+	if err != nil {
+		return "", "", err
+	}
+
+	loginURL, err := p.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		log.Printf("Error preparing %s sign-in: %v", provider, err)
+		return "", "", ErrOIDCProviderUnavailable
+	}
+
+	session := models.OIDCSession{
+		ID:        state,
+		Provider:  provider,
+		Verifier:  verifier,
+		Nonce:     nonce,
+		Device:    device,
+		ExpiresAt: time.Now().Add(oidcSessionTTL),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return "", "", err
+	}
+	return loginURL, state, nil
+}
+
+// Callback finishes a login: it consumes the session named by state, exchanges the
+// code for a verified ID token and signs the matching user in
+func (s *oidcService) Callback(ctx context.Context, provider, state, code string) (string, string, int64, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", 0, ErrUnknownOIDCProvider
+	}
+
+	session, err := s.sessionRepo.Consume(ctx, state, provider)
+	if err != nil {
+		return "", "", 0, ErrInvalidOIDCState
+	}
+
+	identity, err := p.Exchange(ctx, code, session.Verifier, session.Nonce)
+	if err != nil {
+		log.Printf("Error completing %s sign-in: %v", provider, err)
+		return "", "", 0, ErrOIDCLoginFailed
+	}
+
+	user, err := s.findOrCreateUser(ctx, provider, identity)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	// Like a password login, every sign-in starts a new refresh token family
+	return s.issueTokens(ctx, user, primitive.NewObjectID().Hex(), session.Device, identity.AuthTime)
+}
+
+// findOrCreateUser returns the user who linked this provider account. Otherwise the
+// account is linked to the user with the same email, or a new user is created; both
+// only when the provider vouches for the email, so nobody can claim someone else's.
+// When a concurrent sign-in creates the user first, the lookup is simply repeated.
+func (s *oidcService) findOrCreateUser(ctx context.Context, provider string, identity *auth.Identity) (*models.User, error) {
+	user, err := s.findOrCreateUserOnce(ctx, provider, identity)
+	if mongo.IsDuplicateKeyError(err) {
+		return s.findOrCreateUserOnce(ctx, provider, identity)
+	}
+	return user, err
+}
+
+func (s *oidcService) findOrCreateUserOnce(ctx context.Context, provider string, identity *auth.Identity) (*models.User, error) {
+	if user, err := s.userRepo.FindByIdentity(ctx, provider, identity.Subject); err == nil {
+		return user, nil
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	email := models.NormalizeEmail(identity.Email)
+	link := models.LinkedIdentity{Provider: provider, Subject: identity.Subject}
+	if user, err := s.userRepo.FindByEmail(ctx, email); err == nil {
+		// Anyone could have registered an address nobody verified, so such an account
+		// passes to the provider's user without the password and sessions it came with
+		if !user.EmailVerified {
+			if err := s.userRepo.UpdatePassword(ctx, user.ID, ""); err != nil {
+				return nil, err
+			}
+			if err := s.tokenRepo.RevokeAllForUser(ctx, user.ID.Hex()); err != nil {
+				return nil, err
+			}
+			user.Password = ""
+		}
+		if err := s.userRepo.LinkIdentity(ctx, user.ID, link); err != nil {
+			return nil, err
+		}
+		user.EmailVerified = true
+		// The provider proved the address, as a verification link would have
+		if err := promoteBootstrapAdmin(ctx, s.userRepo, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	name := identity.Name
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	role := models.RoleUser
+	if isBootstrapAdmin(email) {
+		role = models.RoleAdmin
+	}
+
+	// No password is set, so password logins fail until the user sets one, right
+	// after a provider sign-in or through the forgot password flow
+	user := &models.User{
+		Name:          name,
+		Email:         email,
+		Role:          role,
+		EmailVerified: true,
+		Identities:    []models.LinkedIdentity{link},
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,214 @@
+package services_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/auth"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+)
+
+// fakeIDP is an OpenID Connect provider whose token endpoint signs in whoever claims says
+type fakeIDP struct {
+	server *httptest.Server
+	key    ed25519.PrivateKey
+	claims jwt.MapClaims
+}
+
+func newFakeIDP(t *testing.T) *fakeIDP {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIDP{key: private}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "OKP", "crv": "Ed25519", "kid": "idp", "x": base64.RawURLEncoding.EncodeToString(public),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		claims := jwt.MapClaims{
+			"iss": idp.server.URL,
+			"aud": "client",
+			"iat": time.Now().Unix(),
+			"exp": time.Now().Add(time.Minute).Unix(),
+		}
+		for name, value := range idp.claims {
+			claims[name] = value
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+		token.Header["kid"] = "idp"
+		signed, err := token.SignedString(idp.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// signIn runs a whole provider login for the user claims describes
+func (f *authFixture) signIn(t *testing.T, idp *fakeIDP, claims jwt.MapClaims) (string, string, error) {
+	t.Helper()
+	ctx := context.Background()
+	config := auth.OIDCConfig{Name: "test", Issuer: idp.server.URL, ClientID: "client", RedirectURL: "http://localhost/callback"}
+	oidc := services.NewOIDCService(f.users, f.refreshTokens, newMemoryOIDCSessions(), f.keys, []auth.OIDCConfig{config})
+
+	loginURL, state, err := oidc.LoginURL(ctx, "test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(loginURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.claims = jwt.MapClaims{"nonce": parsed.Query().Get("nonce")}
+	for name, value := range claims {
+		idp.claims[name] = value
+	}
+	if parsed.Query().Get("state") != state {
+		t.Fatalf("login URL has state %q, LoginURL returned %q", parsed.Query().Get("state"), state)
+	}
+	access, refresh, _, err := oidc.Callback(ctx, "test", state, "code")
+	return access, refresh, err
+}
+
+// TestProviderLoginTakesOverUnverifiedAccount has someone register a victim's address
+// before the victim signs in with a provider: the squatter's password and sessions must go
+func TestProviderLoginTakesOverUnverifiedAccount(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	idp := newFakeIDP(t)
+	user, _ := f.register(t, "victim@example.com")
+
+	login := models.LoginRequest{Email: "victim@example.com", Password: "old-password"}
+	_, squatterSession, _, err := f.service.Login(ctx, login, "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.MapClaims{"sub": "victim", "email": "victim@example.com", "email_verified": true}
+	if _, _, err := f.signIn(t, idp, claims); err != nil {
+		t.Fatalf("provider login: %v", err)
+	}
+
+	stored, _ := f.users.FindByID(ctx, user.ID)
+	if len(stored.Identities) != 1 || !stored.EmailVerified {
+		t.Fatalf("account was not linked: %+v", stored)
+	}
+	if _, _, _, err := f.service.Login(ctx, login, "192.0.2.1"); err == nil {
+		t.Fatal("the squatter's password still works")
+	}
+	if _, _, _, err := f.service.Refresh(ctx, models.RefreshRequest{RefreshToken: squatterSession}); err == nil {
+		t.Fatal("the squatter's session can still refresh")
+	}
+}
+
+func TestProviderLoginKeepsVerifiedAccountPassword(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	idp := newFakeIDP(t)
+	_, token := f.register(t, "owner@example.com")
+	if err := f.service.VerifyEmail(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.MapClaims{"sub": "owner", "email": "owner@example.com", "email_verified": true}
+	if _, _, err := f.signIn(t, idp, claims); err != nil {
+		t.Fatalf("provider login: %v", err)
+	}
+	login := models.LoginRequest{Email: "owner@example.com", Password: "old-password"}
+	if _, _, _, err := f.service.Login(ctx, login, "192.0.2.1"); err != nil {
+		t.Fatalf("password login after linking: %v", err)
+	}
+}
+
+// TestProviderLoginPromotesBootstrapAdmin verifies the bootstrap admin's unverified
+// account through a provider, which must promote it as a verification link would
+func TestProviderLoginPromotesBootstrapAdmin(t *testing.T) {
+	t.Setenv("BOOTSTRAP_ADMIN_EMAIL", "admin@example.com")
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	idp := newFakeIDP(t)
+	user, _ := f.register(t, "admin@example.com")
+	if user.Role == models.RoleAdmin {
+		t.Fatal("registering alone promoted the bootstrap admin")
+	}
+
+	claims := jwt.MapClaims{"sub": "admin", "email": "admin@example.com", "email_verified": true}
+	access, _, err := f.signIn(t, idp, claims)
+	if err != nil {
+		t.Fatalf("provider login: %v", err)
+	}
+
+	stored, _ := f.users.FindByID(ctx, user.ID)
+	if stored.Role != models.RoleAdmin {
+		t.Fatalf("stored role = %q, want admin", stored.Role)
+	}
+	parsed, err := f.keys.Parse(access, auth.KindAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed["role"] != string(models.RoleAdmin) {
+		t.Fatalf("access token role = %v, want admin", parsed["role"])
+	}
+}
+
+// TestEmailCaseDoesNotSplitAccounts signs in with a provider that reports the address
+// in another case than it was registered with: both must be the same account
+func TestEmailCaseDoesNotSplitAccounts(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	idp := newFakeIDP(t)
+	user, token := f.register(t, "Mixed.Case@Example.com")
+	if user.Email != "mixed.case@example.com" {
+		t.Fatalf("registered email = %q, want it lowercased", user.Email)
+	}
+	if err := f.service.VerifyEmail(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.MapClaims{"sub": "mixed", "email": "mixed.case@EXAMPLE.com", "email_verified": true}
+	if _, _, err := f.signIn(t, idp, claims); err != nil {
+		t.Fatalf("provider login: %v", err)
+	}
+	stored, _ := f.users.FindByID(ctx, user.ID)
+	if len(stored.Identities) != 1 || len(f.users.users) != 1 {
+		t.Fatalf("provider login created a second account instead of linking %+v", stored)
+	}
+
+	_, err := f.service.Register(ctx, models.RegisterRequest{Name: "Again", Email: "MIXED.case@example.com", Password: "password"})
+	if !errors.Is(err, services.ErrEmailInUse) {
+		t.Fatalf("registering the address in another case: got %v, want ErrEmailInUse", err)
+	}
+	login := models.LoginRequest{Email: "mixed.CASE@example.com", Password: "old-password"}
+	if _, _, _, err := f.service.Login(ctx, login, "192.0.2.1"); err != nil {
+		t.Fatalf("login with the address in another case: %v", err)
+	}
+}
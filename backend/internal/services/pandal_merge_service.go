@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/repository"
+)
+
+// ErrMergeIntoSelf is returned when a pandal is merged into itself
+var ErrMergeIntoSelf = errors.New("a pandal cannot be merged into itself")
+
+// PandalMergeService folds duplicate pandals into the one that should survive
+type PandalMergeService interface {
+	MergePandals(ctx context.Context, sourceID, targetID primitive.ObjectID) (*models.MergeResult, error)
+}
+
+// pandalMergeService implements PandalMergeService
+type pandalMergeService struct {
+	pandalRepo repository.PandalRepository
+	reviewRepo repository.ReviewRepository
+	routeRepo  repository.RouteRepository
+	tileCache  TileInvalidator
+}
+
+// NewPandalMergeService creates a new merge service
+func NewPandalMergeService(pandalRepo repository.PandalRepository, reviewRepo repository.ReviewRepository, routeRepo repository.RouteRepository, tileCache TileInvalidator) PandalMergeService {
+	return &pandalMergeService{
+		pandalRepo: pandalRepo,
+		reviewRepo: reviewRepo,
+		routeRepo:  routeRepo,
+		tileCache:  tileCache,
+	}
+}
+
+// MergePandals folds source into target and soft deletes source.
+//   - Source is soft deleted first, so no review is posted to it while its reviews move.
+//   - Approvals of source, and its submitter, count as approvals of target unless that
+//     user created or rejected target. Rejections of source are dropped since they
+//     frequently just flag the duplicate.
+//   - Reviews move to target; a user who reviewed both keeps their target review.
+//     The rating of target is then recomputed from its reviews.
+//   - Routes that stop at source stop at target instead.
+//
+// Each step is idempotent, so a merge that fails part way can simply be retried.
+func (s *pandalMergeService) MergePandals(ctx context.Context, sourceID, targetID primitive.ObjectID) (*models.MergeResult, error) {
+	if sourceID == targetID {
+		return nil, ErrMergeIntoSelf
+	}
+
+	source, err := s.findMergeSource(ctx, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.findLivePandal(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.DeletedAt == nil {
+		now := time.Now()
+		_, err := s.pandalRepo.FindOneAndUpdate(ctx, bson.M{"_id": sourceID, "deletedAt": bson.M{"$exists": false}}, bson.M{"$set": bson.M{
+			"deletedAt":  now,
+			"updatedAt":  now,
+			"mergedInto": targetID,
+		}})
+		// Deleted since it was read; carry on only if that was this same merge
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			_, err = s.findMergeSource(ctx, sourceID, targetID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	moved, dropped, err := s.reviewRepo.ReassignPandal(ctx, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	approvers := make([]string, 0, len(source.ApprovedBy)+1)
+	for _, userID := range append([]string{source.CreatedBy}, source.ApprovedBy...) {
+		if userID != "" && userID != target.CreatedBy {
+			approvers = append(approvers, userID)
+		}
+	}
+
+	// One pipeline update so votes cast on target meanwhile are not lost
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"approvedBy": bson.M{"$setDifference": bson.A{
+				bson.M{"$setUnion": bson.A{bson.M{"$ifNull": bson.A{"$approvedBy", bson.A{}}}, approvers}},
+				bson.M{"$ifNull": bson.A{"$rejectedBy", bson.A{}}},
+			}},
+			// Spelled out so syncRatingSummary can match on them
+			"ratingAvg":   bson.M{"$ifNull": bson.A{"$ratingAvg", 0}},
+			"ratingCount": bson.M{"$ifNull": bson.A{"$ratingCount", 0}},
+			"updatedAt":   time.Now(),
+		}}},
+		{{Key: "$set", Value: bson.M{"approvalCount": bson.M{"$size": "$approvedBy"}}}},
+		{{Key: "$set", Value: bson.M{
+			"status": bson.M{"$cond": bson.A{
+				bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$status", models.StatusPending}},
+					bson.M{"$gte": bson.A{"$approvalCount", requiredVotes("REQUIRED_APPROVALS", 3)}},
+				}},
+				models.StatusApproved,
+				"$status",
+			}},
+		}}},
+	}
+
+	merged, err := s.pandalRepo.FindOneAndUpdate(ctx, bson.M{"_id": targetID, "deletedAt": bson.M{"$exists": false}}, update)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrPandalDeleted
+	}
+	if err != nil {
+		return nil, err
+	}
+	merged, err = s.syncRatingSummary(ctx, merged)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := s.routeRepo.ReplaceStop(ctx, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateTiles(s.tileCache, source.Location, merged.Location)
+
+	return &models.MergeResult{
+		Pandal:         merged,
+		ReviewsMoved:   moved,
+		ReviewsDropped: dropped,
+		RoutesUpdated:  routes,
+	}, nil
+}
+
+// syncRatingSummary recomputes the pandal's rating from its reviews, now including the
+// moved ones. The summary is only written while the rating is still the one read before
+// computing it; a review written meanwhile has already adjusted the rating, so the
+// summary is computed again rather than overwriting that change.
+func (s *pandalMergeService) syncRatingSummary(ctx context.Context, pandal *models.Pandal) (*models.Pandal, error) {
+	for attempt := 0; attempt < maxEditAttempts; attempt++ {
+		ratingAvg, ratingCount, err := s.reviewRepo.RatingSummary(ctx, pandal.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		filter := bson.M{
+			"_id":         pandal.ID,
+			"deletedAt":   bson.M{"$exists": false},
+			"ratingAvg":   pandal.RatingAvg,
+			"ratingCount": pandal.RatingCount,
+		}
+		synced, err := s.pandalRepo.FindOneAndUpdate(ctx, filter, bson.M{"$set": bson.M{
+			"ratingAvg":   ratingAvg,
+			"ratingCount": ratingCount,
+		}})
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return synced, err
+		}
+
+		if pandal, err = s.findLivePandal(ctx, pandal.ID); err != nil {
+			return nil, err
+		}
+	}
+	return nil, ErrEditConflict
+}
+
+// findMergeSource loads the pandal to merge into targetID: a live one, or one an
+// earlier attempt of the same merge already soft deleted
+func (s *pandalMergeService) findMergeSource(ctx context.Context, id, targetID primitive.ObjectID) (*models.Pandal, error) {
+	pandal, err := s.pandalRepo.FindByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrPandalNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pandal.DeletedAt != nil && (pandal.MergedInto == nil || *pandal.MergedInto != targetID) {
+		return nil, ErrPandalDeleted
+	}
+	return pandal, nil
+}
+
+// findLivePandal loads a pandal that has not been deleted or merged away
+func (s *pandalMergeService) findLivePandal(ctx context.Context, id primitive.ObjectID) (*models.Pandal, error) {
+	pandal, err := s.pandalRepo.FindByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrPandalNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pandal.DeletedAt != nil {
+		return nil, ErrPandalDeleted
+	}
+	return pandal, nil
+}
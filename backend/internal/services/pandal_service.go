@@ -3,42 +3,146 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
 	"tirthankarkundu17/pandal-hopping-api/internal/repository"
 	"tirthankarkundu17/pandal-hopping-api/internal/validation"
 )
 
+// Bounds on the number of results of an area (map viewport) query
+const (
+	DefaultAreaLimit = 500
+	MaxAreaLimit     = 2000
+)
+
+// Map clustering works on Web Mercator tiles of mapTilePixels, grouping pins
+// that fall within a clusterCellPixels square of each other
+const (
+	mapTilePixels     = 256
+	clusterCellPixels = 64
+	MaxClusterZoom    = 22
+)
+
+// AreaLimit applies the default and maximum to a requested area query limit
+func AreaLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultAreaLimit
+	}
+	if limit > MaxAreaLimit {
+		return MaxAreaLimit
+	}
+	return limit
+}
+
 // PandalService defines the business logic interface
 type PandalService interface {
-	CreatePandal(ctx context.Context, pandal models.Pandal) (*mongo.InsertOneResult, error)
-	GetPandals(ctx context.Context, lng, lat, radius float64, hasCoords bool, tag, search, district string) ([]models.Pandal, error)
-	GetPendingPandals(ctx context.Context, lng, lat, radius float64, hasCoords bool, excludeUserID string) ([]models.Pandal, error)
+	CreatePandal(ctx context.Context, pandal models.Pandal, allowDuplicate bool) (*mongo.InsertOneResult, error)
+	GetPandals(ctx context.Context, near *Proximity, tag, search, district string, page pagination.Params) ([]models.Pandal, string, error)
+	GetPendingPandals(ctx context.Context, near *Proximity, excludeUserID string, page pagination.Params) ([]models.Pandal, string, error)
+	GetPandalsInArea(ctx context.Context, area geo.Polygon, tag, search, district string, limit int) ([]models.Pandal, bool, error)
+	GetPandalClusters(ctx context.Context, area geo.Polygon, zoom int, tag, district string) ([]models.PandalCluster, error)
 	GetDistricts(ctx context.Context, country, state string) ([]models.District, error)
 	ApprovePandal(ctx context.Context, id primitive.ObjectID, approverID string) (*models.Pandal, error)
+	RejectPandal(ctx context.Context, id primitive.ObjectID, rejecterID, reason string) (*models.Pandal, error)
+	UpdatePandal(ctx context.Context, id primitive.ObjectID, req models.UpdatePandalRequest, editorID string) (*models.Pandal, error)
+	DeletePandal(ctx context.Context, id primitive.ObjectID, editorID string) error
+	RestorePandal(ctx context.Context, id primitive.ObjectID, editorID string) (*models.Pandal, error)
+}
+
+// Errors returned by the pandal lifecycle operations, mapped to HTTP codes by the handler
+var (
+	ErrPandalNotFound   = errors.New("pandal not found")
+	ErrNotPandalOwner   = errors.New("only the creator can modify this pandal")
+	ErrPandalDeleted    = errors.New("pandal has been deleted")
+	ErrPandalNotDeleted = errors.New("pandal is not deleted")
+	ErrPandalRejected   = errors.New("pandal has been rejected")
+	ErrPandalApproved   = errors.New("pandal has already been approved")
+	ErrAlreadyApproved  = errors.New("user has already approved this pandal")
+	ErrAlreadyRejected  = errors.New("user has already rejected this pandal")
+	ErrConflictingVote  = errors.New("user cannot both approve and reject the same pandal")
+	ErrVoteConflict     = errors.New("pandal changed while the vote was being recorded, please retry")
+	ErrEditConflict     = errors.New("pandal kept changing while it was being edited, please retry")
+	ErrPandalMerged     = errors.New("pandal was merged into another pandal and cannot be restored")
+	ErrEmailNotVerified = errors.New("verify your email address before voting on pandals")
+	ErrVoterNotFound    = errors.New("your account no longer exists")
+
+	ErrRejectionReasonRequired = errors.New("a rejection reason is required")
+	ErrInvalidPandal           = errors.New("invalid pandal")
+)
+
+// DuplicatePandalError lists the existing pandals a new submission appears to duplicate
+type DuplicatePandalError struct {
+	Message    string                      `json:"error"`
+	Duplicates []models.DuplicateCandidate `json:"duplicates"`
+}
+
+func (e *DuplicatePandalError) Error() string {
+	return e.Message
+}
+
+const (
+	// maxDuplicateCandidates caps how many likely duplicates are returned to the client
+	maxDuplicateCandidates = 5
+
+	// maxEditAttempts is how often an edit is retried when votes keep landing under it
+	maxEditAttempts = 3
+)
+
+// requiredVotes reads a vote threshold from the environment, falling back to def
+func requiredVotes(key string, def int) int {
+	if val, err := strconv.Atoi(os.Getenv(key)); err == nil && val > 0 {
+		return val
+	}
+	return def
 }
 
 // pandalService implements PandalService interface
 type pandalService struct {
-	repo repository.PandalRepository
+	repo      repository.PandalRepository
+	userRepo  repository.UserRepository
+	tileCache TileInvalidator
 }
 
 // NewPandalService creates a new service instance
-func NewPandalService(repo repository.PandalRepository) PandalService {
+func NewPandalService(repo repository.PandalRepository, userRepo repository.UserRepository, tileCache TileInvalidator) PandalService {
 	return &pandalService{
-		repo: repo,
+		repo:      repo,
+		userRepo:  userRepo,
+		tileCache: tileCache,
 	}
 }
 
-// CreatePandal performs business logic before insertion
-func (s *pandalService) CreatePandal(ctx context.Context, pandal models.Pandal) (*mongo.InsertOneResult, error) {
+// CreatePandal performs business logic before insertion.
+// Unless allowDuplicate is set, a submission whose name closely matches a live pandal
+// pinned nearby is refused with a *DuplicatePandalError listing the matches.
+func (s *pandalService) CreatePandal(ctx context.Context, pandal models.Pandal, allowDuplicate bool) (*mongo.InsertOneResult, error) {
+	if !allowDuplicate {
+		duplicates, err := s.findDuplicates(ctx, pandal)
+		if err != nil {
+			return nil, err
+		}
+		if len(duplicates) > 0 {
+			return nil, &DuplicatePandalError{
+				Message:    "this pandal looks like one that already exists; vote for it instead or resubmit with force=true",
+				Duplicates: duplicates,
+			}
+		}
+	}
+
 	// Ensure proper default values for creation
 	if pandal.Images == nil {
 		pandal.Images = []string{}
@@ -46,31 +150,95 @@ func (s *pandalService) CreatePandal(ctx context.Context, pandal models.Pandal)
 	if pandal.CreatedAt.IsZero() {
 		pandal.CreatedAt = time.Now()
 	}
+	pandal.UpdatedAt = pandal.CreatedAt
+	pandal.DeletedAt = nil
+	pandal.MergedInto = nil
 
 	pandal.Status = models.StatusPending
 	pandal.ApprovalCount = 0
 	pandal.ApprovedBy = []string{}
+	pandal.RejectionCount = 0
+	pandal.RejectedBy = []string{}
+	pandal.Rejections = []models.Rejection{}
+	pandal.RatingAvg = 0
+	pandal.RatingCount = 0
 	pandal.ID = primitive.NewObjectID()
 
-	return s.repo.Create(ctx, pandal)
+	// Proximity results are computed per search and never stored
+	pandal.DistanceMeters = nil
+
+	result, err := s.repo.Create(ctx, pandal)
+	if err != nil {
+		return nil, err
+	}
+	invalidateTiles(s.tileCache, pandal.Location)
+	return result, nil
 }
 
-func (s *pandalService) buildGeospatialFilter(status models.PandalStatus, lng, lat, radius float64, hasCoords bool, tag, search, district string) bson.M {
-	filter := bson.M{"status": status}
+// findDuplicates returns the live, non-rejected pandals that matchDuplicate takes for
+// pandal, best match first
+func (s *pandalService) findDuplicates(ctx context.Context, pandal models.Pandal) ([]models.DuplicateCandidate, error) {
+	point, ok := geo.FromCoordinates(pandal.Location.Coordinates)
+	if !ok {
+		return nil, nil
+	}
+
+	filter := duplicateCandidateFilter()
+	filter["location"] = bson.M{
+		"$nearSphere": bson.M{
+			"$geometry":    bson.M{"type": "Point", "coordinates": []float64{point.Lng, point.Lat}},
+			"$maxDistance": duplicateRadiusMeters,
+		},
+	}
+	nearby, err := s.repo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
 
-	if hasCoords {
-		if radius <= 0 {
-			radius = 5000.0
+	candidates := make([]models.DuplicateCandidate, 0)
+	for _, existing := range nearby {
+		distance, score, ok := matchDuplicate(pandal, existing)
+		if !ok {
+			continue
 		}
-		filter["location"] = bson.M{
-			"$nearSphere": bson.M{
-				"$geometry": bson.M{
-					"type":        "Point",
-					"coordinates": []float64{lng, lat},
-				},
-				"$maxDistance": radius, // in meters
+		candidates = append(candidates, models.DuplicateCandidate{
+			Pandal:         existing,
+			DistanceMeters: math.Round(distance),
+			NameScore:      math.Round(score*100) / 100,
+			CanVote: existing.Status == models.StatusPending &&
+				existing.CreatedBy != pandal.CreatedBy &&
+				!contains(existing.ApprovedBy, pandal.CreatedBy) &&
+				!contains(existing.RejectedBy, pandal.CreatedBy),
+		})
+	}
+
+	// $nearSphere already returns nearest first; stable sort keeps that order among equal scores
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].NameScore > candidates[j].NameScore
+	})
+	if len(candidates) > maxDuplicateCandidates {
+		candidates = candidates[:maxDuplicateCandidates]
+	}
+	return candidates, nil
+}
+
+// withinFilter matches points inside area
+func withinFilter(area geo.Polygon) bson.M {
+	return bson.M{
+		"$geoWithin": bson.M{
+			"$geometry": bson.M{
+				"type":        "Polygon",
+				"coordinates": area.Coordinates(),
 			},
-		}
+		},
+	}
+}
+
+// buildPandalFilter matches live pandals in status with the optional list filters
+func (s *pandalService) buildPandalFilter(status models.PandalStatus, tag, search, district string) bson.M {
+	filter := bson.M{
+		"status":    status,
+		"deletedAt": bson.M{"$exists": false},
 	}
 
 	// Tag filter — matches any pandal whose Tags array contains the given tag
@@ -95,26 +263,71 @@ func (s *pandalService) buildGeospatialFilter(status models.PandalStatus, lng, l
 	return filter
 }
 
-// GetPandals returns only approved pandals, with optional tag and text search filters
-func (s *pandalService) GetPandals(ctx context.Context, lng, lat, radius float64, hasCoords bool, tag, search, district string) ([]models.Pandal, error) {
-	filter := s.buildGeospatialFilter(models.StatusApproved, lng, lat, radius, hasCoords, tag, search, district)
-	return s.repo.FindAll(ctx, filter)
+// GetPandals returns one page of approved pandals, with optional proximity, tag and text search filters
+func (s *pandalService) GetPandals(ctx context.Context, near *Proximity, tag, search, district string, page pagination.Params) ([]models.Pandal, string, error) {
+	filter := s.buildPandalFilter(models.StatusApproved, tag, search, district)
+	return s.findPandalPage(ctx, near, filter, page)
 }
 
-// GetPendingPandals returns pandals waiting for approval
-func (s *pandalService) GetPendingPandals(ctx context.Context, lng, lat, radius float64, hasCoords bool, excludeUserID string) ([]models.Pandal, error) {
-	filter := s.buildGeospatialFilter(models.StatusPending, lng, lat, radius, hasCoords, "", "", "")
+// findPandalPage fetches a page of pandals matching filter, through $geoNear when near is set
+// so each result also gets its distance, walking time and bearing
+func (s *pandalService) findPandalPage(ctx context.Context, near *Proximity, filter bson.M, page pagination.Params) ([]models.Pandal, string, error) {
+	page, err := page.WithDefaultSort(near != nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if near == nil {
+		return s.repo.FindPage(ctx, filter, page)
+	}
+
+	pandals, nextCursor, err := s.repo.FindNearPage(ctx, near.Origin, near.MinRadius, near.maxMeters(), filter, page)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range pandals {
+		pandals[i].WalkingMinutes, pandals[i].BearingDegrees = proximityDetails(near.Origin, pandals[i].Location, pandals[i].DistanceMeters)
+	}
+	return pandals, nextCursor, nil
+}
+
+// GetPandalsInArea returns the approved pandals inside area, such as a map viewport.
+// Results are capped at limit (see AreaLimit); truncated reports whether some were left out.
+func (s *pandalService) GetPandalsInArea(ctx context.Context, area geo.Polygon, tag, search, district string, limit int) ([]models.Pandal, bool, error) {
+	filter := s.buildPandalFilter(models.StatusApproved, tag, search, district)
+	filter["location"] = withinFilter(area)
+	return s.repo.FindCapped(ctx, filter, AreaLimit(limit))
+}
+
+// GetPandalClusters groups the approved pandals inside area into map clusters for a zoom level.
+// Cells cover about clusterCellPixels square on a Web Mercator map; their height in degrees is
+// scaled by the cosine of the area's middle latitude, so they are square on screen.
+func (s *pandalService) GetPandalClusters(ctx context.Context, area geo.Polygon, zoom int, tag, district string) ([]models.PandalCluster, error) {
+	filter := s.buildPandalFilter(models.StatusApproved, tag, "", district)
+	filter["location"] = withinFilter(area)
+
+	sw, ne := area.Bounds()
+	midLat := (sw.Lat + ne.Lat) / 2 * math.Pi / 180
+
+	cellLng := 360 / math.Exp2(float64(zoom)) * clusterCellPixels / mapTilePixels
+	cellLat := cellLng * math.Cos(midLat)
+	return s.repo.AggregateClusters(ctx, filter, cellLng, cellLat, MaxAreaLimit)
+}
+
+// GetPendingPandals returns one page of pandals waiting for approval
+func (s *pandalService) GetPendingPandals(ctx context.Context, near *Proximity, excludeUserID string, page pagination.Params) ([]models.Pandal, string, error) {
+	filter := s.buildPandalFilter(models.StatusPending, "", "", "")
 
 	if excludeUserID != "" {
 		filter["createdBy"] = bson.M{"$ne": excludeUserID}
 	}
 
-	// Also exclude pandals the user has already approved
+	// Also exclude pandals the user has already approved or rejected
 	if excludeUserID != "" {
 		filter["approvedBy"] = bson.M{"$ne": excludeUserID}
+		filter["rejectedBy"] = bson.M{"$ne": excludeUserID}
 	}
 
-	return s.repo.FindAll(ctx, filter)
+	return s.findPandalPage(ctx, near, filter, page)
 }
 
 // GetDistricts aggregates approved pandals grouped by district
@@ -135,58 +348,412 @@ func (s *pandalService) GetDistricts(ctx context.Context, country, state string)
 	return districts, nil
 }
 
-// ApprovePandal increments the approval count and updates status to approved if consensus is met
+// checkVoter makes sure the voter's account still exists and has a verified email,
+// so throwaway sign ups cannot sway a pandal's review
+func (s *pandalService) checkVoter(ctx context.Context, voterID string) error {
+	objID, err := primitive.ObjectIDFromHex(voterID)
+	if err != nil {
+		return ErrVoterNotFound
+	}
+	voter, err := s.userRepo.FindByID(ctx, objID)
+	if err != nil {
+		return ErrVoterNotFound
+	}
+	if !voter.EmailVerified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// ApprovePandal registers an approval vote and updates status to approved if consensus is met.
+// The vote is a single conditional update so concurrent approvers cannot overwrite each other.
+// Only users with a verified email address may vote.
 func (s *pandalService) ApprovePandal(ctx context.Context, id primitive.ObjectID, approverID string) (*models.Pandal, error) {
-	pandal, err := s.repo.FindByID(ctx, id)
+	if err := s.checkVoter(ctx, approverID); err != nil {
+		return nil, err
+	}
+
+	// Set required threshold for approval from environment variables, defaulting to 3
+	update := voteUpdate("approvedBy", "approvalCount", approverID, requiredVotes("REQUIRED_APPROVALS", 3), models.StatusApproved, nil)
+
+	pandal, err := s.repo.FindOneAndUpdate(ctx, voteFilter(id, approverID), update)
+	if err == nil {
+		s.invalidateOnStatusChange(pandal)
+		return pandal, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	// The guarded update matched nothing, so work out which precondition failed
+	pandal, err = s.findPandal(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	switch {
+	case pandal.DeletedAt != nil:
+		return nil, ErrPandalDeleted
+	case pandal.Status == models.StatusApproved:
+		// If already approved, skip
+		return pandal, nil
+	case pandal.Status == models.StatusRejected:
+		return nil, ErrPandalRejected
+	case contains(pandal.ApprovedBy, approverID):
+		return nil, ErrAlreadyApproved
+	case contains(pandal.RejectedBy, approverID):
+		return nil, ErrConflictingVote
+	}
+	return nil, ErrVoteConflict
+}
+
+// RejectPandal records a rejection vote with a reason and marks the pandal rejected
+// once the REQUIRED_REJECTIONS threshold is reached. Like approvals, only users with a
+// verified email address may vote.
+func (s *pandalService) RejectPandal(ctx context.Context, id primitive.ObjectID, rejecterID, reason string) (*models.Pandal, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrRejectionReasonRequired
+	}
+	if err := s.checkVoter(ctx, rejecterID); err != nil {
+		return nil, err
+	}
 
-	// If already approved, skip
-	if pandal.Status == models.StatusApproved {
+	rejection := models.Rejection{
+		UserID:    rejecterID,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	extra := bson.M{
+		"rejections": bson.M{"$concatArrays": bson.A{
+			bson.M{"$ifNull": bson.A{"$rejections", bson.A{}}},
+			bson.M{"$literal": bson.A{rejection}},
+		}},
+	}
+
+	// Threshold mirrors REQUIRED_APPROVALS and also defaults to 3
+	update := voteUpdate("rejectedBy", "rejectionCount", rejecterID, requiredVotes("REQUIRED_REJECTIONS", 3), models.StatusRejected, extra)
+
+	pandal, err := s.repo.FindOneAndUpdate(ctx, voteFilter(id, rejecterID), update)
+	if err == nil {
+		s.invalidateOnStatusChange(pandal)
 		return pandal, nil
 	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	pandal, err = s.findPandal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case pandal.DeletedAt != nil:
+		return nil, ErrPandalDeleted
+	case pandal.Status == models.StatusRejected:
+		// Rejection only applies to pandals still under review
+		return pandal, nil
+	case pandal.Status == models.StatusApproved:
+		return nil, ErrPandalApproved
+	case contains(pandal.RejectedBy, rejecterID):
+		return nil, ErrAlreadyRejected
+	case contains(pandal.ApprovedBy, rejecterID):
+		return nil, ErrConflictingVote
+	}
+	return nil, ErrVoteConflict
+}
+
+// invalidateOnStatusChange drops the map tiles of a pandal a vote just moved out of pending
+func (s *pandalService) invalidateOnStatusChange(pandal *models.Pandal) {
+	if pandal.Status != models.StatusPending {
+		invalidateTiles(s.tileCache, pandal.Location)
+	}
+}
 
-	// Validate this user hasn't already approved the pandal
-	for _, user := range pandal.ApprovedBy {
-		if user == approverID {
-			return nil, errors.New("user has already approved this pandal")
+// voteFilter matches a live pending pandal the user has not voted on yet
+func voteFilter(id primitive.ObjectID, userID string) bson.M {
+	return bson.M{
+		"_id":        id,
+		"status":     models.StatusPending,
+		"deletedAt":  bson.M{"$exists": false},
+		"approvedBy": bson.M{"$ne": userID},
+		"rejectedBy": bson.M{"$ne": userID},
+	}
+}
+
+// voteUpdate builds a pipeline update that appends userID to listField, increments
+// countField and moves the pandal to target once threshold votes are reached.
+// Running it as one pipeline keeps the vote and the status change in a single atomic write;
+// voteFilter already guarantees userID is absent, so appending behaves like $addToSet.
+func voteUpdate(listField, countField, userID string, threshold int, target models.PandalStatus, extra bson.M) mongo.Pipeline {
+	set := bson.M{
+		listField: bson.M{"$concatArrays": bson.A{
+			bson.M{"$ifNull": bson.A{"$" + listField, bson.A{}}},
+			bson.M{"$literal": bson.A{userID}},
+		}},
+		countField: bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + countField, 0}}, 1}},
+	}
+	for k, v := range extra {
+		set[k] = v
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$set", Value: set}},
+		{{Key: "$set", Value: bson.M{
+			"status": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$" + countField, threshold}},
+				target,
+				"$status",
+			}},
+		}}},
+	}
+}
+
+func contains(values []string, v string) bool {
+	for _, item := range values {
+		if item == v {
+			return true
 		}
 	}
+	return false
+}
 
-	newCount := pandal.ApprovalCount + 1
-	status := pandal.Status
-	approvedBy := append(pandal.ApprovedBy, approverID)
+// findPandal loads a pandal and translates a missing document into ErrPandalNotFound
+func (s *pandalService) findPandal(ctx context.Context, id primitive.ObjectID) (*models.Pandal, error) {
+	pandal, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrPandalNotFound
+		}
+		return nil, err
+	}
+	return pandal, nil
+}
 
-	// Set required threshold for approval from environment variables, defaulting to 3
-	reqApprovalsStr := os.Getenv("REQUIRED_APPROVALS")
-	reqApprovals := 3
-	if reqApprovalsStr != "" {
-		if val, err := strconv.Atoi(reqApprovalsStr); err == nil {
-			reqApprovals = val
+// UpdatePandal applies the non-nil fields of req to a pandal owned by editorID.
+// Editing an approved pandal sends it back to pending so the change is reviewed again,
+// and renaming or moving a pending one discards the votes it collected so far.
+// Rejected pandals cannot be edited, so an edit cannot undo the community's rejection.
+// The write only lands if the pandal is unchanged since it was read, so a vote cast
+// meanwhile is never lost; the edit is retried against the fresh document instead.
+func (s *pandalService) UpdatePandal(ctx context.Context, id primitive.ObjectID, req models.UpdatePandalRequest, editorID string) (*models.Pandal, error) {
+	for attempt := 0; attempt < maxEditAttempts; attempt++ {
+		pandal, err := s.updatePandalOnce(ctx, id, req, editorID)
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return pandal, err
 		}
 	}
+	return nil, ErrEditConflict
+}
 
-	if newCount >= reqApprovals {
-		status = models.StatusApproved
+// updatePandalOnce reads the pandal, merges and validates the edit, and writes it with
+// a pipeline update guarded by the status and updatedAt that were read. It returns
+// mongo.ErrNoDocuments when the guard no longer matches.
+func (s *pandalService) updatePandalOnce(ctx context.Context, id primitive.ObjectID, req models.UpdatePandalRequest, editorID string) (*models.Pandal, error) {
+	pandal, err := s.findPandal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pandal.DeletedAt != nil {
+		return nil, ErrPandalDeleted
+	}
+	if pandal.CreatedBy != editorID {
+		return nil, ErrNotPandalOwner
 	}
+	if pandal.Status == models.StatusRejected {
+		return nil, ErrPandalRejected
+	}
+	previous := *pandal
+	previousLocation := pandal.Location
+	readStatus, readUpdatedAt := pandal.Status, pandal.UpdatedAt
 
+	if req.Name != nil {
+		pandal.Name = *req.Name
+	}
+	if req.Description != nil {
+		pandal.Description = *req.Description
+	}
+	if req.Area != nil {
+		pandal.Area = *req.Area
+	}
+	if req.District != nil {
+		pandal.District = *req.District
+	}
+	if req.State != nil {
+		pandal.State = *req.State
+	}
+	if req.Country != nil {
+		pandal.Country = *req.Country
+	}
+	if req.Theme != nil {
+		pandal.Theme = *req.Theme
+	}
+	if req.Tags != nil {
+		pandal.Tags = *req.Tags
+	}
+	if req.Location != nil {
+		pandal.Location = *req.Location
+	}
+	if req.Images != nil {
+		pandal.Images = *req.Images
+	}
+
+	if pandal.Name == "" || pandal.Area == "" {
+		return nil, fmt.Errorf("%w: name and area cannot be empty", ErrInvalidPandal)
+	}
+
+	// Re-validate the administrative codes and pin against the merged document
+	if err := validation.ValidateLocation(pandal.Country, pandal.State, pandal.District); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPandal, err)
+	}
+	if err := validation.ValidateGeoPoint(&pandal.Location, pandal.Country, pandal.State); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPandal, err)
+	}
+	if err := validation.ValidateDistrictPoint(pandal.Country, pandal.State, pandal.District, pandal.Location.Coordinates); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPandal, err)
+	}
+
+	filter := bson.M{
+		"_id":       id,
+		"createdBy": editorID,
+		"status":    readStatus,
+		"deletedAt": bson.M{"$exists": false},
+	}
+	if readUpdatedAt.IsZero() {
+		filter["updatedAt"] = bson.M{"$exists": false}
+	} else {
+		filter["updatedAt"] = readUpdatedAt
+	}
+
+	updated, err := s.repo.FindOneAndUpdate(ctx, filter, editUpdate(pandal, identityChanged(&previous, pandal)))
+	if err != nil {
+		return nil, err
+	}
+	invalidateTiles(s.tileCache, previousLocation, updated.Location)
+	return updated, nil
+}
+
+// identityChanged reports whether an edit changes what voters vouched for: the
+// pandal's name, its district or where it is pinned
+func identityChanged(before, after *models.Pandal) bool {
+	return before.Name != after.Name ||
+		before.Country != after.Country || before.State != after.State || before.District != after.District ||
+		!slices.Equal(before.Location.Coordinates, after.Location.Coordinates)
+}
+
+// editUpdate builds the pipeline update for an edit. Values are wrapped in $literal so
+// user text starting with "$" is not read as a field path. Approved pandals go back
+// into the review queue. A pending pandal keeps its votes unless resetVotes is set,
+// since votes cast for one name or pin must not count towards another.
+func editUpdate(pandal *models.Pandal, resetVotes bool) mongo.Pipeline {
+	approved := bson.M{"$eq": bson.A{"$status", models.StatusApproved}}
+	resetVote := func(field string, value any) any {
+		if resetVotes {
+			return bson.M{"$literal": value}
+		}
+		return bson.M{"$cond": bson.A{approved, bson.M{"$literal": value}, "$" + field}}
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"name":           bson.M{"$literal": pandal.Name},
+			"description":    bson.M{"$literal": pandal.Description},
+			"area":           bson.M{"$literal": pandal.Area},
+			"district":       bson.M{"$literal": pandal.District},
+			"state":          bson.M{"$literal": pandal.State},
+			"country":        bson.M{"$literal": pandal.Country},
+			"theme":          bson.M{"$literal": pandal.Theme},
+			"tags":           bson.M{"$literal": pandal.Tags},
+			"location":       bson.M{"$literal": pandal.Location},
+			"images":         bson.M{"$literal": pandal.Images},
+			"status":         resetVote("status", models.StatusPending),
+			"approvalCount":  resetVote("approvalCount", 0),
+			"approvedBy":     resetVote("approvedBy", bson.A{}),
+			"rejectionCount": resetVote("rejectionCount", 0),
+			"rejectedBy":     resetVote("rejectedBy", bson.A{}),
+			"rejections":     resetVote("rejections", bson.A{}),
+			"updatedAt":      time.Now(),
+		}}},
+	}
+}
+
+// DeletePandal soft deletes a pandal by stamping deletedAt; the document is kept for restore.
+// The write is conditional on the pandal still being live, so of two concurrent deletes,
+// or a delete racing a merge, only one lands.
+func (s *pandalService) DeletePandal(ctx context.Context, id primitive.ObjectID, editorID string) error {
+	pandal, err := s.findPandal(ctx, id)
+	if err != nil {
+		return err
+	}
+	if pandal.DeletedAt != nil {
+		return ErrPandalDeleted
+	}
+	if pandal.CreatedBy != editorID {
+		return ErrNotPandalOwner
+	}
+
+	now := time.Now()
+	filter := bson.M{
+		"_id":       id,
+		"createdBy": editorID,
+		"deletedAt": bson.M{"$exists": false},
+	}
 	update := bson.M{
 		"$set": bson.M{
-			"approvalCount": newCount,
-			"status":        status,
-			"approvedBy":    approvedBy,
+			"deletedAt": now,
+			"updatedAt": now,
 		},
 	}
+	deleted, err := s.repo.FindOneAndUpdate(ctx, filter, update)
+	if err != nil {
+		return s.lostPrecondition(ctx, id, err, ErrPandalDeleted)
+	}
+	invalidateTiles(s.tileCache, deleted.Location)
+	return nil
+}
 
-	_, err = s.repo.Update(ctx, id, update)
+// RestorePandal clears deletedAt so the pandal shows up in listings again. Like
+// DeletePandal, the write only lands if the pandal is still deleted and not merged.
+func (s *pandalService) RestorePandal(ctx context.Context, id primitive.ObjectID, editorID string) (*models.Pandal, error) {
+	pandal, err := s.findPandal(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if pandal.DeletedAt == nil {
+		return nil, ErrPandalNotDeleted
+	}
+	if pandal.CreatedBy != editorID {
+		return nil, ErrNotPandalOwner
+	}
+	if pandal.MergedInto != nil {
+		return nil, ErrPandalMerged
+	}
 
-	pandal.ApprovalCount = newCount
-	pandal.Status = status
-	pandal.ApprovedBy = approvedBy
+	filter := bson.M{
+		"_id":        id,
+		"createdBy":  editorID,
+		"deletedAt":  bson.M{"$exists": true},
+		"mergedInto": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set":   bson.M{"updatedAt": time.Now()},
+		"$unset": bson.M{"deletedAt": ""},
+	}
+	restored, err := s.repo.FindOneAndUpdate(ctx, filter, update)
+	if err != nil {
+		return nil, s.lostPrecondition(ctx, id, err, ErrPandalNotDeleted)
+	}
+	invalidateTiles(s.tileCache, restored.Location)
+	return restored, nil
+}
 
-	return pandal, nil
+// lostPrecondition explains a conditional write that matched nothing: the pandal is
+// gone, or a concurrent request already changed it, which is reported as conflict
+func (s *pandalService) lostPrecondition(ctx context.Context, id primitive.ObjectID, err error, conflict error) error {
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	if _, err := s.findPandal(ctx, id); err != nil {
+		return err
+	}
+	return conflict
 }
@@ -0,0 +1,215 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+	"tirthankarkundu17/pandal-hopping-api/internal/validation"
+)
+
+// votedPandal is a pending pandal in Gariahat, owned by "owner", that two voters
+// approved and one rejected
+func votedPandal(t *testing.T) models.Pandal {
+	t.Helper()
+	if err := validation.LoadAdministrativeData(); err != nil {
+		t.Fatal(err)
+	}
+	return models.Pandal{
+		ID:             primitive.NewObjectID(),
+		Name:           "Singhi Park",
+		Area:           "Gariahat",
+		District:       "KOL",
+		State:          "WB",
+		Country:        "IN",
+		Location:       models.Location{Type: "Point", Coordinates: []float64{88.3700, 22.5200}},
+		Status:         models.StatusPending,
+		ApprovalCount:  2,
+		ApprovedBy:     []string{"voter-1", "voter-2"},
+		RejectionCount: 1,
+		RejectedBy:     []string{"voter-3"},
+		Rejections:     []models.Rejection{{UserID: "voter-3", Reason: "wrong pin"}},
+		CreatedBy:      "owner",
+		CreatedAt:      time.Now().Add(-time.Hour),
+		UpdatedAt:      time.Now().Add(-time.Hour),
+	}
+}
+
+func hasVotes(pandal *models.Pandal) bool {
+	return pandal.ApprovalCount != 0 || len(pandal.ApprovedBy) != 0 ||
+		pandal.RejectionCount != 0 || len(pandal.RejectedBy) != 0 || len(pandal.Rejections) != 0
+}
+
+func TestEditResetsVotesWhenIdentityChanges(t *testing.T) {
+	description, sameName, name, howrah := "Theme pandal by the lake", "Singhi Park", "Singhee Park", "HWH"
+	moved := models.Location{Type: "Point", Coordinates: []float64{88.3710, 22.5210}}
+	// Pins are checked against the district's boundary, so moving to Howrah moves the pin too
+	inHowrah := models.Location{Type: "Point", Coordinates: []float64{88.3100, 22.5900}}
+
+	tests := []struct {
+		name       string
+		req        models.UpdatePandalRequest
+		resetVotes bool
+	}{
+		{"description", models.UpdatePandalRequest{Description: &description}, false},
+		{"same name", models.UpdatePandalRequest{Name: &sameName}, false},
+		{"name", models.UpdatePandalRequest{Name: &name}, true},
+		{"pin", models.UpdatePandalRequest{Location: &moved}, true},
+		{"district", models.UpdatePandalRequest{District: &howrah, Location: &inHowrah}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pandal := votedPandal(t)
+			service := services.NewPandalService(&memoryPandals{pandals: []models.Pandal{pandal}}, nil, nil)
+
+			updated, err := service.UpdatePandal(context.Background(), pandal.ID, tt.req, "owner")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated.Status != models.StatusPending {
+				t.Fatalf("status = %q, want pending", updated.Status)
+			}
+			if hasVotes(updated) == tt.resetVotes {
+				t.Fatalf("votes after the edit: %d approvals by %v, %d rejections by %v; want reset %v",
+					updated.ApprovalCount, updated.ApprovedBy, updated.RejectionCount, updated.RejectedBy, tt.resetVotes)
+			}
+		})
+	}
+}
+
+func TestEditSendsApprovedPandalBackToReview(t *testing.T) {
+	pandal := votedPandal(t)
+	pandal.Status = models.StatusApproved
+	service := services.NewPandalService(&memoryPandals{pandals: []models.Pandal{pandal}}, nil, nil)
+
+	description := "Theme pandal by the lake"
+	updated, err := service.UpdatePandal(context.Background(), pandal.ID, models.UpdatePandalRequest{Description: &description}, "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status != models.StatusPending || hasVotes(updated) {
+		t.Fatalf("got status %q with %d approvals, want a fresh pending pandal", updated.Status, updated.ApprovalCount)
+	}
+}
+
+// TestEditRetriesAfterConcurrentVote casts a vote between the edit's read and its
+// write: the edit must be retried on top of the vote rather than overwrite it
+func TestEditRetriesAfterConcurrentVote(t *testing.T) {
+	pandal := votedPandal(t)
+	writes := 0
+	repo := &memoryPandals{pandals: []models.Pandal{pandal}, interleave: func(r *memoryPandals) {
+		writes++
+		if writes == 1 {
+			r.pandals[0].ApprovalCount++
+			r.pandals[0].ApprovedBy = append(r.pandals[0].ApprovedBy, "voter-4")
+			r.pandals[0].UpdatedAt = time.Now()
+		}
+	}}
+	service := services.NewPandalService(repo, nil, nil)
+
+	description := "Theme pandal by the lake"
+	updated, err := service.UpdatePandal(context.Background(), pandal.ID, models.UpdatePandalRequest{Description: &description}, "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if writes != 2 {
+		t.Fatalf("the edit was written %d times, want a retry after the vote", writes)
+	}
+	if updated.Description != description {
+		t.Fatalf("description = %q, the edit was lost", updated.Description)
+	}
+	if updated.ApprovalCount != 3 || !slices.Contains(updated.ApprovedBy, "voter-4") {
+		t.Fatalf("got %d approvals by %v, the concurrent vote was lost", updated.ApprovalCount, updated.ApprovedBy)
+	}
+}
+
+func TestEditGivesUpWhenVotesKeepLanding(t *testing.T) {
+	pandal := votedPandal(t)
+	writes := 0
+	repo := &memoryPandals{pandals: []models.Pandal{pandal}, interleave: func(r *memoryPandals) {
+		writes++
+		r.pandals[0].UpdatedAt = r.pandals[0].UpdatedAt.Add(time.Second)
+	}}
+	service := services.NewPandalService(repo, nil, nil)
+
+	description := "Theme pandal by the lake"
+	_, err := service.UpdatePandal(context.Background(), pandal.ID, models.UpdatePandalRequest{Description: &description}, "owner")
+	if !errors.Is(err, services.ErrEditConflict) {
+		t.Fatalf("got %v, want ErrEditConflict", err)
+	}
+	if writes != 3 {
+		t.Fatalf("tried %d times, want 3", writes)
+	}
+	if repo.pandals[0].Description != "" {
+		t.Fatal("a conflicting edit was written")
+	}
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	pandal := votedPandal(t)
+	repo := &memoryPandals{pandals: []models.Pandal{pandal}}
+	service := services.NewPandalService(repo, nil, nil)
+	ctx := context.Background()
+
+	if err := service.DeletePandal(ctx, pandal.ID, "owner"); err != nil {
+		t.Fatal(err)
+	}
+	if repo.pandals[0].DeletedAt == nil {
+		t.Fatal("the pandal was not soft deleted")
+	}
+	if err := service.DeletePandal(ctx, pandal.ID, "owner"); !errors.Is(err, services.ErrPandalDeleted) {
+		t.Fatalf("deleting twice: got %v, want ErrPandalDeleted", err)
+	}
+
+	restored, err := service.RestorePandal(ctx, pandal.ID, "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.DeletedAt != nil || repo.pandals[0].DeletedAt != nil {
+		t.Fatal("the pandal is still deleted")
+	}
+	if _, err := service.RestorePandal(ctx, pandal.ID, "owner"); !errors.Is(err, services.ErrPandalNotDeleted) {
+		t.Fatalf("restoring twice: got %v, want ErrPandalNotDeleted", err)
+	}
+	if err := service.DeletePandal(ctx, primitive.NewObjectID(), "owner"); !errors.Is(err, services.ErrPandalNotFound) {
+		t.Fatalf("deleting a missing pandal: got %v, want ErrPandalNotFound", err)
+	}
+}
+
+// TestConcurrentDeletesLandOnce lets a second delete land between the first one's
+// read and its write: the first must notice rather than stamp deletedAt again
+func TestConcurrentDeletesLandOnce(t *testing.T) {
+	pandal := votedPandal(t)
+	deletedAt := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	repo := &memoryPandals{pandals: []models.Pandal{pandal}, interleave: func(r *memoryPandals) {
+		r.pandals[0].DeletedAt = &deletedAt
+	}}
+	service := services.NewPandalService(repo, nil, nil)
+
+	if err := service.DeletePandal(context.Background(), pandal.ID, "owner"); !errors.Is(err, services.ErrPandalDeleted) {
+		t.Fatalf("got %v, want ErrPandalDeleted", err)
+	}
+	if !repo.pandals[0].DeletedAt.Equal(deletedAt) {
+		t.Fatalf("deletedAt = %v, the other delete's %v was overwritten", repo.pandals[0].DeletedAt, deletedAt)
+	}
+}
+
+func TestConcurrentRestoresLandOnce(t *testing.T) {
+	pandal := votedPandal(t)
+	deletedAt := time.Now().Add(-time.Minute)
+	pandal.DeletedAt = &deletedAt
+	repo := &memoryPandals{pandals: []models.Pandal{pandal}, interleave: func(r *memoryPandals) {
+		r.pandals[0].DeletedAt = nil
+	}}
+	service := services.NewPandalService(repo, nil, nil)
+
+	if _, err := service.RestorePandal(context.Background(), pandal.ID, "owner"); !errors.Is(err, services.ErrPandalNotDeleted) {
+		t.Fatalf("got %v, want ErrPandalNotDeleted", err)
+	}
+}
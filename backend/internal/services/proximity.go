@@ -0,0 +1,43 @@
+package services
+
+import (
+	"math"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// DefaultSearchRadiusMeters is the radius of a proximity search that does not name one
+const DefaultSearchRadiusMeters = 5000.0
+
+// Proximity restricts a listing to results between MinRadius and Radius meters of
+// Origin. Results come nearest first unless another sort is asked for, and each
+// carries its distance, walking time and bearing from Origin.
+type Proximity struct {
+	Origin    geo.Point
+	Radius    float64 // meters; 0 means DefaultSearchRadiusMeters
+	MinRadius float64 // meters; results closer than this are left out
+}
+
+// maxMeters returns the search radius with the default applied
+func (p Proximity) maxMeters() float64 {
+	if p.Radius <= 0 {
+		return DefaultSearchRadiusMeters
+	}
+	return p.Radius
+}
+
+// proximityDetails derives the walking time and bearing from origin to a result at
+// location, given the distance $geoNear reported for it
+func proximityDetails(origin geo.Point, location models.Location, distanceMeters *float64) (*int, *float64) {
+	point, ok := geo.FromCoordinates(location.Coordinates)
+	if distanceMeters == nil || !ok {
+		return nil, nil
+	}
+	minutes := int(math.Ceil(geo.WalkingMinutes(*distanceMeters)))
+	bearing := math.Round(geo.Bearing(origin, point))
+	if bearing == 360 {
+		bearing = 0
+	}
+	return &minutes, &bearing
+}
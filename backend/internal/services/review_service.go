@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
+	"tirthankarkundu17/pandal-hopping-api/internal/repository"
+)
+
+// ReviewService defines business logic for pandal ratings and reviews
+type ReviewService interface {
+	CreateReview(ctx context.Context, pandalID primitive.ObjectID, userID string, req models.ReviewRequest) (*models.Review, error)
+	UpdateReview(ctx context.Context, id primitive.ObjectID, userID string, req models.ReviewRequest) (*models.Review, error)
+	DeleteReview(ctx context.Context, id primitive.ObjectID, userID string) error
+	GetReviews(ctx context.Context, pandalID primitive.ObjectID, page pagination.Params) ([]models.Review, string, error)
+}
+
+var (
+	ErrReviewNotFound   = errors.New("review not found")
+	ErrReviewExists     = errors.New("user has already reviewed this pandal")
+	ErrNotReviewOwner   = errors.New("only the author can modify this review")
+	ErrPandalNotRatable = errors.New("only approved pandals can be reviewed")
+)
+
+type reviewService struct {
+	repo       repository.ReviewRepository
+	pandalRepo repository.PandalRepository
+}
+
+// NewReviewService creates a new service instance
+func NewReviewService(repo repository.ReviewRepository, pandalRepo repository.PandalRepository) ReviewService {
+	return &reviewService{repo: repo, pandalRepo: pandalRepo}
+}
+
+// CreateReview stores a user's review and folds its rating into the pandal's average.
+// The unique (pandalId, userId) index enforces one review per user per pandal.
+func (s *reviewService) CreateReview(ctx context.Context, pandalID primitive.ObjectID, userID string, req models.ReviewRequest) (*models.Review, error) {
+	pandal, err := s.pandalRepo.FindByID(ctx, pandalID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrPandalNotFound
+		}
+		return nil, err
+	}
+	if pandal.DeletedAt != nil || pandal.Status != models.StatusApproved {
+		return nil, ErrPandalNotRatable
+	}
+
+	now := time.Now()
+	review := models.Review{
+		ID:        primitive.NewObjectID(),
+		PandalID:  pandalID,
+		UserID:    userID,
+		Rating:    req.Rating,
+		Text:      req.Text,
+		Photos:    req.Photos,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if review.Photos == nil {
+		review.Photos = []string{}
+	}
+
+	if _, err := s.repo.Create(ctx, review); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrReviewExists
+		}
+		return nil, err
+	}
+
+	// A review whose rating never reached the average would skew it once edited or
+	// deleted, and would block the user from trying again, so it is taken back out.
+	// The rating is refused when the pandal was deleted or merged since it was checked.
+	if err := s.pandalRepo.ApplyRatingChange(ctx, pandalID, 0, review.Rating); err != nil {
+		deleted, delErr := s.repo.DeleteFromPandal(context.WithoutCancel(ctx), review.ID, pandalID)
+		if delErr != nil {
+			log.Printf("Error removing review %s after its rating failed to apply: %v", review.ID.Hex(), delErr)
+			return nil, err
+		}
+		// A merge already moved the review onto the pandal that survived, whose rating
+		// is recomputed from its reviews
+		if deleted.DeletedCount == 0 {
+			if moved, findErr := s.repo.FindByID(ctx, review.ID); findErr == nil {
+				return moved, nil
+			}
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrPandalNotRatable
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+// UpdateReview edits the caller's own review and adjusts the pandal average by the rating delta.
+// The delta is taken from the review as the update replaced it, so concurrent edits of
+// the same review each adjust the average from the rating they actually overwrote.
+func (s *reviewService) UpdateReview(ctx context.Context, id primitive.ObjectID, userID string, req models.ReviewRequest) (*models.Review, error) {
+	review, err := s.findOwnReview(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	review.Rating = req.Rating
+	review.Text = req.Text
+	review.Photos = req.Photos
+	if review.Photos == nil {
+		review.Photos = []string{}
+	}
+	review.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"rating":    review.Rating,
+			"text":      review.Text,
+			"photos":    review.Photos,
+			"updatedAt": review.UpdatedAt,
+		},
+	}
+	previous, err := s.repo.FindOneAndUpdateBefore(ctx, bson.M{"_id": id, "userId": userID}, update)
+	if err != nil {
+		// Deleted since it was read
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+
+	if previous.Rating != review.Rating {
+		if err := s.pandalRepo.ApplyRatingChange(ctx, review.PandalID, previous.Rating, review.Rating); err != nil {
+			return nil, err
+		}
+	}
+	return review, nil
+}
+
+// DeleteReview removes the caller's own review and takes its rating out of the pandal average
+func (s *reviewService) DeleteReview(ctx context.Context, id primitive.ObjectID, userID string) error {
+	review, err := s.findOwnReview(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	// A concurrent delete already adjusted the rating
+	if res.DeletedCount == 0 {
+		return ErrReviewNotFound
+	}
+
+	return s.pandalRepo.ApplyRatingChange(ctx, review.PandalID, review.Rating, 0)
+}
+
+// GetReviews returns one page of a pandal's reviews, newest first unless asked otherwise
+func (s *reviewService) GetReviews(ctx context.Context, pandalID primitive.ObjectID, page pagination.Params) ([]models.Review, string, error) {
+	page, err := page.WithDefaultSort(false)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.repo.FindPage(ctx, pandalID, page)
+}
+
+func (s *reviewService) findOwnReview(ctx context.Context, id primitive.ObjectID, userID string) (*models.Review, error) {
+	review, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+	if review.UserID != userID {
+		return nil, ErrNotReviewOwner
+	}
+	return review, nil
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
+)
+
+// CreatePersonalRoute stores a user's own itinerary, private unless asked otherwise.
+// Unlike curated routes it may start out empty and be filled in stop by stop.
+func (s *routeService) CreatePersonalRoute(ctx context.Context, req models.PersonalRouteRequest, ownerID string) (*models.Route, error) {
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityPrivate
+	}
+	if !visibility.IsValid() {
+		return nil, &RouteValidationError{Message: "invalid visibility: " + string(visibility)}
+	}
+
+	stops := req.Stops
+	if stops == nil {
+		stops = []primitive.ObjectID{}
+	}
+	pandals, err := s.validateStops(ctx, stops)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	route := models.Route{
+		ID:          primitive.NewObjectID(),
+		Title:       req.Title,
+		Description: req.Description,
+		Stops:       stops,
+		StopCount:   len(stops),
+		Duration:    estimateDuration(pandals),
+		CreatedBy:   ownerID,
+		Visibility:  visibility,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if _, err := s.repo.Create(ctx, route); err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// GetMyRoutes returns one page of the routes owned by ownerID, whatever their visibility
+func (s *routeService) GetMyRoutes(ctx context.Context, ownerID string, page pagination.Params) ([]models.Route, string, error) {
+	page, err := page.WithDefaultSort(false)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.repo.FindPage(ctx, bson.M{"createdBy": ownerID}, page)
+}
+
+// GetSharedRoute lets anyone holding a share token view an unlisted or public route
+func (s *routeService) GetSharedRoute(ctx context.Context, token string) (*models.RouteWithStops, error) {
+	if token == "" {
+		return nil, ErrRouteNotFound
+	}
+
+	filter := bson.M{
+		"shareToken": token,
+		"visibility": bson.M{"$in": bson.A{models.VisibilityUnlisted, models.VisibilityPublic}},
+	}
+	route, pandals, err := s.repo.FindOneWithStops(ctx, filter)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRouteNotFound
+		}
+		return nil, err
+	}
+
+	route.ShareToken = ""
+	return enrichRoute(route, pandals), nil
+}
+
+// UpdateRoute edits the title, description or visibility of an owned route
+func (s *routeService) UpdateRoute(ctx context.Context, id primitive.ObjectID, req models.UpdateRouteRequest, ownerID string) (*models.Route, error) {
+	route, err := s.findOwnRoute(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title != nil {
+		if *req.Title == "" {
+			return nil, &RouteValidationError{Message: "title cannot be empty"}
+		}
+		route.Title = *req.Title
+	}
+	if req.Description != nil {
+		route.Description = *req.Description
+	}
+	if req.Visibility != nil {
+		if !req.Visibility.IsValid() {
+			return nil, &RouteValidationError{Message: "invalid visibility: " + string(*req.Visibility)}
+		}
+		route.Visibility = *req.Visibility
+	}
+	route.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"title":       route.Title,
+			"description": route.Description,
+			"visibility":  route.Visibility,
+			"updatedAt":   route.UpdatedAt,
+		},
+	}
+	if _, err := s.repo.Update(ctx, id, update); err != nil {
+		return nil, err
+	}
+	return route, nil
+}
+
+// AddStop inserts a pandal at the requested position, or at the end
+func (s *routeService) AddStop(ctx context.Context, id primitive.ObjectID, req models.AddStopRequest, ownerID string) (*models.Route, error) {
+	route, err := s.findOwnRoute(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	position := len(route.Stops)
+	if req.Position != nil && *req.Position < position {
+		position = *req.Position
+	}
+
+	stops := make([]primitive.ObjectID, 0, len(route.Stops)+1)
+	stops = append(stops, route.Stops[:position]...)
+	stops = append(stops, req.PandalID)
+	stops = append(stops, route.Stops[position:]...)
+
+	return s.saveStops(ctx, route, stops, []primitive.ObjectID{req.PandalID})
+}
+
+// RemoveStop drops a pandal from an owned route
+func (s *routeService) RemoveStop(ctx context.Context, id, pandalID primitive.ObjectID, ownerID string) (*models.Route, error) {
+	route, err := s.findOwnRoute(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	stops := make([]primitive.ObjectID, 0, len(route.Stops))
+	for _, stop := range route.Stops {
+		if stop != pandalID {
+			stops = append(stops, stop)
+		}
+	}
+	if len(stops) == len(route.Stops) {
+		return nil, &RouteValidationError{Message: "pandal is not a stop of this route", MissingStops: []primitive.ObjectID{pandalID}}
+	}
+
+	return s.saveStops(ctx, route, stops, nil)
+}
+
+// ReorderStops replaces the stop order; stops must be a permutation of the current stops
+func (s *routeService) ReorderStops(ctx context.Context, id primitive.ObjectID, stops []primitive.ObjectID, ownerID string) (*models.Route, error) {
+	route, err := s.findOwnRoute(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[primitive.ObjectID]int, len(route.Stops))
+	for _, stop := range route.Stops {
+		current[stop]++
+	}
+	for _, stop := range stops {
+		current[stop]--
+	}
+	for _, count := range current {
+		if count != 0 {
+			return nil, &RouteValidationError{Message: "stops must contain exactly the route's current stops in the new order"}
+		}
+	}
+
+	return s.saveStops(ctx, route, stops, nil)
+}
+
+// CloneRoute copies a route the caller can see into a new private route they own
+func (s *routeService) CloneRoute(ctx context.Context, id primitive.ObjectID, ownerID string) (*models.Route, error) {
+	source, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRouteNotFound
+		}
+		return nil, err
+	}
+	if source.CreatedBy != ownerID && source.EffectiveVisibility() != models.VisibilityPublic {
+		return nil, ErrRouteNotFound
+	}
+
+	now := time.Now()
+	clone := models.Route{
+		ID:          primitive.NewObjectID(),
+		Title:       source.Title,
+		Description: source.Description,
+		Duration:    source.Duration,
+		Stops:       append([]primitive.ObjectID{}, source.Stops...),
+		StopCount:   len(source.Stops),
+		CreatedBy:   ownerID,
+		Visibility:  models.VisibilityPrivate,
+		ClonedFrom:  &source.ID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if _, err := s.repo.Create(ctx, clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// ShareRoute issues a share token for an owned route, making a private route unlisted.
+// Calling it again rotates the token, cutting off anyone holding the old link.
+func (s *routeService) ShareRoute(ctx context.Context, id primitive.ObjectID, ownerID string) (*models.Route, error) {
+	route, err := s.findOwnRoute(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, err
+	}
+	route.ShareToken = token
+	if route.EffectiveVisibility() == models.VisibilityPrivate {
+		route.Visibility = models.VisibilityUnlisted
+	}
+	route.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"shareToken": route.ShareToken,
+			"visibility": route.Visibility,
+			"updatedAt":  route.UpdatedAt,
+		},
+	}
+	if _, err := s.repo.Update(ctx, id, update); err != nil {
+		return nil, err
+	}
+	return route, nil
+}
+
+// UnshareRoute revokes the share token of an owned route
+func (s *routeService) UnshareRoute(ctx context.Context, id primitive.ObjectID, ownerID string) (*models.Route, error) {
+	route, err := s.findOwnRoute(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	route.ShareToken = ""
+	route.UpdatedAt = time.Now()
+	update := bson.M{
+		"$set":   bson.M{"updatedAt": route.UpdatedAt},
+		"$unset": bson.M{"shareToken": ""},
+	}
+	if _, err := s.repo.Update(ctx, id, update); err != nil {
+		return nil, err
+	}
+	return route, nil
+}
+
+// findOwnRoute loads a route and checks that ownerID created it. Someone else's route
+// is reported as not found unless it is public.
+func (s *routeService) findOwnRoute(ctx context.Context, id primitive.ObjectID, ownerID string) (*models.Route, error) {
+	route, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRouteNotFound
+		}
+		return nil, err
+	}
+	if route.CreatedBy == "" || route.CreatedBy != ownerID {
+		// Like GetRouteByID, routes the caller cannot see do not exist as far as they know
+		if route.EffectiveVisibility() != models.VisibilityPublic {
+			return nil, ErrRouteNotFound
+		}
+		return nil, ErrNotRouteOwner
+	}
+	return route, nil
+}
+
+// saveStops persists a new stop list for route with a fresh duration. Only the added
+// stops are validated: stops already on the route may have been deleted or unapproved
+// since, and that must not keep the owner from reordering or removing them.
+func (s *routeService) saveStops(ctx context.Context, route *models.Route, stops, added []primitive.ObjectID) (*models.Route, error) {
+	if len(stops) > maxRouteStops {
+		return nil, &RouteValidationError{Message: fmt.Sprintf("a route can have at most %d stops", maxRouteStops)}
+	}
+	seen := make(map[primitive.ObjectID]bool, len(stops))
+	for _, id := range stops {
+		if seen[id] {
+			return nil, &RouteValidationError{Message: "route contains invalid stops", DuplicateStops: []primitive.ObjectID{id}}
+		}
+		seen[id] = true
+	}
+	if _, err := s.validateStops(ctx, added); err != nil {
+		return nil, err
+	}
+
+	pandals, err := s.usableStops(ctx, stops)
+	if err != nil {
+		return nil, err
+	}
+
+	route.Stops = stops
+	route.StopCount = len(stops)
+	route.Duration = estimateDuration(pandals)
+	route.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"$set": bson.M{
+			"stops":     route.Stops,
+			"stopCount": route.StopCount,
+			"duration":  route.Duration,
+			"updatedAt": route.UpdatedAt,
+		},
+	}
+	if _, err := s.repo.Update(ctx, route.ID, update); err != nil {
+		return nil, err
+	}
+	return route, nil
+}
+
+// usableStops returns the live, approved pandals among stops, in stop order, which
+// is what the route's duration is estimated from
+func (s *routeService) usableStops(ctx context.Context, stops []primitive.ObjectID) ([]models.Pandal, error) {
+	if len(stops) == 0 {
+		return []models.Pandal{}, nil
+	}
+	found, err := s.pandalRepo.FindAll(ctx, bson.M{
+		"_id":       bson.M{"$in": stops},
+		"status":    models.StatusApproved,
+		"deletedAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]models.Pandal, len(found))
+	for _, pandal := range found {
+		byID[pandal.ID] = pandal
+	}
+
+	pandals := make([]models.Pandal, 0, len(stops))
+	for _, id := range stops {
+		if pandal, ok := byID[id]; ok {
+			pandals = append(pandals, pandal)
+		}
+	}
+	return pandals, nil
+}
+
+// newShareToken returns a random URL-safe token for unlisted routes
+func newShareToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
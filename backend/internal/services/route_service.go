@@ -2,49 +2,429 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
 	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/pagination"
 	"tirthankarkundu17/pandal-hopping-api/internal/repository"
+	"tirthankarkundu17/pandal-hopping-api/internal/validation"
 )
 
-// RouteService defines business logic for curated routes
+// RouteService defines business logic for curated routes and user itineraries
 type RouteService interface {
-	CreateRoute(ctx context.Context, route models.Route) (*models.Route, error)
-	GetRoutes(ctx context.Context) ([]models.Route, error)
-	GetRouteByID(ctx context.Context, id primitive.ObjectID) (*models.Route, error)
+	CreateRoute(ctx context.Context, route models.Route, creatorID string) (*models.Route, error)
+	GetRoutes(ctx context.Context, page pagination.Params) ([]models.Route, string, error)
+	GetRouteByID(ctx context.Context, id primitive.ObjectID, viewerID string) (*models.RouteWithStops, error)
+	PlanRoute(ctx context.Context, req models.PlanRouteRequest) (*models.RoutePlan, error)
+
+	CreatePersonalRoute(ctx context.Context, req models.PersonalRouteRequest, ownerID string) (*models.Route, error)
+	GetMyRoutes(ctx context.Context, ownerID string, page pagination.Params) ([]models.Route, string, error)
+	GetSharedRoute(ctx context.Context, token string) (*models.RouteWithStops, error)
+	UpdateRoute(ctx context.Context, id primitive.ObjectID, req models.UpdateRouteRequest, ownerID string) (*models.Route, error)
+	AddStop(ctx context.Context, id primitive.ObjectID, req models.AddStopRequest, ownerID string) (*models.Route, error)
+	RemoveStop(ctx context.Context, id, pandalID primitive.ObjectID, ownerID string) (*models.Route, error)
+	ReorderStops(ctx context.Context, id primitive.ObjectID, stops []primitive.ObjectID, ownerID string) (*models.Route, error)
+	CloneRoute(ctx context.Context, id primitive.ObjectID, ownerID string) (*models.Route, error)
+	ShareRoute(ctx context.Context, id primitive.ObjectID, ownerID string) (*models.Route, error)
+	UnshareRoute(ctx context.Context, id primitive.ObjectID, ownerID string) (*models.Route, error)
+}
+
+var (
+	// ErrInvalidPlanRequest wraps problems with the caller's plan request
+	ErrInvalidPlanRequest = errors.New("invalid route plan request")
+
+	ErrRouteNotFound = errors.New("route not found")
+	ErrNotRouteOwner = errors.New("only the owner can modify this route")
+)
+
+// RouteValidationError lists the stops that prevented a route from being saved
+type RouteValidationError struct {
+	Message          string               `json:"error"`
+	DuplicateStops   []primitive.ObjectID `json:"duplicateStops,omitempty"`
+	MissingStops     []primitive.ObjectID `json:"missingStops,omitempty"`
+	UnavailableStops []primitive.ObjectID `json:"unavailableStops,omitempty"` // deleted, not approved or (when planning) unpinned
 }
 
+func (e *RouteValidationError) Error() string {
+	return e.Message
+}
+
+const (
+	// maxRouteStops caps how many stops a saved route may have
+	maxRouteStops = 25
+
+	// maxPlanStops caps how many pandals the optimizer will order in one request
+	maxPlanStops = 50
+
+	defaultVisitMinutes = 15
+)
+
 type routeService struct {
-	repo repository.RouteRepository
+	repo       repository.RouteRepository
+	pandalRepo repository.PandalRepository
 }
 
 // NewRouteService creates a new service instance
-func NewRouteService(repo repository.RouteRepository) RouteService {
-	return &routeService{repo: repo}
+func NewRouteService(repo repository.RouteRepository, pandalRepo repository.PandalRepository) RouteService {
+	return &routeService{repo: repo, pandalRepo: pandalRepo}
 }
 
-func (s *routeService) CreateRoute(ctx context.Context, route models.Route) (*models.Route, error) {
-	route.ID = primitive.NewObjectID()
-	route.CreatedAt = time.Now()
-	if route.Stops == nil {
-		route.Stops = []primitive.ObjectID{}
+// CreateRoute validates the stops and stores a new public curated route.
+// Duration is always derived from the stops' geography, never taken from the client.
+func (s *routeService) CreateRoute(ctx context.Context, route models.Route, creatorID string) (*models.Route, error) {
+	if len(route.Stops) == 0 {
+		return nil, &RouteValidationError{Message: "a route needs at least one stop"}
+	}
+	pandals, err := s.validateStops(ctx, route.Stops)
+	if err != nil {
+		return nil, err
 	}
+
+	now := time.Now()
+	route.ID = primitive.NewObjectID()
+	route.CreatedBy = creatorID
+	route.Curated = true
+	route.Visibility = models.VisibilityPublic
+	route.ShareToken = ""
+	route.ClonedFrom = nil
+	route.CreatedAt = now
+	route.UpdatedAt = now
 	route.StopCount = len(route.Stops)
+	route.Duration = estimateDuration(pandals)
 
-	_, err := s.repo.Create(ctx, route)
+	_, err = s.repo.Create(ctx, route)
 	if err != nil {
 		return nil, err
 	}
 	return &route, nil
 }
 
-func (s *routeService) GetRoutes(ctx context.Context) ([]models.Route, error) {
-	return s.repo.FindAll(ctx)
+// validateStops checks that stops is a duplicate-free list of approved pandals
+// within maxRouteStops, and returns the pandals in stop order
+func (s *routeService) validateStops(ctx context.Context, stops []primitive.ObjectID) ([]models.Pandal, error) {
+	if len(stops) == 0 {
+		return []models.Pandal{}, nil
+	}
+	if len(stops) > maxRouteStops {
+		return nil, &RouteValidationError{Message: fmt.Sprintf("a route can have at most %d stops", maxRouteStops)}
+	}
+
+	verr := &RouteValidationError{
+		DuplicateStops:   []primitive.ObjectID{},
+		MissingStops:     []primitive.ObjectID{},
+		UnavailableStops: []primitive.ObjectID{},
+	}
+
+	seen := make(map[primitive.ObjectID]bool, len(stops))
+	for _, id := range stops {
+		if seen[id] {
+			verr.DuplicateStops = append(verr.DuplicateStops, id)
+		}
+		seen[id] = true
+	}
+
+	found, err := s.pandalRepo.FindAll(ctx, bson.M{"_id": bson.M{"$in": stops}})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]models.Pandal, len(found))
+	for _, pandal := range found {
+		byID[pandal.ID] = pandal
+	}
+
+	pandals := make([]models.Pandal, 0, len(stops))
+	for _, id := range stops {
+		pandal, ok := byID[id]
+		switch {
+		case !ok:
+			verr.MissingStops = append(verr.MissingStops, id)
+		case pandal.DeletedAt != nil || pandal.Status != models.StatusApproved:
+			verr.UnavailableStops = append(verr.UnavailableStops, id)
+		default:
+			pandals = append(pandals, pandal)
+		}
+	}
+
+	if len(verr.DuplicateStops) > 0 || len(verr.MissingStops) > 0 || len(verr.UnavailableStops) > 0 {
+		verr.Message = "route contains invalid stops"
+		return nil, verr
+	}
+	return pandals, nil
+}
+
+// estimateDuration turns the walk between consecutive stops plus a visit at each stop
+// into the human-readable form used by curated routes, e.g. "~2-3 Hours"
+func estimateDuration(pandals []models.Pandal) string {
+	if len(pandals) == 0 {
+		return ""
+	}
+	minutes := float64(defaultVisitMinutes * len(pandals))
+	for i := 1; i < len(pandals); i++ {
+		from, ok1 := geo.FromCoordinates(pandals[i-1].Location.Coordinates)
+		to, ok2 := geo.FromCoordinates(pandals[i].Location.Coordinates)
+		if ok1 && ok2 {
+			minutes += geo.WalkingMinutes(geo.Haversine(from, to))
+		}
+	}
+
+	if minutes < 60 {
+		// Round up to the next 5 minutes
+		return fmt.Sprintf("~%d Minutes", int(math.Ceil(minutes/5))*5)
+	}
+	low := int(math.Floor(minutes / 60))
+	high := int(math.Ceil(minutes / 60))
+	if low == high {
+		return fmt.Sprintf("~%d Hours", low)
+	}
+	return fmt.Sprintf("~%d-%d Hours", low, high)
+}
+
+// GetRoutes returns one page of public routes, curated and user-created
+func (s *routeService) GetRoutes(ctx context.Context, page pagination.Params) ([]models.Route, string, error) {
+	page, err := page.WithDefaultSort(false)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Routes stored before visibility existed have no such field and are public
+	filter := bson.M{"visibility": bson.M{"$in": bson.A{models.VisibilityPublic, nil}}}
+	routes, nextCursor, err := s.repo.FindPage(ctx, filter, page)
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range routes {
+		routes[i].ShareToken = ""
+	}
+	return routes, nextCursor, nil
+}
+
+// GetRouteByID returns a route the viewer may see with its stop pandals in order.
+// Private and unlisted routes are reported as not found to everyone but their owner.
+func (s *routeService) GetRouteByID(ctx context.Context, id primitive.ObjectID, viewerID string) (*models.RouteWithStops, error) {
+	route, pandals, err := s.repo.FindOneWithStops(ctx, bson.M{"_id": id})
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrRouteNotFound
+		}
+		return nil, err
+	}
+
+	isOwner := route.CreatedBy != "" && route.CreatedBy == viewerID
+	if !isOwner && route.EffectiveVisibility() != models.VisibilityPublic {
+		return nil, ErrRouteNotFound
+	}
+	if !isOwner {
+		route.ShareToken = ""
+	}
+	return enrichRoute(route, pandals), nil
+}
+
+// enrichRoute expands a route's stops into pandals in order, computes the walking legs
+// between them and adds a warning for every stop that is missing, deleted or not approved
+func enrichRoute(route *models.Route, pandals []models.Pandal) *models.RouteWithStops {
+	found := make(map[primitive.ObjectID]models.Pandal, len(pandals))
+	for _, pandal := range pandals {
+		found[pandal.ID] = pandal
+	}
+
+	result := &models.RouteWithStops{
+		ID:          route.ID,
+		Title:       route.Title,
+		Description: route.Description,
+		Duration:    route.Duration,
+		Stops:       []models.Pandal{},
+		Legs:        []models.RouteLeg{},
+		Warnings:    []models.RouteWarning{},
+		CreatedBy:   route.CreatedBy,
+		Curated:     route.Curated,
+		Visibility:  route.EffectiveVisibility(),
+		ShareToken:  route.ShareToken,
+		ClonedFrom:  route.ClonedFrom,
+		CreatedAt:   route.CreatedAt,
+	}
+
+	for _, stopID := range route.Stops {
+		pandal, ok := found[stopID]
+		switch {
+		case !ok:
+			result.Warnings = append(result.Warnings, models.RouteWarning{PandalID: stopID, Reason: "missing"})
+			continue
+		case pandal.DeletedAt != nil:
+			result.Warnings = append(result.Warnings, models.RouteWarning{PandalID: stopID, Reason: "deleted"})
+			continue
+		case pandal.Status != models.StatusApproved:
+			result.Warnings = append(result.Warnings, models.RouteWarning{PandalID: stopID, Reason: string(pandal.Status)})
+			continue
+		}
+
+		if n := len(result.Stops); n > 0 {
+			prev := result.Stops[n-1]
+			from, ok1 := geo.FromCoordinates(prev.Location.Coordinates)
+			to, ok2 := geo.FromCoordinates(pandal.Location.Coordinates)
+			if ok1 && ok2 {
+				meters := geo.Haversine(from, to)
+				result.Legs = append(result.Legs, models.RouteLeg{
+					From:           prev.ID,
+					To:             pandal.ID,
+					DistanceMeters: math.Round(meters),
+					WalkingMinutes: math.Round(geo.WalkingMinutes(meters)),
+				})
+				result.TotalDistanceMeters += meters
+			}
+		}
+		result.Stops = append(result.Stops, pandal)
+	}
+
+	result.StopCount = len(result.Stops)
+	result.TotalDistanceMeters = math.Round(result.TotalDistanceMeters)
+	return result
+}
+
+// findPlannablePandals loads explicitly requested pandals. Rather than quietly planning
+// a shorter tour, it fails with a *RouteValidationError naming the pandals that do
+// not exist, are deleted or unapproved, or have no pin to walk to.
+func (s *routeService) findPlannablePandals(ctx context.Context, ids []primitive.ObjectID) ([]models.Pandal, error) {
+	found, err := s.pandalRepo.FindAll(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]models.Pandal, len(found))
+	for _, pandal := range found {
+		byID[pandal.ID] = pandal
+	}
+
+	verr := &RouteValidationError{
+		MissingStops:     []primitive.ObjectID{},
+		UnavailableStops: []primitive.ObjectID{},
+	}
+	pandals := make([]models.Pandal, 0, len(ids))
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		pandal, ok := byID[id]
+		_, pinned := geo.FromCoordinates(pandal.Location.Coordinates)
+		switch {
+		case !ok:
+			verr.MissingStops = append(verr.MissingStops, id)
+		case pandal.DeletedAt != nil || pandal.Status != models.StatusApproved || !pinned:
+			verr.UnavailableStops = append(verr.UnavailableStops, id)
+		default:
+			pandals = append(pandals, pandal)
+		}
+	}
+
+	if len(verr.MissingStops) > 0 || len(verr.UnavailableStops) > 0 {
+		verr.Message = "some requested pandals cannot be planned"
+		return nil, verr
+	}
+	return pandals, nil
 }
 
-func (s *routeService) GetRouteByID(ctx context.Context, id primitive.ObjectID) (*models.Route, error) {
-	return s.repo.FindByID(ctx, id)
+// PlanRoute orders the requested pandals into a short walking tour from the start point
+// and trims it to the time budget, counting both walking and visiting time
+func (s *routeService) PlanRoute(ctx context.Context, req models.PlanRouteRequest) (*models.RoutePlan, error) {
+	if err := validation.ValidateCoordinates(req.Start.Coordinates); err != nil {
+		return nil, fmt.Errorf("%w: start: %w", ErrInvalidPlanRequest, err)
+	}
+	start, _ := geo.FromCoordinates(req.Start.Coordinates)
+
+	visitMinutes := defaultVisitMinutes
+	if req.VisitMinutes != nil {
+		visitMinutes = *req.VisitMinutes
+	}
+
+	filter := bson.M{
+		"status":    models.StatusApproved,
+		"deletedAt": bson.M{"$exists": false},
+	}
+
+	var pandals []models.Pandal
+	var err error
+	if len(req.PandalIDs) > 0 {
+		if len(req.PandalIDs) > maxPlanStops {
+			return nil, fmt.Errorf("%w: at most %d pandals can be planned at once", ErrInvalidPlanRequest, maxPlanStops)
+		}
+		pandals, err = s.findPlannablePandals(ctx, req.PandalIDs)
+	} else {
+		if req.District == "" && req.Tag == "" {
+			return nil, fmt.Errorf("%w: provide pandalIds or a district/tag filter", ErrInvalidPlanRequest)
+		}
+		if req.District != "" {
+			filter["district"] = req.District
+		}
+		if req.Tag != "" {
+			filter["tags"] = bson.M{"$in": []string{req.Tag}}
+		}
+		// Only the pandals nearest the start are worth considering
+		filter["location"] = bson.M{
+			"$nearSphere": bson.M{
+				"$geometry": bson.M{
+					"type":        "Point",
+					"coordinates": []float64{start.Lng, start.Lat},
+				},
+			},
+		}
+		page := pagination.Params{Limit: maxPlanStops, Sort: pagination.SortDistance}
+		pandals, _, err = s.pandalRepo.FindPage(ctx, filter, page)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]geo.Point, 0, len(pandals))
+	located := make([]models.Pandal, 0, len(pandals))
+	for _, pandal := range pandals {
+		if point, ok := geo.FromCoordinates(pandal.Location.Coordinates); ok {
+			points = append(points, point)
+			located = append(located, pandal)
+		}
+	}
+
+	plan := &models.RoutePlan{
+		Stops:   []models.PlannedStop{},
+		Skipped: []primitive.ObjectID{},
+	}
+
+	budget := float64(req.TimeBudgetMinutes)
+	current := start
+	for _, idx := range geo.OrderTour(start, points) {
+		legMeters := geo.Haversine(current, points[idx])
+		legMinutes := geo.WalkingMinutes(legMeters)
+
+		// Once one stop does not fit, the rest of the tour is dropped so the order stays optimal
+		if len(plan.Skipped) > 0 || plan.TotalMinutes+legMinutes+float64(visitMinutes) > budget {
+			plan.Skipped = append(plan.Skipped, located[idx].ID)
+			continue
+		}
+
+		plan.TotalDistanceMeters += legMeters
+		plan.TotalWalkingMinutes += legMinutes
+		plan.TotalMinutes += legMinutes
+		plan.Stops = append(plan.Stops, models.PlannedStop{
+			Order:             len(plan.Stops) + 1,
+			Pandal:            located[idx],
+			LegDistanceMeters: math.Round(legMeters),
+			LegWalkingMinutes: math.Round(legMinutes),
+			ArrivalMinutes:    math.Round(plan.TotalMinutes),
+		})
+		plan.TotalMinutes += float64(visitMinutes)
+		current = points[idx]
+	}
+
+	plan.TotalDistanceMeters = math.Round(plan.TotalDistanceMeters)
+	plan.TotalWalkingMinutes = math.Round(plan.TotalWalkingMinutes)
+	plan.TotalMinutes = math.Round(plan.TotalMinutes)
+	return plan, nil
 }
@@ -0,0 +1,103 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+)
+
+// planStart is where the planned tours below begin
+var planStart = geo.Point{Lng: 88.36, Lat: 22.57}
+
+// pandalsEastOfStart pins approved pandals 1, 2 and 3 km due east of planStart
+func pandalsEastOfStart() []models.Pandal {
+	pandals := make([]models.Pandal, 0, 3)
+	for km := 1; km <= 3; km++ {
+		// A degree of longitude spans cos(lat) times a degree of latitude
+		lng := planStart.Lng + float64(km)*1000/(geo.EarthRadiusMeters*math.Pi/180*math.Cos(planStart.Lat*math.Pi/180))
+		pandals = append(pandals, models.Pandal{
+			ID:       primitive.NewObjectID(),
+			Name:     "Pandal",
+			Status:   models.StatusApproved,
+			Location: models.Location{Type: "Point", Coordinates: []float64{lng, planStart.Lat}},
+		})
+	}
+	return pandals
+}
+
+func TestPlanRouteFitsTheTimeBudget(t *testing.T) {
+	pandals := pandalsEastOfStart()
+	ids := []primitive.ObjectID{pandals[2].ID, pandals[0].ID, pandals[1].ID}
+	service := services.NewRouteService(nil, &memoryPandals{pandals: pandals})
+
+	// Every leg is 1 km, whichever pandal comes next on the way east
+	leg := geo.WalkingMinutes(1000)
+	visit := 10
+
+	tests := []struct {
+		name    string
+		budget  float64
+		visit   *int
+		stops   int
+		skipped int
+	}{
+		{"room for the whole tour", 3*leg + 3*float64(visit), &visit, 3, 0},
+		{"one minute short of the last visit", 3*leg + 3*float64(visit) - 1, &visit, 2, 1},
+		{"no time to reach the first pandal", leg, &visit, 0, 3},
+		{"walking only", 3 * leg, new(int), 3, 0},
+		{"default visit time", 3*leg + 3*15, nil, 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := service.PlanRoute(context.Background(), models.PlanRouteRequest{
+				Start:             models.Location{Type: "Point", Coordinates: []float64{planStart.Lng, planStart.Lat}},
+				PandalIDs:         ids,
+				TimeBudgetMinutes: int(math.Ceil(tt.budget)),
+				VisitMinutes:      tt.visit,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(plan.Stops) != tt.stops || len(plan.Skipped) != tt.skipped {
+				t.Fatalf("got %d stops and %d skipped, want %d and %d", len(plan.Stops), len(plan.Skipped), tt.stops, tt.skipped)
+			}
+			// The nearest pandals are visited first; the farthest are the ones left out
+			for i, stop := range plan.Stops {
+				if stop.Pandal.ID != pandals[i].ID || stop.Order != i+1 {
+					t.Fatalf("stop %d is %s (order %d), want %s", i, stop.Pandal.ID.Hex(), stop.Order, pandals[i].ID.Hex())
+				}
+			}
+			if plan.TotalMinutes > float64(int(math.Ceil(tt.budget))) {
+				t.Fatalf("plan takes %v minutes, over the %v minute budget", plan.TotalMinutes, math.Ceil(tt.budget))
+			}
+		})
+	}
+}
+
+func TestPlanRouteRejectsAStartOutOfRange(t *testing.T) {
+	service := services.NewRouteService(nil, &memoryPandals{pandals: pandalsEastOfStart()})
+
+	for name, coordinates := range map[string][]float64{
+		"latitude past the pole": {88.36, 95},
+		"longitude past 180":     {188.36, 22.57},
+		"missing latitude":       {88.36},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.PlanRoute(context.Background(), models.PlanRouteRequest{
+				Start:             models.Location{Type: "Point", Coordinates: coordinates},
+				PandalIDs:         []primitive.ObjectID{primitive.NewObjectID()},
+				TimeBudgetMinutes: 60,
+			})
+			if !errors.Is(err, services.ErrInvalidPlanRequest) {
+				t.Fatalf("got %v, want ErrInvalidPlanRequest", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/repository"
+	"tirthankarkundu17/pandal-hopping-api/internal/tiles"
+)
+
+// maxTileFeatures caps the features per layer of one tile; pandals keep the best rated
+const maxTileFeatures = 2000
+
+// Tile layer names, as referenced by map styles
+const (
+	PandalLayer   = "pandals"
+	FoodStopLayer = "food"
+)
+
+// TileInvalidator drops cached map tiles that show a changed pin
+type TileInvalidator interface {
+	InvalidatePoint(lng, lat float64)
+}
+
+// invalidateTiles tells invalidator, when there is one, about every changed location
+func invalidateTiles(invalidator TileInvalidator, locations ...models.Location) {
+	if invalidator == nil {
+		return
+	}
+	for _, location := range locations {
+		if point, ok := geo.FromCoordinates(location.Coordinates); ok {
+			invalidator.InvalidatePoint(point.Lng, point.Lat)
+		}
+	}
+}
+
+// TileService renders pandals and food stops as Mapbox Vector Tiles
+type TileService interface {
+	GetTile(ctx context.Context, tile tiles.Tile) ([]byte, error)
+}
+
+// tileService implements TileService
+type tileService struct {
+	pandalRepo   repository.PandalRepository
+	foodStopRepo repository.FoodStopRepository
+	cache        *tiles.Cache
+}
+
+// NewTileService creates a tile service serving from cache where possible
+func NewTileService(pandalRepo repository.PandalRepository, foodStopRepo repository.FoodStopRepository, cache *tiles.Cache) TileService {
+	return &tileService{
+		pandalRepo:   pandalRepo,
+		foodStopRepo: foodStopRepo,
+		cache:        cache,
+	}
+}
+
+// GetTile returns the encoded tile with a "pandals" layer of live pending and approved
+// pandals and a "food" layer of food stops
+func (s *tileService) GetTile(ctx context.Context, tile tiles.Tile) ([]byte, error) {
+	data, generation, ok := s.cache.Get(tile)
+	if ok {
+		return data, nil
+	}
+
+	location := tileFilter(tile)
+	pandals, _, err := s.pandalRepo.FindCapped(ctx, bson.M{
+		"status":    bson.M{"$in": bson.A{models.StatusApproved, models.StatusPending}},
+		"deletedAt": bson.M{"$exists": false},
+		"$and":      location,
+	}, maxTileFeatures)
+	if err != nil {
+		return nil, err
+	}
+	stops, _, err := s.foodStopRepo.FindCapped(ctx, bson.M{"$and": location}, maxTileFeatures)
+	if err != nil {
+		return nil, err
+	}
+
+	pandalLayer := tiles.Layer{Name: PandalLayer, Features: make([]tiles.Feature, 0, len(pandals))}
+	for _, pandal := range pandals {
+		point, ok := geo.FromCoordinates(pandal.Location.Coordinates)
+		if !ok {
+			continue
+		}
+		x, y := tile.Project(point)
+		pandalLayer.Features = append(pandalLayer.Features, tiles.Feature{X: x, Y: y, Properties: []tiles.Property{
+			{Key: "id", Value: pandal.ID.Hex()},
+			{Key: "name", Value: pandal.Name},
+			{Key: "status", Value: string(pandal.Status)},
+			{Key: "ratingAvg", Value: pandal.RatingAvg},
+			{Key: "ratingCount", Value: pandal.RatingCount},
+			{Key: "tags", Value: strings.Join(pandal.Tags, ",")},
+		}})
+	}
+
+	foodLayer := tiles.Layer{Name: FoodStopLayer, Features: make([]tiles.Feature, 0, len(stops))}
+	for _, stop := range stops {
+		point, ok := geo.FromCoordinates(stop.Location.Coordinates)
+		if !ok {
+			continue
+		}
+		x, y := tile.Project(point)
+		foodLayer.Features = append(foodLayer.Features, tiles.Feature{X: x, Y: y, Properties: []tiles.Property{
+			{Key: "id", Value: stop.ID.Hex()},
+			{Key: "name", Value: stop.Name},
+			{Key: "type", Value: stop.Type},
+		}})
+	}
+
+	data, err = tiles.Encode([]tiles.Layer{pandalLayer, foodLayer})
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Put(tile, data, generation)
+	return data, nil
+}
+
+// tileFilter matches points inside the tile and its buffer. The exact bounds are checked
+// on the coordinates themselves, because polygon edges are great circles and bow away from
+// lines of latitude. From zoom 5 tiles are narrow enough for that bow to stay within a
+// quarter-tile pad, so a padded $geoWithin polygon is added to let the 2dsphere index help.
+func tileFilter(tile tiles.Tile) bson.A {
+	sw, ne := tile.Bounds(float64(tiles.Buffer) / tiles.Extent)
+	filter := bson.A{
+		bson.M{"location.coordinates.0": bson.M{"$gte": sw.Lng, "$lte": ne.Lng}},
+		bson.M{"location.coordinates.1": bson.M{"$gte": sw.Lat, "$lte": ne.Lat}},
+	}
+
+	if tile.Z >= 5 {
+		outerSW, outerNE := tile.Bounds(0.25)
+		area := geo.Polygon{geo.Ring{
+			{Lng: outerSW.Lng, Lat: outerSW.Lat},
+			{Lng: outerNE.Lng, Lat: outerSW.Lat},
+			{Lng: outerNE.Lng, Lat: outerNE.Lat},
+			{Lng: outerSW.Lng, Lat: outerNE.Lat},
+			{Lng: outerSW.Lng, Lat: outerSW.Lat},
+		}}
+		filter = append(filter, bson.M{"location": withinFilter(area)})
+	}
+	return filter
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/lockout"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/repository"
+	"tirthankarkundu17/pandal-hopping-api/internal/validation"
+)
+
+// UserService defines business logic for managing user accounts
+type UserService interface {
+	SetRole(ctx context.Context, id primitive.ObjectID, role models.Role, actorID string) (*models.User, error)
+	BootstrapAdmin(ctx context.Context) error
+	Unlock(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	GetProfile(ctx context.Context, userID string) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID string, req models.UpdateProfileRequest) (*models.User, error)
+	ChangePassword(ctx context.Context, userID string, req models.ChangePasswordRequest, signedInAt time.Time) error
+	DeleteAccount(ctx context.Context, userID, password string, signedInAt time.Time) error
+}
+
+// recentSignInWindow is how long after signing in a user without a password may set
+// one or delete their account
+const recentSignInWindow = 10 * time.Minute
+
+// Errors returned by the self-service account operations
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrWrongPassword       = errors.New("password is incorrect")
+	ErrRecentSignInNeeded  = errors.New("sign in again to confirm it is you")
+	ErrAdminDeletion       = errors.New("admins must hand their role to someone else before deleting their account")
+	ErrUnsupportedLanguage = errors.New("unsupported language, expected one of: " + strings.Join(models.SupportedLanguages, ", "))
+	ErrInvalidAvatarURL    = errors.New("avatarUrl must be an http or https URL")
+	ErrInvalidProfile      = errors.New("invalid profile")
+	ErrInvalidRole         = errors.New("invalid role")
+	ErrSelfDemotion        = errors.New("admins cannot revoke their own admin role")
+)
+
+type userService struct {
+	repo       repository.UserRepository
+	attempts   lockout.Store
+	throttle   *loginThrottle
+	tokenRepo  repository.RefreshTokenRepository
+	pandalRepo repository.PandalRepository
+	reviewRepo repository.ReviewRepository
+	routeRepo  repository.RouteRepository
+}
+
+// NewUserService creates a new service instance. The pandal, review and route
+// repositories are used to anonymise a deleted user's contributions.
+func NewUserService(repo repository.UserRepository, attempts lockout.Store, tokenRepo repository.RefreshTokenRepository,
+	pandalRepo repository.PandalRepository, reviewRepo repository.ReviewRepository, routeRepo repository.RouteRepository) UserService {
+	return &userService{
+		repo:       repo,
+		attempts:   attempts,
+		throttle:   newLoginThrottle(attempts),
+		tokenRepo:  tokenRepo,
+		pandalRepo: pandalRepo,
+		reviewRepo: reviewRepo,
+		routeRepo:  routeRepo,
+	}
+}
+
+// SetRole grants a role to a user; revoking is granting RoleUser.
+// Admins cannot demote themselves so the system is never left without one by accident.
+func (s *userService) SetRole(ctx context.Context, id primitive.ObjectID, role models.Role, actorID string) (*models.User, error) {
+	if !role.IsValid() {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRole, role)
+	}
+	if id.Hex() == actorID && role != models.RoleAdmin {
+		return nil, ErrSelfDemotion
+	}
+
+	if err := s.repo.UpdateRole(ctx, id, role); err != nil {
+		return nil, userNotFound(err)
+	}
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, userNotFound(err)
+	}
+	return user, nil
+}
+
+// Unlock lifts a login lockout and forgets the account's failed attempts.
+// Lockouts of the client IPs involved are left to expire.
+func (s *userService) Unlock(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, userNotFound(err)
+	}
+	if err := s.attempts.Reset(ctx, accountKey(user.Email)); err != nil {
+		return nil, err
+	}
+	if user.LockedUntil != nil {
+		if err := s.repo.SetLockedUntil(ctx, id, nil); err != nil {
+			return nil, userNotFound(err)
+		}
+		user.LockedUntil = nil
+	}
+	return user, nil
+}
+
+// userNotFound turns the repository's missing user into ErrUserNotFound and passes
+// any other error through
+func userNotFound(err error) error {
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// BootstrapAdmin promotes the account named by BOOTSTRAP_ADMIN_EMAIL to admin once its
+// email address is verified. Otherwise it is promoted when the address is verified.
+func (s *userService) BootstrapAdmin(ctx context.Context) error {
+	email := strings.TrimSpace(os.Getenv("BOOTSTRAP_ADMIN_EMAIL"))
+	if email == "" {
+		return nil
+	}
+
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		log.Printf("Bootstrap admin %s not promoted (%v); they will be promoted once they sign up and verify their email", email, err)
+		return nil
+	}
+	if user.Role == models.RoleAdmin {
+		return nil
+	}
+	if !user.EmailVerified {
+		log.Printf("Bootstrap admin %s not promoted; they will be promoted once they verify their email", email)
+		return nil
+	}
+
+	if err := s.repo.UpdateRole(ctx, user.ID, models.RoleAdmin); err != nil {
+		return err
+	}
+	log.Printf("Promoted bootstrap admin %s", email)
+	return nil
+}
+
+// GetProfile returns the caller's own account
+func (s *userService) GetProfile(ctx context.Context, userID string) (*models.User, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// UpdateProfile changes the caller's name, avatar, language or home district
+func (s *userService) UpdateProfile(ctx context.Context, userID string, req models.UpdateProfileRequest) (*models.User, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := bson.M{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: name cannot be empty", ErrInvalidProfile)
+		}
+		fields["name"] = name
+	}
+	if req.AvatarURL != nil {
+		avatar := strings.TrimSpace(*req.AvatarURL)
+		if avatar != "" {
+			u, err := url.ParseRequestURI(avatar)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, ErrInvalidAvatarURL
+			}
+		}
+		fields["avatarUrl"] = avatar
+	}
+	if req.Language != nil {
+		language := strings.ToLower(strings.TrimSpace(*req.Language))
+		if language != "" && !slices.Contains(models.SupportedLanguages, language) {
+			return nil, ErrUnsupportedLanguage
+		}
+		fields["preferredLanguage"] = language
+	}
+	if req.HomeDistrict != nil {
+		district := strings.ToUpper(strings.TrimSpace(*req.HomeDistrict))
+		if district != "" {
+			if err := validation.ValidateDistrictCode(district); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidProfile, err)
+			}
+		}
+		fields["homeDistrict"] = district
+	}
+
+	if len(fields) == 0 {
+		return user, nil
+	}
+	if err := s.repo.UpdateProfile(ctx, user.ID, fields); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(ctx, user.ID)
+}
+
+// confirmUser checks password against the user's. A user without a password, who only
+// signs in with a provider, must instead have signed in within recentSignInWindow;
+// signedInAt is zero when the caller's token came from a refresh. Password checks
+// count towards the same account lockout as logins, so a stolen access token cannot
+// be used to guess the password without limit.
+func (s *userService) confirmUser(ctx context.Context, user *models.User, password string, signedInAt time.Time) error {
+	if user.Password == "" {
+		if signedInAt.IsZero() || time.Since(signedInAt) > recentSignInWindow {
+			return ErrRecentSignInNeeded
+		}
+		return nil
+	}
+
+	attempt, err := s.throttle.begin(ctx, user.Email, "")
+	if err != nil {
+		return err
+	}
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		if err := attempt.cancel(ctx); err != nil {
+			return err
+		}
+		return &LoginThrottledError{RetryAfter: time.Until(*user.LockedUntil)}
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		if lockedFor := attempt.lockout(); lockedFor > 0 {
+			until := time.Now().Add(lockedFor)
+			if err := s.repo.SetLockedUntil(ctx, user.ID, &until); err != nil {
+				return err
+			}
+		}
+		return ErrWrongPassword
+	}
+	if err := attempt.succeeded(ctx); err != nil {
+		return err
+	}
+	if user.LockedUntil != nil {
+		return s.repo.SetLockedUntil(ctx, user.ID, nil)
+	}
+	return nil
+}
+
+// ChangePassword replaces the caller's password after checking the current one, or
+// sets the first one. Every session is signed out, including the one making the change.
+func (s *userService) ChangePassword(ctx context.Context, userID string, req models.ChangePasswordRequest, signedInAt time.Time) error {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmUser(ctx, user, req.CurrentPassword, signedInAt); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePassword(ctx, user.ID, string(hashedPassword)); err != nil {
+		return err
+	}
+	return s.tokenRepo.RevokeAllForUser(ctx, userID)
+}
+
+// DeleteAccount removes the caller's account after confirming it is them.
+// Pandals, votes, reviews and shared routes they contributed stay, attributed to a
+// fresh placeholder ID so nothing points at the deleted user or links their activity
+// to them. The account itself is deleted last, so a failed attempt can be retried.
+func (s *userService) DeleteAccount(ctx context.Context, userID, password string, signedInAt time.Time) error {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmUser(ctx, user, password, signedInAt); err != nil {
+		return err
+	}
+	if user.Role == models.RoleAdmin {
+		return ErrAdminDeletion
+	}
+
+	if err := s.tokenRepo.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+
+	placeholder := models.DeletedUserPrefix + primitive.NewObjectID().Hex()
+	if err := s.pandalRepo.ReplaceUser(ctx, userID, placeholder); err != nil {
+		return err
+	}
+	if err := s.reviewRepo.ReplaceUser(ctx, userID, placeholder); err != nil {
+		return err
+	}
+	if err := s.routeRepo.ReplaceOwner(ctx, userID, placeholder); err != nil {
+		return err
+	}
+
+	if err := s.attempts.Reset(ctx, accountKey(user.Email)); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, user.ID)
+}
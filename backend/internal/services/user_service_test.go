@@ -0,0 +1,162 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/auth"
+	"tirthankarkundu17/pandal-hopping-api/internal/lockout"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+	"tirthankarkundu17/pandal-hopping-api/internal/services"
+)
+
+// providerOnlyUser stores a user who signed up with a provider and has no password
+func providerOnlyUser(t *testing.T, f *authFixture, email string) *models.User {
+	t.Helper()
+	user := &models.User{
+		Name:          "Provider",
+		Email:         email,
+		Role:          models.RoleUser,
+		EmailVerified: true,
+		Identities:    []models.LinkedIdentity{{Provider: "test", Subject: email}},
+	}
+	if err := f.users.CreateUser(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestProviderOnlyUserSetsPasswordAfterSigningIn(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	users := services.NewUserService(f.users, lockout.NewMemoryStore(), f.refreshTokens, nil, nil, nil)
+	user := providerOnlyUser(t, f, "provider@example.com")
+	req := models.ChangePasswordRequest{NewPassword: "first-password"}
+
+	for name, signedInAt := range map[string]time.Time{
+		"refreshed token": {},
+		"stale sign-in":   time.Now().Add(-11 * time.Minute),
+	} {
+		if err := users.ChangePassword(ctx, user.ID.Hex(), req, signedInAt); !errors.Is(err, services.ErrRecentSignInNeeded) {
+			t.Fatalf("%s: got %v, want ErrRecentSignInNeeded", name, err)
+		}
+	}
+
+	if err := users.ChangePassword(ctx, user.ID.Hex(), req, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("recent sign-in: %v", err)
+	}
+	login := models.LoginRequest{Email: "provider@example.com", Password: "first-password"}
+	if _, _, _, err := f.service.Login(ctx, login, "192.0.2.1"); err != nil {
+		t.Fatalf("login with the new password: %v", err)
+	}
+
+	// Once there is a password, a recent sign-in no longer stands in for it
+	req = models.ChangePasswordRequest{NewPassword: "second-password"}
+	if err := users.ChangePassword(ctx, user.ID.Hex(), req, time.Now()); !errors.Is(err, services.ErrWrongPassword) {
+		t.Fatalf("got %v, want ErrWrongPassword", err)
+	}
+}
+
+func TestProviderOnlyUserDeletionNeedsRecentSignIn(t *testing.T) {
+	f := newAuthFixture(t)
+	users := services.NewUserService(f.users, lockout.NewMemoryStore(), f.refreshTokens, nil, nil, nil)
+	user := providerOnlyUser(t, f, "leaving@example.com")
+
+	err := users.DeleteAccount(context.Background(), user.ID.Hex(), "", time.Now().Add(-time.Hour))
+	if !errors.Is(err, services.ErrRecentSignInNeeded) {
+		t.Fatalf("got %v, want ErrRecentSignInNeeded", err)
+	}
+	if _, err := f.users.FindByID(context.Background(), user.ID); err != nil {
+		t.Fatal("the account was deleted")
+	}
+}
+
+func TestOnlySignInsCarryAuthTime(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	idp := newFakeIDP(t)
+
+	access, refresh, err := f.signIn(t, idp, jwt.MapClaims{"sub": "new", "email": "new@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := f.keys.Parse(access, auth.KindAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := claims["auth_time"]; !ok {
+		t.Fatal("access token from a provider sign-in has no auth_time")
+	}
+
+	refreshed, _, _, err := f.service.Refresh(ctx, models.RefreshRequest{RefreshToken: refresh})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err = f.keys.Parse(refreshed, auth.KindAccess); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := claims["auth_time"]; ok {
+		t.Fatal("refreshed access token claims a sign-in")
+	}
+}
+
+// TestPasswordConfirmationIsThrottled guesses the current password through the account
+// endpoints, as someone holding a stolen access token would: the guesses lock the
+// account like failed logins do
+func TestPasswordConfirmationIsThrottled(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	users := services.NewUserService(f.users, f.attempts, f.refreshTokens, nil, nil, nil)
+	user, _ := f.register(t, "guessed@example.com")
+
+	for i := range services.AccountLockoutPolicy.Threshold {
+		req := models.ChangePasswordRequest{CurrentPassword: "guess", NewPassword: "new-password"}
+		if err := users.ChangePassword(ctx, user.ID.Hex(), req, time.Now()); !errors.Is(err, services.ErrWrongPassword) {
+			t.Fatalf("guess %d: got %v, want ErrWrongPassword", i+1, err)
+		}
+	}
+
+	var throttled *services.LoginThrottledError
+	if err := users.DeleteAccount(ctx, user.ID.Hex(), "old-password", time.Now()); !errors.As(err, &throttled) {
+		t.Fatalf("deletion after the guesses: got %v, want LoginThrottledError", err)
+	}
+	login := models.LoginRequest{Email: "guessed@example.com", Password: "old-password"}
+	if _, _, _, err := f.service.Login(ctx, login, "192.0.2.1"); !errors.As(err, &throttled) {
+		t.Fatalf("login after the guesses: got %v, want LoginThrottledError", err)
+	}
+}
+
+func TestSetRoleErrors(t *testing.T) {
+	f := newAuthFixture(t)
+	ctx := context.Background()
+	users := services.NewUserService(f.users, lockout.NewMemoryStore(), f.refreshTokens, nil, nil, nil)
+	admin := providerOnlyUser(t, f, "admin@example.com")
+	member := providerOnlyUser(t, f, "member@example.com")
+
+	tests := []struct {
+		name  string
+		id    primitive.ObjectID
+		role  models.Role
+		actor string
+		want  error
+	}{
+		{"unknown role", member.ID, "owner", admin.ID.Hex(), services.ErrInvalidRole},
+		{"admin demoting themselves", admin.ID, models.RoleUser, admin.ID.Hex(), services.ErrSelfDemotion},
+		{"no such user", primitive.NewObjectID(), models.RoleModerator, admin.ID.Hex(), services.ErrUserNotFound},
+		{"granted", member.ID, models.RoleModerator, admin.ID.Hex(), nil},
+	}
+	for _, tt := range tests {
+		if _, err := users.SetRole(ctx, tt.id, tt.role, tt.actor); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := users.Unlock(ctx, primitive.NewObjectID()); !errors.Is(err, services.ErrUserNotFound) {
+		t.Errorf("unlocking a missing user: got %v, want ErrUserNotFound", err)
+	}
+}
@@ -0,0 +1,98 @@
+package tiles
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+)
+
+// Cache keeps recently encoded tiles in memory, evicting the least recently used
+// once full. Entries also expire after a TTL, which bounds staleness from changes
+// that are not invalidated explicitly, such as a new review shifting a rating.
+type Cache struct {
+	mu         sync.Mutex
+	capacity   int
+	ttl        time.Duration
+	entries    map[Tile]*list.Element
+	order      *list.List // front is most recently used
+	generation uint64
+}
+
+// cacheEntry is one cached tile
+type cacheEntry struct {
+	tile    Tile
+	data    []byte
+	expires time.Time
+}
+
+// NewCache creates a cache holding up to capacity tiles for at most ttl each
+func NewCache(capacity int, ttl time.Duration) *Cache {
+	return &Cache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[Tile]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the cached tile and the cache generation. The generation must be
+// passed back to Put so a tile built from data read before an invalidation is not stored.
+func (c *Cache) Get(t Tile) ([]byte, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[t]
+	if !ok {
+		return nil, c.generation, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.remove(elem)
+		return nil, c.generation, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.data, c.generation, true
+}
+
+// Put stores a tile built after Get returned generation, unless tiles have been
+// invalidated since
+func (c *Cache) Put(t Tile, data []byte, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	if elem, ok := c.entries[t]; ok {
+		c.remove(elem)
+	}
+	c.entries[t] = c.order.PushFront(&cacheEntry{tile: t, data: data, expires: time.Now().Add(c.ttl)})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// InvalidatePoint drops every cached tile, at every zoom, that shows a feature at lng/lat
+func (c *Cache) InvalidatePoint(lng, lat float64) {
+	p := geo.Point{Lng: lng, Lat: lat}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for z := 0; z <= MaxZoom; z++ {
+		for _, t := range Covering(p, z) {
+			if elem, ok := c.entries[t]; ok {
+				c.remove(elem)
+			}
+		}
+	}
+}
+
+// remove deletes an entry; the caller holds mu
+func (c *Cache) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).tile)
+}
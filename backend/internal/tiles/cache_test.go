@@ -0,0 +1,101 @@
+package tiles_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+	"tirthankarkundu17/pandal-hopping-api/internal/tiles"
+)
+
+func TestCachePutAfterInvalidationIsDropped(t *testing.T) {
+	cache := tiles.NewCache(16, time.Hour)
+	pin := geo.Point{Lng: 88.36, Lat: 22.57}
+	tile := tiles.Covering(pin, 14)[0]
+
+	// A request misses and starts building the tile from the database
+	if _, _, ok := cache.Get(tile); ok {
+		t.Fatal("empty cache returned a tile")
+	}
+	_, generation, _ := cache.Get(tile)
+
+	// Meanwhile the pin moves, so what the request read is already stale
+	cache.InvalidatePoint(pin.Lng, pin.Lat)
+	cache.Put(tile, []byte("stale"), generation)
+	if data, _, ok := cache.Get(tile); ok {
+		t.Fatalf("tile built before the invalidation was cached: %q", data)
+	}
+
+	// A tile built after the invalidation is kept
+	_, generation, _ = cache.Get(tile)
+	cache.Put(tile, []byte("fresh"), generation)
+	if data, _, ok := cache.Get(tile); !ok || string(data) != "fresh" {
+		t.Fatalf("got %q (cached %v), want the fresh tile", data, ok)
+	}
+}
+
+func TestCacheInvalidatePointDropsEveryZoom(t *testing.T) {
+	cache := tiles.NewCache(4*(tiles.MaxZoom+1), time.Hour)
+	pin := geo.Point{Lng: 88.36, Lat: 22.57}
+	elsewhere := geo.Point{Lng: 77.21, Lat: 28.61}
+
+	_, generation, _ := cache.Get(tiles.Tile{})
+	var pinned, unrelated []tiles.Tile
+	for z := 0; z <= tiles.MaxZoom; z++ {
+		pinned = append(pinned, tiles.Covering(pin, z)...)
+		// At the lowest zooms both points are on the same tiles
+		for _, tile := range tiles.Covering(elsewhere, z) {
+			if !slices.Contains(pinned, tile) {
+				unrelated = append(unrelated, tile)
+			}
+		}
+	}
+	for _, tile := range append(pinned, unrelated...) {
+		cache.Put(tile, []byte(tile.String()), generation)
+	}
+
+	cache.InvalidatePoint(pin.Lng, pin.Lat)
+	for _, tile := range pinned {
+		if _, _, ok := cache.Get(tile); ok {
+			t.Fatalf("tile %s showing the pin is still cached", tile)
+		}
+	}
+	for _, tile := range unrelated {
+		if _, _, ok := cache.Get(tile); !ok {
+			t.Fatalf("tile %s away from the pin was dropped", tile)
+		}
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := tiles.NewCache(2, time.Hour)
+	a, b, c := tiles.Tile{Z: 1, X: 0, Y: 0}, tiles.Tile{Z: 1, X: 1, Y: 0}, tiles.Tile{Z: 1, X: 0, Y: 1}
+
+	_, generation, _ := cache.Get(a)
+	cache.Put(a, []byte("a"), generation)
+	cache.Put(b, []byte("b"), generation)
+	cache.Get(a)
+	cache.Put(c, []byte("c"), generation)
+
+	if _, _, ok := cache.Get(b); ok {
+		t.Fatal("the least recently used tile was kept")
+	}
+	for _, tile := range []tiles.Tile{a, c} {
+		if _, _, ok := cache.Get(tile); !ok {
+			t.Fatalf("tile %s was evicted", tile)
+		}
+	}
+}
+
+func TestCacheEntriesExpire(t *testing.T) {
+	cache := tiles.NewCache(2, time.Nanosecond)
+	tile := tiles.Tile{Z: 1, X: 0, Y: 0}
+
+	_, generation, _ := cache.Get(tile)
+	cache.Put(tile, []byte("tile"), generation)
+	time.Sleep(time.Millisecond)
+	if _, _, ok := cache.Get(tile); ok {
+		t.Fatal("an expired tile was served")
+	}
+}
@@ -0,0 +1,163 @@
+package tiles
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ContentType is the media type of an encoded tile
+const ContentType = "application/vnd.mapbox-vector-tile"
+
+// Field numbers and values from the Mapbox Vector Tile 2.1 spec (vector_tile.proto)
+const (
+	tileLayers = 3
+
+	layerName     = 1
+	layerFeatures = 2
+	layerKeys     = 3
+	layerValues   = 4
+	layerExtent   = 5
+	layerVersion  = 15
+
+	featureTags     = 2
+	featureType     = 3
+	featureGeometry = 4
+
+	valueString = 1
+	valueDouble = 3
+	valueSint   = 6
+	valueBool   = 7
+
+	geomPoint = 1
+	cmdMoveTo = 1
+)
+
+// Layer is a named layer of point features
+type Layer struct {
+	Name     string
+	Features []Feature
+}
+
+// Feature is a point in tile coordinates (see Tile.Project) with its properties
+type Feature struct {
+	X, Y       int
+	Properties []Property
+}
+
+// Property is a feature attribute. Value must be a string, float64, int or bool;
+// MVT has no arrays, so lists should be joined into a string.
+type Property struct {
+	Key   string
+	Value any
+}
+
+// Encode serialises layers into a Mapbox Vector Tile
+func Encode(layers []Layer) ([]byte, error) {
+	var tile []byte
+	for _, layer := range layers {
+		encoded, err := encodeLayer(layer)
+		if err != nil {
+			return nil, err
+		}
+		tile = protowire.AppendTag(tile, tileLayers, protowire.BytesType)
+		tile = protowire.AppendBytes(tile, encoded)
+	}
+	return tile, nil
+}
+
+// encodeLayer writes one layer, sharing repeated keys and values between its features
+func encodeLayer(layer Layer) ([]byte, error) {
+	keyIndex := make(map[string]uint64)
+	valueIndex := make(map[string]uint64)
+	var keys []string
+	var values [][]byte
+
+	var features [][]byte
+	for _, feature := range layer.Features {
+		var tags []byte
+		for _, prop := range feature.Properties {
+			k, ok := keyIndex[prop.Key]
+			if !ok {
+				k = uint64(len(keys))
+				keyIndex[prop.Key] = k
+				keys = append(keys, prop.Key)
+			}
+
+			// Values are matched by their encoding: an unsupported value, such as a
+			// slice, could not even be used as a map key
+			encoded, err := encodeValue(prop.Value)
+			if err != nil {
+				return nil, fmt.Errorf("layer %s, property %s: %w", layer.Name, prop.Key, err)
+			}
+			v, ok := valueIndex[string(encoded)]
+			if !ok {
+				v = uint64(len(values))
+				valueIndex[string(encoded)] = v
+				values = append(values, encoded)
+			}
+
+			tags = protowire.AppendVarint(tags, k)
+			tags = protowire.AppendVarint(tags, v)
+		}
+
+		// A single MoveTo; coordinates are zigzag encoded deltas from the origin
+		var geometry []byte
+		geometry = protowire.AppendVarint(geometry, cmdMoveTo|1<<3)
+		geometry = protowire.AppendVarint(geometry, protowire.EncodeZigZag(int64(feature.X)))
+		geometry = protowire.AppendVarint(geometry, protowire.EncodeZigZag(int64(feature.Y)))
+
+		var encoded []byte
+		encoded = protowire.AppendTag(encoded, featureTags, protowire.BytesType)
+		encoded = protowire.AppendBytes(encoded, tags)
+		encoded = protowire.AppendTag(encoded, featureType, protowire.VarintType)
+		encoded = protowire.AppendVarint(encoded, geomPoint)
+		encoded = protowire.AppendTag(encoded, featureGeometry, protowire.BytesType)
+		encoded = protowire.AppendBytes(encoded, geometry)
+		features = append(features, encoded)
+	}
+
+	var b []byte
+	b = protowire.AppendTag(b, layerVersion, protowire.VarintType)
+	b = protowire.AppendVarint(b, 2)
+	b = protowire.AppendTag(b, layerName, protowire.BytesType)
+	b = protowire.AppendString(b, layer.Name)
+	for _, feature := range features {
+		b = protowire.AppendTag(b, layerFeatures, protowire.BytesType)
+		b = protowire.AppendBytes(b, feature)
+	}
+	for _, key := range keys {
+		b = protowire.AppendTag(b, layerKeys, protowire.BytesType)
+		b = protowire.AppendString(b, key)
+	}
+	for _, value := range values {
+		b = protowire.AppendTag(b, layerValues, protowire.BytesType)
+		b = protowire.AppendBytes(b, value)
+	}
+	b = protowire.AppendTag(b, layerExtent, protowire.VarintType)
+	b = protowire.AppendVarint(b, Extent)
+	return b, nil
+}
+
+// encodeValue writes a property value as a vector_tile Value message
+func encodeValue(value any) ([]byte, error) {
+	var b []byte
+	switch v := value.(type) {
+	case string:
+		b = protowire.AppendTag(b, valueString, protowire.BytesType)
+		b = protowire.AppendString(b, v)
+	case float64:
+		b = protowire.AppendTag(b, valueDouble, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(v))
+	case int:
+		b = protowire.AppendTag(b, valueSint, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeZigZag(int64(v)))
+	case bool:
+		b = protowire.AppendTag(b, valueBool, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(v))
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+	return b, nil
+}
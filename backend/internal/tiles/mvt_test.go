@@ -0,0 +1,245 @@
+package tiles_test
+
+import (
+	"math"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/tiles"
+)
+
+// field is one decoded protobuf field: varint and fixed64 values in num, bytes in raw
+type field struct {
+	number protowire.Number
+	num    uint64
+	raw    []byte
+}
+
+// decodeFields splits a protobuf message into its fields
+func decodeFields(t *testing.T, b []byte) []field {
+	t.Helper()
+	var fields []field
+	for len(b) > 0 {
+		number, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("bad tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		f := field{number: number}
+		switch typ {
+		case protowire.VarintType:
+			f.num, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			f.num, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			f.raw, n = protowire.ConsumeBytes(b)
+		default:
+			t.Fatalf("field %d has unexpected wire type %d", number, typ)
+		}
+		if n < 0 {
+			t.Fatalf("field %d: %v", number, protowire.ParseError(n))
+		}
+		b = b[n:]
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// decodedFeature is a point feature read back from a tile
+type decodedFeature struct {
+	geomType   uint64
+	x, y       int64
+	properties map[string]any
+}
+
+// decodedLayer is a layer read back from a tile
+type decodedLayer struct {
+	version  uint64
+	name     string
+	extent   uint64
+	keys     []string
+	values   []any
+	features []decodedFeature
+}
+
+// decodeLayer reads a vector_tile Layer, resolving feature tags against its keys and values
+func decodeLayer(t *testing.T, b []byte) decodedLayer {
+	t.Helper()
+	var layer decodedLayer
+	var rawFeatures [][]byte
+	for _, f := range decodeFields(t, b) {
+		switch f.number {
+		case 15:
+			layer.version = f.num
+		case 1:
+			layer.name = string(f.raw)
+		case 2:
+			rawFeatures = append(rawFeatures, f.raw)
+		case 3:
+			layer.keys = append(layer.keys, string(f.raw))
+		case 4:
+			layer.values = append(layer.values, decodeValue(t, f.raw))
+		case 5:
+			layer.extent = f.num
+		default:
+			t.Fatalf("unexpected layer field %d", f.number)
+		}
+	}
+
+	for _, raw := range rawFeatures {
+		feature := decodedFeature{properties: map[string]any{}}
+		for _, f := range decodeFields(t, raw) {
+			switch f.number {
+			case 2:
+				tags := decodeVarints(t, f.raw)
+				if len(tags)%2 != 0 {
+					t.Fatalf("odd number of tags %v", tags)
+				}
+				for i := 0; i < len(tags); i += 2 {
+					if tags[i] >= uint64(len(layer.keys)) || tags[i+1] >= uint64(len(layer.values)) {
+						t.Fatalf("tag pair %d/%d points past %d keys and %d values", tags[i], tags[i+1], len(layer.keys), len(layer.values))
+					}
+					feature.properties[layer.keys[tags[i]]] = layer.values[tags[i+1]]
+				}
+			case 3:
+				feature.geomType = f.num
+			case 4:
+				geometry := decodeVarints(t, f.raw)
+				// One MoveTo command (id 1) with a count of 1, then a zigzag encoded x and y
+				if len(geometry) != 3 || geometry[0] != 1|1<<3 {
+					t.Fatalf("geometry %v is not a single MoveTo", geometry)
+				}
+				feature.x = protowire.DecodeZigZag(geometry[1])
+				feature.y = protowire.DecodeZigZag(geometry[2])
+			default:
+				t.Fatalf("unexpected feature field %d", f.number)
+			}
+		}
+		layer.features = append(layer.features, feature)
+	}
+	return layer
+}
+
+// decodeValue reads a vector_tile Value message
+func decodeValue(t *testing.T, b []byte) any {
+	t.Helper()
+	fields := decodeFields(t, b)
+	if len(fields) != 1 {
+		t.Fatalf("value has %d fields, want 1", len(fields))
+	}
+	switch f := fields[0]; f.number {
+	case 1:
+		return string(f.raw)
+	case 3:
+		return math.Float64frombits(f.num)
+	case 6:
+		return int(protowire.DecodeZigZag(f.num))
+	case 7:
+		return protowire.DecodeBool(f.num)
+	default:
+		t.Fatalf("unexpected value field %d", f.number)
+		return nil
+	}
+}
+
+// decodeVarints reads a packed run of varints
+func decodeVarints(t *testing.T, b []byte) []uint64 {
+	t.Helper()
+	var values []uint64
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			t.Fatalf("bad varint: %v", protowire.ParseError(n))
+		}
+		values = append(values, v)
+		b = b[n:]
+	}
+	return values
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	layers := []tiles.Layer{
+		{Name: "pandals", Features: []tiles.Feature{
+			{X: 100, Y: 200, Properties: []tiles.Property{
+				{Key: "name", Value: "Bagbazar"},
+				{Key: "ratingAvg", Value: 4.5},
+				{Key: "ratingCount", Value: 12},
+				{Key: "approved", Value: true},
+			}},
+			// In the buffer past the north-west corner, so both coordinates are negative
+			{X: -30, Y: -1, Properties: []tiles.Property{
+				{Key: "name", Value: "Kumartuli"},
+				{Key: "ratingAvg", Value: 4.5},
+				{Key: "ratingCount", Value: -3},
+				{Key: "approved", Value: false},
+			}},
+		}},
+		{Name: "food", Features: []tiles.Feature{
+			{X: tiles.Extent + 10, Y: 0, Properties: []tiles.Property{{Key: "type", Value: "stall"}}},
+		}},
+		{Name: "empty"},
+	}
+
+	encoded, err := tiles.Encode(layers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []decodedLayer
+	for _, f := range decodeFields(t, encoded) {
+		if f.number != 3 {
+			t.Fatalf("unexpected tile field %d", f.number)
+		}
+		decoded = append(decoded, decodeLayer(t, f.raw))
+	}
+	if len(decoded) != len(layers) {
+		t.Fatalf("got %d layers, want %d", len(decoded), len(layers))
+	}
+
+	for i, want := range layers {
+		got := decoded[i]
+		if got.name != want.Name || got.version != 2 || got.extent != tiles.Extent {
+			t.Fatalf("layer %d: got name %q, version %d, extent %d", i, got.name, got.version, got.extent)
+		}
+		if len(got.features) != len(want.Features) {
+			t.Fatalf("layer %s: got %d features, want %d", want.Name, len(got.features), len(want.Features))
+		}
+		for j, wantFeature := range want.Features {
+			gotFeature := got.features[j]
+			if gotFeature.geomType != 1 {
+				t.Fatalf("layer %s feature %d: geometry type %d, want POINT", want.Name, j, gotFeature.geomType)
+			}
+			if gotFeature.x != int64(wantFeature.X) || gotFeature.y != int64(wantFeature.Y) {
+				t.Fatalf("layer %s feature %d: got (%d, %d), want (%d, %d)", want.Name, j, gotFeature.x, gotFeature.y, wantFeature.X, wantFeature.Y)
+			}
+			if len(gotFeature.properties) != len(wantFeature.Properties) {
+				t.Fatalf("layer %s feature %d: got properties %v", want.Name, j, gotFeature.properties)
+			}
+			for _, prop := range wantFeature.Properties {
+				if gotFeature.properties[prop.Key] != prop.Value {
+					t.Fatalf("layer %s feature %d: %s = %#v, want %#v", want.Name, j, prop.Key, gotFeature.properties[prop.Key], prop.Value)
+				}
+			}
+		}
+	}
+
+	// Keys and values repeated between features are stored once per layer
+	pandals := decoded[0]
+	if len(pandals.keys) != 4 {
+		t.Fatalf("got keys %v, want each of the 4 keys once", pandals.keys)
+	}
+	if len(pandals.values) != 7 {
+		t.Fatalf("got values %v, want the shared 4.5 stored once", pandals.values)
+	}
+}
+
+func TestEncodeRejectsUnsupportedValues(t *testing.T) {
+	layers := []tiles.Layer{{Name: "pandals", Features: []tiles.Feature{
+		{Properties: []tiles.Property{{Key: "tags", Value: []string{"theme"}}}},
+	}}}
+	if _, err := tiles.Encode(layers); err == nil {
+		t.Fatal("a list value was encoded; MVT has no arrays")
+	}
+}
@@ -0,0 +1,116 @@
+package tiles
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+)
+
+const (
+	// Extent is the resolution of a tile's internal coordinate grid, the MVT default
+	Extent = 4096
+
+	// Buffer is how far, in extent units, features past a tile's edge are still
+	// included so that icons straddling the edge render on both tiles
+	Buffer = 64
+
+	// MaxZoom is the deepest zoom level served
+	MaxZoom = 22
+
+	// maxMercatorLat is the latitude at which Web Mercator tiles end
+	maxMercatorLat = 85.05112878
+)
+
+// ErrInvalidTile is returned for tile coordinates outside the tile pyramid
+var ErrInvalidTile = errors.New("invalid tile coordinates")
+
+// Tile addresses one Web Mercator (XYZ) tile
+type Tile struct {
+	Z, X, Y int
+}
+
+// Parse reads and range checks z/x/y tile coordinates
+func Parse(z, x, y string) (Tile, error) {
+	var t Tile
+	var err1, err2, err3 error
+	t.Z, err1 = strconv.Atoi(z)
+	t.X, err2 = strconv.Atoi(x)
+	t.Y, err3 = strconv.Atoi(y)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return Tile{}, ErrInvalidTile
+	}
+	if t.Z < 0 || t.Z > MaxZoom {
+		return Tile{}, ErrInvalidTile
+	}
+	if n := 1 << t.Z; t.X < 0 || t.X >= n || t.Y < 0 || t.Y >= n {
+		return Tile{}, ErrInvalidTile
+	}
+	return t, nil
+}
+
+// String formats the tile as z/x/y
+func (t Tile) String() string {
+	return strconv.Itoa(t.Z) + "/" + strconv.Itoa(t.X) + "/" + strconv.Itoa(t.Y)
+}
+
+// Bounds returns the south-west and north-east corners of the tile, widened on each
+// side by margin tile widths
+func (t Tile) Bounds(margin float64) (sw, ne geo.Point) {
+	n := float64(int(1) << t.Z)
+	x0, x1 := float64(t.X)-margin, float64(t.X)+1+margin
+	y0, y1 := float64(t.Y)-margin, float64(t.Y)+1+margin
+
+	sw = geo.Point{Lng: tileLng(x0, n), Lat: tileLat(y1, n)}
+	ne = geo.Point{Lng: tileLng(x1, n), Lat: tileLat(y0, n)}
+	return sw, ne
+}
+
+// Project converts p into the tile's coordinate grid, where (0, 0) is the north-west
+// corner and (Extent, Extent) the south-east one
+func (t Tile) Project(p geo.Point) (x, y int) {
+	fx, fy := fractional(p, t.Z)
+	x = int(math.Round((fx - float64(t.X)) * Extent))
+	y = int(math.Round((fy - float64(t.Y)) * Extent))
+	return x, y
+}
+
+// Covering returns the tiles at zoom z whose buffered area contains p: the tile
+// the point is in, plus neighbours when it lies within Buffer of an edge
+func Covering(p geo.Point, z int) []Tile {
+	fx, fy := fractional(p, z)
+	n := 1 << z
+	margin := float64(Buffer) / Extent
+
+	var covering []Tile
+	for x := int(math.Floor(fx - margin)); x <= int(math.Floor(fx+margin)); x++ {
+		for y := int(math.Floor(fy - margin)); y <= int(math.Floor(fy+margin)); y++ {
+			if x >= 0 && x < n && y >= 0 && y < n {
+				covering = append(covering, Tile{Z: z, X: x, Y: y})
+			}
+		}
+	}
+	return covering
+}
+
+// fractional returns p's position in tile units at zoom z
+func fractional(p geo.Point, z int) (fx, fy float64) {
+	n := float64(int(1) << z)
+	lat := math.Max(-maxMercatorLat, math.Min(maxMercatorLat, p.Lat)) * math.Pi / 180
+
+	fx = (p.Lng + 180) / 360 * n
+	fy = (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * n
+	return fx, fy
+}
+
+// tileLng is the longitude of the tile column edge x at n tiles per side
+func tileLng(x, n float64) float64 {
+	return math.Max(-180, math.Min(180, x/n*360-180))
+}
+
+// tileLat is the latitude of the tile row edge y at n tiles per side
+func tileLat(y, n float64) float64 {
+	lat := math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+	return math.Max(-90, math.Min(90, lat))
+}
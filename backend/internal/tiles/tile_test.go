@@ -0,0 +1,72 @@
+package tiles_test
+
+import (
+	"slices"
+	"testing"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+	"tirthankarkundu17/pandal-hopping-api/internal/tiles"
+)
+
+// pointIn returns the point at fraction (fx, fy) across tile t from its north-west
+// corner; fractions outside [0, 1] land in the neighbouring tiles
+func pointIn(t tiles.Tile, fx, fy float64) geo.Point {
+	sw, ne := t.Bounds(0)
+	// Linear in latitude is close enough to Mercator within one tile at these zooms
+	return geo.Point{
+		Lng: sw.Lng + fx*(ne.Lng-sw.Lng),
+		Lat: ne.Lat - fy*(ne.Lat-sw.Lat),
+	}
+}
+
+func TestCovering(t *testing.T) {
+	// A zoom 14 tile over north Kolkata
+	home := tiles.Tile{Z: 14, X: 12213, Y: 7178}
+	// Well within Buffer of an edge, and well clear of it
+	const near, far = 0.005, 0.5
+	west := tiles.Tile{Z: 14, X: home.X - 1, Y: home.Y}
+	north := tiles.Tile{Z: 14, X: home.X, Y: home.Y - 1}
+	east := tiles.Tile{Z: 14, X: home.X + 1, Y: home.Y}
+	south := tiles.Tile{Z: 14, X: home.X, Y: home.Y + 1}
+	northWest := tiles.Tile{Z: 14, X: home.X - 1, Y: home.Y - 1}
+
+	tests := []struct {
+		name string
+		p    geo.Point
+		z    int
+		want []tiles.Tile
+	}{
+		{"middle of a tile", pointIn(home, far, far), 14, []tiles.Tile{home}},
+		{"inside the west edge", pointIn(home, near, far), 14, []tiles.Tile{home, west}},
+		{"inside the east edge", pointIn(home, 1-near, far), 14, []tiles.Tile{home, east}},
+		{"inside the north edge", pointIn(home, far, near), 14, []tiles.Tile{home, north}},
+		{"inside the south edge", pointIn(home, far, 1-near), 14, []tiles.Tile{home, south}},
+		{"just past the west edge", pointIn(home, -near, far), 14, []tiles.Tile{home, west}},
+		{"north-west corner", pointIn(home, near, near), 14, []tiles.Tile{home, west, north, northWest}},
+		{"past the buffer", pointIn(home, -0.1, far), 14, []tiles.Tile{west}},
+		{"whole world at zoom 0", geo.Point{Lng: 88.36, Lat: 22.57}, 0, []tiles.Tile{{Z: 0, X: 0, Y: 0}}},
+		{"antimeridian has no tile west of it", geo.Point{Lng: -180, Lat: 40}, 2, []tiles.Tile{{Z: 2, X: 0, Y: 1}}},
+		{"pole is clamped to the top row", geo.Point{Lng: 10, Lat: 89.9}, 3, []tiles.Tile{{Z: 3, X: 4, Y: 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tiles.Covering(tt.p, tt.z)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for _, want := range tt.want {
+				if !slices.Contains(got, want) {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+
+			// Every covering tile has the point within its buffered extent
+			for _, tile := range got {
+				x, y := tile.Project(tt.p)
+				if x < -tiles.Buffer || x > tiles.Extent+tiles.Buffer || y < -tiles.Buffer || y > tiles.Extent+tiles.Buffer {
+					t.Fatalf("%s projects the point to (%d, %d), outside its buffer", tile, x, y)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/data"
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+)
+
+// BoundaryToleranceMeters is how far outside its district's outline a pin may fall and
+// still be accepted. The bundled outlines are simplified, so edges are only approximate;
+// see the source and licence notes at the top of district-boundaries.geojson.
+const BoundaryToleranceMeters = 2000.0
+
+// districtBoundary is the outline of one district from district-boundaries.geojson
+type districtBoundary struct {
+	Country  string
+	State    string
+	District string
+	Polygons []geo.Polygon
+	Area     float64
+}
+
+// ResolvedLocation is the administrative area a point falls in
+type ResolvedLocation struct {
+	Country      string `json:"country"`
+	CountryName  string `json:"countryName"`
+	State        string `json:"state"`
+	StateName    string `json:"stateName"`
+	District     string `json:"district"`
+	DistrictName string `json:"districtName"`
+}
+
+var boundaries []districtBoundary
+
+// stateBounds holds the bounding box of each state's district outlines, keyed by country/state code
+var stateBounds map[string]bounds
+
+// bounds is a longitude/latitude bounding box
+type bounds struct {
+	MinLng, MinLat, MaxLng, MaxLat float64
+}
+
+// extend grows the box to include p
+func (b bounds) extend(p geo.Point) bounds {
+	return bounds{
+		MinLng: math.Min(b.MinLng, p.Lng),
+		MinLat: math.Min(b.MinLat, p.Lat),
+		MaxLng: math.Max(b.MaxLng, p.Lng),
+		MaxLat: math.Max(b.MaxLat, p.Lat),
+	}
+}
+
+// contains reports whether p lies in the box
+func (b bounds) contains(p geo.Point) bool {
+	return p.Lng >= b.MinLng && p.Lng <= b.MaxLng && p.Lat >= b.MinLat && p.Lat <= b.MaxLat
+}
+
+// boundaryFile is the subset of GeoJSON read from district-boundaries.geojson
+type boundaryFile struct {
+	Features []struct {
+		Properties struct {
+			Country  string `json:"country"`
+			State    string `json:"state"`
+			District string `json:"district"`
+		} `json:"properties"`
+		Geometry struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// loadDistrictBoundaries parses the embedded district outlines.
+// It is called by LoadAdministrativeData.
+func loadDistrictBoundaries() error {
+	var file boundaryFile
+	if err := json.Unmarshal(data.DistrictBoundariesGeoJSON, &file); err != nil {
+		return fmt.Errorf("could not unmarshal district boundaries: %w", err)
+	}
+
+	loaded := make([]districtBoundary, 0, len(file.Features))
+	loadedBounds := make(map[string]bounds)
+	for _, feature := range file.Features {
+		props := feature.Properties
+
+		var raw [][][][]float64
+		switch feature.Geometry.Type {
+		case "Polygon":
+			var polygon [][][]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &polygon); err != nil {
+				return fmt.Errorf("district %s: %w", props.District, err)
+			}
+			raw = [][][][]float64{polygon}
+		case "MultiPolygon":
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &raw); err != nil {
+				return fmt.Errorf("district %s: %w", props.District, err)
+			}
+		default:
+			return fmt.Errorf("district %s: unsupported geometry %q", props.District, feature.Geometry.Type)
+		}
+
+		boundary := districtBoundary{Country: props.Country, State: props.State, District: props.District}
+		key := props.Country + "/" + props.State
+		box, seen := loadedBounds[key]
+		if !seen {
+			box = bounds{MinLng: math.Inf(1), MinLat: math.Inf(1), MaxLng: math.Inf(-1), MaxLat: math.Inf(-1)}
+		}
+		for _, rawPolygon := range raw {
+			polygon := make(geo.Polygon, 0, len(rawPolygon))
+			for _, rawRing := range rawPolygon {
+				ring := make(geo.Ring, 0, len(rawRing))
+				for _, coordinates := range rawRing {
+					point, ok := geo.FromCoordinates(coordinates)
+					if !ok {
+						return fmt.Errorf("district %s: invalid coordinates %v", props.District, coordinates)
+					}
+					ring = append(ring, point)
+					box = box.extend(point)
+				}
+				polygon = append(polygon, ring)
+			}
+			boundary.Polygons = append(boundary.Polygons, polygon)
+			boundary.Area += polygon.AreaSqMeters()
+		}
+		loaded = append(loaded, boundary)
+		loadedBounds[key] = box
+	}
+
+	boundaries = loaded
+	stateBounds = loadedBounds
+	return nil
+}
+
+// contains reports whether the district's outline contains p
+func (b districtBoundary) contains(p geo.Point) bool {
+	for _, polygon := range b.Polygons {
+		if polygon.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// distanceMeters is how far p lies outside the district, 0 when inside
+func (b districtBoundary) distanceMeters(p geo.Point) float64 {
+	best := math.Inf(1)
+	for _, polygon := range b.Polygons {
+		best = math.Min(best, polygon.DistanceMeters(p))
+	}
+	return best
+}
+
+// edgeMeters is how far p lies from the district's outline, inside or out
+func (b districtBoundary) edgeMeters(p geo.Point) float64 {
+	best := math.Inf(1)
+	for _, polygon := range b.Polygons {
+		best = math.Min(best, polygon.EdgeDistanceMeters(p))
+	}
+	return best
+}
+
+// ValidateDistrictPoint checks that a [lng, lat] pin lies in the district it claims.
+// Pins up to BoundaryToleranceMeters outside the outline are accepted, and districts
+// without an outline are not checked.
+func ValidateDistrictPoint(countryCode, stateCode, districtCode string, coordinates []float64) error {
+	point, ok := geo.FromCoordinates(coordinates)
+	if !ok {
+		return nil
+	}
+
+	for _, boundary := range boundaries {
+		if boundary.Country != countryCode || boundary.State != stateCode || boundary.District != districtCode {
+			continue
+		}
+		if boundary.distanceMeters(point) <= BoundaryToleranceMeters {
+			return nil
+		}
+
+		if actual, found := ResolveLocation(point.Lng, point.Lat); found {
+			return fmt.Errorf("location is in district %s (%s), not %s", actual.District, actual.DistrictName, districtCode)
+		}
+		return fmt.Errorf("location is outside district %s", districtCode)
+	}
+
+	return nil
+}
+
+// ResolveLocation finds the state and district containing a point.
+// Where outlines overlap (police districts such as Siliguri lie inside revenue
+// districts) the smallest, most specific district wins. The outlines are only good to
+// BoundaryToleranceMeters, so a point that close to any district's edge could lie on
+// either side of it and is not resolved at all rather than guessed.
+func ResolveLocation(lng, lat float64) (ResolvedLocation, bool) {
+	point := geo.Point{Lng: lng, Lat: lat}
+
+	var match *districtBoundary
+	for i := range boundaries {
+		boundary := &boundaries[i]
+		if boundary.edgeMeters(point) < BoundaryToleranceMeters {
+			return ResolvedLocation{}, false
+		}
+		if !boundary.contains(point) {
+			continue
+		}
+		if match == nil || boundary.Area < match.Area {
+			match = boundary
+		}
+	}
+	if match == nil {
+		return ResolvedLocation{}, false
+	}
+
+	resolved := ResolvedLocation{
+		Country:      match.Country,
+		State:        match.State,
+		District:     match.District,
+		DistrictName: GetDistrictName(match.Country, match.State, match.District),
+	}
+	if adminData.Country.Code == match.Country {
+		resolved.CountryName = adminData.Country.Name
+	}
+	for _, state := range adminData.States {
+		if state.Code == match.State {
+			resolved.StateName = state.Name
+			break
+		}
+	}
+	return resolved, true
+}
@@ -0,0 +1,41 @@
+package validation_test
+
+import (
+	"testing"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/validation"
+)
+
+func TestResolveLocation(t *testing.T) {
+	if err := validation.LoadAdministrativeData(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		lng, lat float64
+		district string // empty when the point must not be resolved
+	}{
+		{"well inside Kolkata", 88.3667, 22.5186, "KOL"},
+		{"well inside Howrah", 88.3100, 22.5900, "HWH"},
+		{"police district inside a revenue district", 88.4300, 26.7100, "SLG"},
+		// The outlines cannot tell these apart from their neighbours
+		{"Kalyani, near the Hooghly and Nadia edges", 88.4345, 22.9751, ""},
+		{"Garia, near the Kolkata edge", 88.3869, 22.4633, ""},
+		{"outside West Bengal", 77.2090, 28.6139, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, ok := validation.ResolveLocation(tt.lng, tt.lat)
+			if tt.district == "" {
+				if ok {
+					t.Fatalf("resolved to %s, want no district", resolved.District)
+				}
+				return
+			}
+			if !ok || resolved.District != tt.district {
+				t.Fatalf("got %q (found %v), want %s", resolved.District, ok, tt.district)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+)
+
+// ValidateCoordinates checks that a GeoJSON [lng, lat] pair is inside the valid
+// longitude [-180, 180] and latitude [-90, 90] ranges.
+func ValidateCoordinates(coordinates []float64) error {
+	if len(coordinates) != 2 {
+		return fmt.Errorf("coordinates must be [lng, lat], got %d values", len(coordinates))
+	}
+
+	lng, lat := coordinates[0], coordinates[1]
+	if math.IsNaN(lng) || math.IsInf(lng, 0) || lng < -180 || lng > 180 {
+		return fmt.Errorf("longitude %v is out of range [-180, 180]", lng)
+	}
+	if math.IsNaN(lat) || math.IsInf(lat, 0) || lat < -90 || lat > 90 {
+		return fmt.Errorf("latitude %v is out of range [-90, 90]", lat)
+	}
+	return nil
+}
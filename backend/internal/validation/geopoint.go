@@ -0,0 +1,62 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"tirthankarkundu17/pandal-hopping-api/internal/geo"
+	"tirthankarkundu17/pandal-hopping-api/internal/models"
+)
+
+// swapMarginDegrees pads state bounding boxes when looking for swapped axes,
+// so pins just over a border are not mistaken for swapped ones
+const swapMarginDegrees = 0.1
+
+// ValidateGeoPoint checks that location is a GeoJSON Point with a valid [lng, lat] pair
+// and normalises it in place: the type is written as "Point" whatever its case, and an
+// altitude, which GeoJSON allows as a third coordinate, is dropped.
+//
+// A pin that lies outside the declared state but inside it once longitude and latitude
+// are swapped is rejected as swapped. With empty codes every state with outlines is tried.
+func ValidateGeoPoint(location *models.Location, countryCode, stateCode string) error {
+	if location == nil {
+		return errors.New("location is required")
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(location.Type), "Point") {
+		return fmt.Errorf("location type must be \"Point\", got %q", location.Type)
+	}
+	location.Type = "Point"
+
+	if len(location.Coordinates) == 3 {
+		location.Coordinates = location.Coordinates[:2]
+	}
+	if len(location.Coordinates) == 2 && looksSwapped(location.Coordinates, countryCode, stateCode) {
+		return fmt.Errorf("coordinates %v look like [lat, lng]; GeoJSON points are [lng, lat]", location.Coordinates)
+	}
+	return ValidateCoordinates(location.Coordinates)
+}
+
+// looksSwapped reports whether a [lng, lat] pair falls outside the state's bounding box
+// but inside it with the axes swapped. States without outlines are never flagged.
+func looksSwapped(coordinates []float64, countryCode, stateCode string) bool {
+	point := geo.Point{Lng: coordinates[0], Lat: coordinates[1]}
+	swapped := geo.Point{Lng: coordinates[1], Lat: coordinates[0]}
+
+	inside, insideSwapped := false, false
+	for key, box := range stateBounds {
+		if stateCode != "" && key != countryCode+"/"+stateCode {
+			continue
+		}
+		box = bounds{
+			MinLng: box.MinLng - swapMarginDegrees,
+			MinLat: box.MinLat - swapMarginDegrees,
+			MaxLng: box.MaxLng + swapMarginDegrees,
+			MaxLat: box.MaxLat + swapMarginDegrees,
+		}
+		inside = inside || box.contains(point)
+		insideSwapped = insideSwapped || box.contains(swapped)
+	}
+	return !inside && insideSwapped
+}
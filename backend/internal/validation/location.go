@@ -37,14 +37,14 @@ type AdministrativeData struct {
 
 var adminData AdministrativeData
 
-// LoadAdministrativeData loads the data from the embedded file
+// LoadAdministrativeData loads the data and district boundaries from the embedded files
 // This should be called once on app startup.
 func LoadAdministrativeData() error {
 	if err := json.Unmarshal(data.IndiaAdministrativeJSON, &adminData); err != nil {
 		return fmt.Errorf("could not unmarshal administrative data: %w", err)
 	}
 
-	return nil
+	return loadDistrictBoundaries()
 }
 
 // GetAdministrativeData returns the loaded administrative data with optional filtering.
@@ -109,6 +109,21 @@ func ValidateLocation(countryCode, stateCode, districtCode string) error {
 	return nil
 }
 
+// ValidateDistrictCode checks that districtCode is an active district of any active state
+func ValidateDistrictCode(districtCode string) error {
+	for _, state := range adminData.States {
+		if !state.IsActive {
+			continue
+		}
+		for _, district := range state.Districts {
+			if district.IsActive && district.Code == districtCode {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unsupported or inactive district: %s", districtCode)
+}
+
 // GetDistrictName looks up the human-readable name for a given country, state, and district code
 // Returns the original code if the name cannot be found
 func GetDistrictName(countryCode, stateCode, districtCode string) string {